@@ -0,0 +1,141 @@
+package serial
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrAudit is returned when asked to create a generator with an audit sink
+// which is nil, or a sampling rate less than 1.
+var ErrAudit = errors.New("serial: invalid audit configuration")
+
+// AuditSink receives the audit records of a generator created with
+// WithAudit. Audit is called synchronously by the goroutine performing each
+// action, before the action's method returns, so it must be safe for
+// concurrent use, and should be quick.
+type AuditSink interface {
+	Audit(e Event)
+}
+
+// AuditFunc adapts a function to an AuditSink.
+type AuditFunc func(e Event)
+
+// Audit implements AuditSink.
+func (f AuditFunc) Audit(e Event) {
+	f(e)
+}
+
+// WithAudit records the generator's actions to an audit sink, so that there
+// is a lasting record of when each value was issued and consumed: an Event
+// for every value generated, every value newly flagged as seen or unset, and
+// every expiry of the seen history. Unlike the Events channel, nothing is
+// dropped.
+//
+// Generated values are usually far more numerous than seen ones, so if
+// sample is greater than 1, only one in every sample generated values is
+// recorded. Other events are always recorded. As with Events, blocks claimed
+// with ReserveBlock aren't recorded.
+func WithAudit(sink AuditSink, sample int) Option {
+	return func(g *Generator) error {
+		if sink == nil || sample < 1 {
+			return ErrAudit
+		}
+		g.audit = &auditor{sink: sink, sample: uint64(sample)}
+		return nil
+	}
+}
+
+// auditor holds the audit configuration of a generator.
+type auditor struct {
+	sink   AuditSink
+	sample uint64
+	n      atomic.Uint64
+}
+
+// record passes e to the sink, subject to sampling.
+func (a *auditor) record(e Event) {
+	if e.Kind == EventGenerate && a.sample > 1 && (a.n.Add(1)-1)%a.sample != 0 {
+		return
+	}
+	a.sink.Audit(e)
+}
+
+// AuditWriter is an AuditSink which writes each record to an io.Writer as a
+// line of JSON, such as:
+//
+//	{"time":"2023-11-14T22:13:20Z","event":"seen","serial":1700000000000000000}
+//
+// Expiry records have a count of values removed in place of a serial.
+type AuditWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+	err   error
+}
+
+// NewAuditWriter returns an AuditWriter which writes to w.
+func NewAuditWriter(w io.Writer) *AuditWriter {
+	return &AuditWriter{w: w}
+}
+
+// auditRecord is the JSON form of an audit record.
+type auditRecord struct {
+	Time   time.Time `json:"time"`
+	Event  string    `json:"event"`
+	Serial *Serial   `json:"serial,omitempty"`
+	Count  *int      `json:"count,omitempty"`
+}
+
+// Audit implements AuditSink. Once a write has failed, nothing more is
+// written, and the error is available from Err.
+func (aw *AuditWriter) Audit(e Event) {
+	rec := auditRecord{Time: e.Time.UTC(), Event: e.Kind.String()}
+	if e.Kind == EventExpire {
+		rec.Count = &e.Count
+	} else {
+		rec.Serial = &e.Serial
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+	aw.mutex.Lock()
+	if aw.err == nil {
+		_, aw.err = aw.w.Write(b)
+	}
+	aw.mutex.Unlock()
+}
+
+// Err returns the first error encountered writing records, if any.
+func (aw *AuditWriter) Err() error {
+	aw.mutex.Lock()
+	defer aw.mutex.Unlock()
+	return aw.err
+}
+
+// AuditLogger returns an AuditSink which logs each record to l at the
+// specified level, with the message "serial audit" and attributes event,
+// serial and count, and the time of the action as the record's time.
+func AuditLogger(l *slog.Logger, level slog.Level) AuditSink {
+	return AuditFunc(func(e Event) {
+		ctx := context.Background()
+		h := l.Handler()
+		if !h.Enabled(ctx, level) {
+			return
+		}
+		r := slog.NewRecord(e.Time, level, "serial audit", 0)
+		r.AddAttrs(slog.String("event", e.Kind.String()))
+		if e.Kind == EventExpire {
+			r.AddAttrs(slog.Int("count", e.Count))
+		} else {
+			r.AddAttrs(slog.Int64("serial", int64(e.Serial)))
+		}
+		h.Handle(ctx, r)
+	})
+}
@@ -0,0 +1,86 @@
+package serial
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAudit(t *testing.T) {
+	if _, err := New(WithAudit(nil, 1)); err != ErrAudit {
+		t.Errorf("Expected ErrAudit got %v", err)
+	}
+	if _, err := New(WithAudit(AuditFunc(func(Event) {}), 0)); err != ErrAudit {
+		t.Errorf("Expected ErrAudit got %v", err)
+	}
+	var buf bytes.Buffer
+	aw := NewAuditWriter(&buf)
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk), WithAudit(aw, 5))
+	xs := g.GenerateN(10)
+	g.SetSeen(xs[3])
+	g.UnsetSeen(xs[3])
+	g.SetSeen(xs[4])
+	clk.Advance(time.Hour)
+	g.ExpireSeen(time.Minute)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		`{"time":"1970-01-01T00:16:40Z","event":"generate","serial":1000000000000}`,
+		`{"time":"1970-01-01T00:16:40Z","event":"generate","serial":1000000000005}`,
+		`{"time":"1970-01-01T00:16:40Z","event":"seen","serial":1000000000003}`,
+		`{"time":"1970-01-01T00:16:40Z","event":"unset","serial":1000000000003}`,
+		`{"time":"1970-01-01T00:16:40Z","event":"seen","serial":1000000000004}`,
+		`{"time":"1970-01-01T01:16:40Z","event":"expire","count":1}`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("Expected %d records got %q", len(want), buf.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("Expected %s got %s", want[i], lines[i])
+		}
+	}
+	if aw.Err() != nil {
+		t.Error(aw.Err())
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestAuditWriterError(t *testing.T) {
+	aw := NewAuditWriter(failWriter{})
+	g := NewGenerator(WithAudit(aw, 1))
+	g.Generate()
+	if aw.Err() == nil {
+		t.Error("Expected write error")
+	}
+}
+
+func TestAuditLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slog.NewJSONHandler(&buf, nil))
+	g := NewDeterministicGenerator(42, WithAudit(AuditLogger(l, slog.LevelInfo), 1))
+	g.SetSeen(g.Generate())
+	var rec map[string]interface{}
+	line := strings.SplitN(buf.String(), "\n", 3)[1]
+	if err := json.Unmarshal([]byte(line), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["msg"] != "serial audit" || rec["event"] != "seen" || rec["serial"] != 42.0 {
+		t.Errorf("Wrong log record %s", line)
+	}
+	buf.Reset()
+	g = NewGenerator(WithAudit(AuditLogger(l, slog.LevelDebug), 1))
+	g.Generate()
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing logged below the handler's level, got %s", buf.String())
+	}
+}
@@ -0,0 +1,52 @@
+package serial
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrAutoExpire is returned when asked to create a generator which expires
+// its seen history automatically at an interval which isn't positive.
+var ErrAutoExpire = errors.New("serial: invalid automatic expiry interval")
+
+// WithAutoExpire starts a background goroutine which calls ExpireSeen with
+// the specified age limit at the specified interval, so the application
+// doesn't need its own expiry loop. The goroutine runs until Close is called.
+func WithAutoExpire(interval, agelimit time.Duration) Option {
+	return func(g *Generator) error {
+		if interval <= 0 {
+			return ErrAutoExpire
+		}
+		g.autoInterval = interval
+		g.autoAge = agelimit
+		return nil
+	}
+}
+
+// background runs automatic expiry and drift monitoring, whichever are
+// enabled, until the generator is closed.
+func (g *Generator) background() {
+	defer close(g.done)
+	var expiry, drift <-chan time.Time
+	if g.autoInterval > 0 {
+		ticker := time.NewTicker(g.autoInterval)
+		defer ticker.Stop()
+		expiry = ticker.C
+	}
+	if g.drift != nil {
+		ticker := time.NewTicker(g.drift.interval)
+		defer ticker.Stop()
+		drift = ticker.C
+		g.drift.start(g.clock)
+	}
+	for {
+		select {
+		case <-expiry:
+			g.ExpireSeen(g.autoAge)
+		case <-drift:
+			g.drift.check(g.clock)
+		case <-g.stop:
+			return
+		}
+	}
+}
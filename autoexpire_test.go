@@ -0,0 +1,38 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAutoExpire(t *testing.T) {
+	if _, err := New(WithAutoExpire(0, time.Second)); err != ErrAutoExpire {
+		t.Errorf("Expected ErrAutoExpire, got %v", err)
+	}
+	g := NewGenerator(WithAutoExpire(10*time.Millisecond, 0))
+	defer g.Close()
+	x := g.Generate()
+	g.SetSeen(x)
+	deadline := time.Now().Add(5 * time.Second)
+	for g.Seen(x) {
+		if time.Now().After(deadline) {
+			t.Fatal("Value wasn't expired automatically")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := g.Close(); err != nil {
+		t.Errorf("Close returned %v", err)
+	}
+	g.SetSeen(x)
+	time.Sleep(30 * time.Millisecond)
+	if !g.Seen(x) {
+		t.Error("Value expired after generator was closed")
+	}
+}
+
+func TestCloseWithoutAutoExpire(t *testing.T) {
+	g := NewGenerator()
+	if err := g.Close(); err != nil {
+		t.Errorf("Close returned %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package serial
+
+import (
+	"errors"
+	"strings"
+)
+
+// crockford is Douglas Crockford's Base32 alphabet, which omits I, L, O and U
+// to avoid transcription errors.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// crockfordCheck extends the alphabet with the five extra symbols used only
+// for the check symbol.
+const crockfordCheck = crockford + "*~$=U"
+
+var (
+	// ErrInvalidBase32 is returned when parsing a string which isn't a valid
+	// Base32 serial value.
+	ErrInvalidBase32 = errors.New("serial: invalid Base32 serial")
+	// ErrChecksum is returned when a serial value's check symbol or check
+	// digit doesn't match, indicating a transcription error.
+	ErrChecksum = errors.New("serial: checksum mismatch")
+)
+
+// Base32 returns the serial value encoded in Douglas Crockford's Base32, as
+// described at <https://www.crockford.com/base32.html>. The alphabet avoids
+// letters which are easily mistaken for digits or each other, so the encoding
+// is well suited to being printed and read back by people. It always returns
+// 13 characters, padding with leading zeros, and the alphabet is in ASCII
+// order, so encodings sort as strings in the same order as the values they
+// encode, as for Key.
+func (x Serial) Base32() string {
+	var b [13]byte
+	n := uint64(x)
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = crockford[n&31]
+		n >>= 5
+	}
+	return string(b[:])
+}
+
+// Base32Check returns the serial value encoded in Crockford's Base32, followed
+// by a check symbol which allows ParseBase32Check to detect most transcription
+// errors.
+func (x Serial) Base32Check() string {
+	return x.Base32() + string(crockfordCheck[uint64(x)%37])
+}
+
+// ParseBase32 parses a serial value encoded by Base32. Upper and lower case
+// are accepted, I and L are read as 1, O is read as 0, and hyphens are
+// ignored, as the encoding specifies. Any other character, or a number of
+// symbols other than 13, is an error.
+func ParseBase32(s string) (Serial, error) {
+	s = strings.Replace(s, "-", "", -1)
+	if len(s) != 13 {
+		return 0, ErrInvalidBase32
+	}
+	var n uint64
+	for i := 0; i < len(s); i++ {
+		v := crockfordValue(s[i])
+		if v < 0 || i == 0 && v > 15 {
+			return 0, ErrInvalidBase32
+		}
+		n = n<<5 | uint64(v)
+	}
+	return Serial(n), nil
+}
+
+// ParseBase32Check parses a serial value encoded by Base32Check, returning
+// ErrChecksum if the check symbol doesn't match.
+func ParseBase32Check(s string) (Serial, error) {
+	s = strings.Replace(s, "-", "", -1)
+	if len(s) != 14 {
+		return 0, ErrInvalidBase32
+	}
+	x, err := ParseBase32(s[:13])
+	if err != nil {
+		return 0, err
+	}
+	c := crockfordCheckValue(s[13])
+	if c < 0 {
+		return 0, ErrInvalidBase32
+	}
+	if uint64(c) != uint64(x)%37 {
+		return 0, ErrChecksum
+	}
+	return x, nil
+}
+
+// crockfordCheckValue returns the value of a check symbol, or -1 if it isn't
+// a valid check symbol.
+func crockfordCheckValue(c byte) int {
+	switch c {
+	case '*':
+		return 32
+	case '~':
+		return 33
+	case '$':
+		return 34
+	case '=':
+		return 35
+	case 'U', 'u':
+		return 36
+	}
+	return crockfordValue(c)
+}
+
+// crockfordValue returns the value of a character in Crockford's Base32
+// alphabet, ignoring case, or -1 if it isn't part of the alphabet. As the
+// alphabet specifies, I and L are read as 1, and O as 0.
+func crockfordValue(c byte) int {
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	switch c {
+	case 'I', 'L':
+		return 1
+	case 'O':
+		return 0
+	}
+	for i := 0; i < len(crockford); i++ {
+		if crockford[i] == c {
+			return i
+		}
+	}
+	return -1
+}
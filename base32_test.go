@@ -0,0 +1,53 @@
+package serial
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBase32(t *testing.T) {
+	g := NewGenerator()
+	for _, x := range []Serial{0, 1, 31, 32, 36, g.Generate(), 1<<63 - 1, -1} {
+		s := x.Base32()
+		if len(s) != 13 {
+			t.Fatalf("Wrong length encoding %q", s)
+		}
+		y, err := ParseBase32(strings.ToLower(s[:5]) + "-" + s[5:])
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", s, err)
+		}
+		if y != x {
+			t.Errorf("Round trip failed, expected %d got %d via %q", x, y, s)
+		}
+		s = x.Base32Check()
+		y, err = ParseBase32Check(s)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", s, err)
+		}
+		if y != x {
+			t.Errorf("Round trip with check failed, expected %d got %d via %q", x, y, s)
+		}
+	}
+	if x, err := ParseBase32("000000000000O"); err != nil || x != 0 {
+		t.Errorf("Failed to read O as 0, got %d %v", x, err)
+	}
+	if x, err := ParseBase32("00000000000il"); err != nil || x != 33 {
+		t.Errorf("Failed to read I and L as 1, got %d %v", x, err)
+	}
+}
+
+func TestParseBase32Errors(t *testing.T) {
+	for _, s := range []string{"", "000000000000", "000000000000U", "0000000000_00", "G000000000000"} {
+		if _, err := ParseBase32(s); err != ErrInvalidBase32 {
+			t.Errorf("Expected ErrInvalidBase32 for %q, got %v", s, err)
+		}
+	}
+	s := Serial(123456789).Base32Check()
+	typo := strings.Replace(s, "3", "4", 1)
+	if _, err := ParseBase32Check(typo); err != ErrChecksum {
+		t.Errorf("Expected ErrChecksum for %q, got %v", typo, err)
+	}
+	if _, err := ParseBase32Check(s[:13] + "!"); err != ErrInvalidBase32 {
+		t.Errorf("Expected ErrInvalidBase32 for bad check symbol, got %v", err)
+	}
+}
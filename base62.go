@@ -0,0 +1,82 @@
+package serial
+
+import (
+	"errors"
+)
+
+// base62 is the alphabet used for Base62 encodings. Digits come before
+// upper case before lower case, as in ASCII, so that fixed width encodings
+// sort in the same order as the values they encode.
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ErrInvalidBase62 is returned when parsing a string which isn't a valid
+// Base62 serial value.
+var ErrInvalidBase62 = errors.New("serial: invalid Base62 serial")
+
+// Base62 returns the serial value encoded in Base62, using the digits and
+// the upper and lower case letters, for compact use in URLs. The encoding has
+// no padding, so takes up to 11 characters.
+func (x Serial) Base62() string {
+	var b [11]byte
+	i := len(b)
+	n := uint64(x)
+	for {
+		i--
+		b[i] = base62[n%62]
+		n /= 62
+		if n == 0 {
+			break
+		}
+	}
+	return string(b[i:])
+}
+
+// Key returns the serial value encoded in Base62 padded with leading zeros
+// to a fixed width of 11 characters, so that keys sort as strings in the same
+// order as the values they encode, for use as object store keys or in text
+// database columns. Order is as for unsigned numbers, so negative values,
+// which generators never issue, sort after positive ones. Keys are parsed by
+// ParseBase62. Base32 also sorts correctly, and is easier for people to read
+// back, but takes 13 characters.
+func (x Serial) Key() string {
+	var b [11]byte
+	n := uint64(x)
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = base62[n%62]
+		n /= 62
+	}
+	return string(b[:])
+}
+
+// ParseBase62 parses a serial value encoded by Base62 or Key.
+func ParseBase62(s string) (Serial, error) {
+	if len(s) == 0 || len(s) > 11 {
+		return 0, ErrInvalidBase62
+	}
+	var n uint64
+	for i := 0; i < len(s); i++ {
+		v := base62Value(s[i])
+		if v < 0 {
+			return 0, ErrInvalidBase62
+		}
+		if n > (1<<64-1-uint64(v))/62 {
+			return 0, ErrInvalidBase62
+		}
+		n = n*62 + uint64(v)
+	}
+	return Serial(n), nil
+}
+
+// base62Value returns the value of a character in the Base62 alphabet, or -1
+// if it isn't part of the alphabet.
+func base62Value(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10
+	case c >= 'a' && c <= 'z':
+		return int(c-'a') + 36
+	}
+	return -1
+}
@@ -0,0 +1,53 @@
+package serial
+
+import (
+	"testing"
+)
+
+func TestBase62(t *testing.T) {
+	g := NewGenerator()
+	for _, x := range []Serial{0, 1, 61, 62, g.Generate(), 1<<63 - 1, -1} {
+		s := x.Base62()
+		y, err := ParseBase62(s)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", s, err)
+		}
+		if y != x {
+			t.Errorf("Round trip failed, expected %d got %d via %q", x, y, s)
+		}
+	}
+	if s := Serial(62).Base62(); s != "10" {
+		t.Errorf("Wrong encoding of 62, expected \"10\" got %q", s)
+	}
+}
+
+func TestParseBase62Errors(t *testing.T) {
+	for _, s := range []string{"", "abc-def", "LygHa16AHYG", "000000000000"} {
+		if _, err := ParseBase62(s); err != ErrInvalidBase62 {
+			t.Errorf("Expected ErrInvalidBase62 for %q, got %v", s, err)
+		}
+	}
+	if x, err := ParseBase62("LygHa16AHYF"); err != nil || x != -1 {
+		t.Errorf("Failed to parse maximum value, got %d %v", x, err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	g := NewGenerator()
+	xs := []Serial{0, 1, 61, 62, 3843, g.Generate(), g.Generate() + 1000, 1<<63 - 1}
+	for i, x := range xs {
+		k := x.Key()
+		if len(k) != 11 {
+			t.Errorf("Expected 11 characters got %q", k)
+		}
+		if y, err := ParseBase62(k); err != nil || y != x {
+			t.Errorf("Round trip failed, expected %d got %d, %v via %q", x, y, err, k)
+		}
+		if i > 0 && xs[i-1].Key() >= k {
+			t.Errorf("Keys out of order: %q then %q", xs[i-1].Key(), k)
+		}
+	}
+	if k := Serial(62).Key(); k != "00000000010" {
+		t.Errorf("Expected \"00000000010\" got %q", k)
+	}
+}
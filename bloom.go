@@ -0,0 +1,180 @@
+package serial
+
+import (
+	"errors"
+	"math"
+	"sync"
+)
+
+// ErrBloomFilter is returned when asked to create a generator with unusable
+// Bloom filter parameters.
+var ErrBloomFilter = errors.New("serial: invalid Bloom filter parameters")
+
+// bloomStore is a seenStore which keeps the history in a series of Bloom
+// filters, using a small fraction of the memory of an exact history at the
+// cost of occasional false positives. Each filter, or generation, holds up to
+// capacity values; when it fills up, a new one is started. Expiry drops whole
+// generations once every value in them is old enough, so values can outlive
+// the age limit by up to a generation. The length of the history is only
+// approximate, since values which appear to already be in a generation due to
+// a false positive aren't counted.
+type bloomStore struct {
+	mutex    sync.RWMutex
+	gens     []*bloomGen
+	capacity int
+	bits     uint64
+	hashes   int
+	lagw     lagWindow
+}
+
+// bloomGen is a single generation of a bloomStore. It can be dropped once
+// newest is below the expiry limit and the latest deadline has passed.
+type bloomGen struct {
+	bits     []uint64
+	count    int
+	newest   Serial
+	deadline int64
+}
+
+// WithBloomFilter makes the generator keep its seen history in rotating Bloom
+// filters rather than an exact set, for deployments where the history would
+// grow to tens of millions of values. Each filter holds capacity values, and
+// is sized so that Seen has a false positive rate of fpRate for it; the
+// overall rate is roughly fpRate times the number of filters needed to hold
+// the unexpired history. Seen never gives false negatives.
+func WithBloomFilter(capacity int, fpRate float64) Option {
+	return func(g *Generator) error {
+		if capacity < 1 || fpRate <= 0 || fpRate >= 1 {
+			return ErrBloomFilter
+		}
+		// The standard optimal sizing: m = -n ln p / (ln 2)^2 bits, with
+		// k = (m / n) ln 2 hash functions.
+		m := math.Ceil(-float64(capacity) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+		k := int(math.Max(1, math.Round(m/float64(capacity)*math.Ln2)))
+		g.store = &bloomStore{capacity: capacity, bits: uint64(m), hashes: k}
+		return nil
+	}
+}
+
+// locations calls f with each bit position for x, stopping if it returns
+// false, using double hashing of two halves of a 64 bit mix of x.
+func (st *bloomStore) locations(x Serial, f func(uint64) bool) {
+	h := mix64(uint64(x))
+	h1, h2 := h>>32, h&0xffffffff|1
+	for i := 0; i < st.hashes; i++ {
+		if !f((h1 + uint64(i)*h2) % st.bits) {
+			return
+		}
+	}
+}
+
+func (st *bloomStore) add(x Serial, e seenEntry, now int64) bool {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	seen := st.hasLocked(x)
+	if len(st.gens) == 0 || st.gens[len(st.gens)-1].count >= st.capacity {
+		st.gens = append(st.gens, &bloomGen{bits: make([]uint64, (st.bits+63)/64)})
+	}
+	// Even if x appears to be in an older generation, it has to be added
+	// to the current one, or it would be forgotten early if the match was
+	// a false positive.
+	gen := st.gens[len(st.gens)-1]
+	if seen && gen.has(st, x) {
+		return false
+	}
+	st.locations(x, func(b uint64) bool {
+		gen.bits[b/64] |= 1 << (b % 64)
+		return true
+	})
+	gen.count++
+	if e.deadline > 0 {
+		if e.deadline > gen.deadline {
+			gen.deadline = e.deadline
+		}
+	} else if k := e.key(x); k > gen.newest {
+		gen.newest = k
+	}
+	st.lagw.record(now, 1)
+	return !seen
+}
+
+func (st *bloomStore) has(x Serial) (seenEntry, bool) {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+	return seenEntry{}, st.hasLocked(x)
+}
+
+// hasLocked checks each generation for x. The caller must hold the mutex.
+func (st *bloomStore) hasLocked(x Serial) bool {
+	for _, gen := range st.gens {
+		if gen.has(st, x) {
+			return true
+		}
+	}
+	return false
+}
+
+// has checks whether x is in the generation.
+func (gen *bloomGen) has(st *bloomStore, x Serial) bool {
+	found := true
+	st.locations(x, func(b uint64) bool {
+		found = gen.bits[b/64]&(1<<(b%64)) != 0
+		return found
+	})
+	return found
+}
+
+// remove always fails, since values can't be removed from a Bloom filter
+// without risking removing others.
+func (st *bloomStore) remove(x Serial, now int64) bool {
+	return false
+}
+
+// each does nothing, since the values in a Bloom filter can't be recovered.
+func (st *bloomStore) each(f func(x Serial, e seenEntry) bool) {
+}
+
+// expire never reports the values removed, since they can't be recovered.
+func (st *bloomStore) expire(limit Serial, now int64, _ *[]Serial) int {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	removed := 0
+	keep := st.gens[:0]
+	for _, gen := range st.gens {
+		if gen.newest < limit && gen.deadline <= now {
+			removed += gen.count
+		} else {
+			keep = append(keep, gen)
+		}
+	}
+	for i := len(keep); i < len(st.gens); i++ {
+		st.gens[i] = nil
+	}
+	st.gens = keep
+	st.lagw.record(now, -int64(removed))
+	return removed
+}
+
+func (st *bloomStore) lag(now int64) int64 {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+	return st.lagw.sum(now)
+}
+
+func (st *bloomStore) len() int {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+	n := 0
+	for _, gen := range st.gens {
+		n += gen.count
+	}
+	return n
+}
+
+// mix64 is the finalizer of the SplitMix64 generator, which scrambles the
+// bits of sequential values thoroughly.
+func mix64(z uint64) uint64 {
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
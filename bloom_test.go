@@ -0,0 +1,56 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBloomFilter(t *testing.T) {
+	for _, c := range []struct {
+		n  int
+		fp float64
+	}{{0, 0.01}, {100, 0}, {100, 1}} {
+		if _, err := New(WithBloomFilter(c.n, c.fp)); err != ErrBloomFilter {
+			t.Errorf("Expected ErrBloomFilter for %d, %v, got %v", c.n, c.fp, err)
+		}
+	}
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	g := NewGenerator(WithClock(clock), WithBloomFilter(1000, 0.01))
+	old := g.GenerateN(1000)
+	for _, x := range old {
+		g.SetSeen(x)
+	}
+	clock.Advance(time.Hour)
+	recent := g.GenerateN(1000)
+	for _, x := range recent {
+		g.SetSeen(x)
+	}
+	for _, x := range append(old, recent...) {
+		if !g.Seen(x) {
+			t.Fatalf("False negative for %d", x)
+		}
+	}
+	fp := 0
+	unseen := g.GenerateN(10000)
+	for _, x := range unseen {
+		if g.Seen(x) {
+			fp++
+		}
+	}
+	// Two generations at 1% each.
+	if fp > 400 {
+		t.Errorf("Too many false positives: %d in 10000", fp)
+	}
+	if n := g.SeenCount(); n < 1950 || n > 2000 {
+		t.Errorf("History wrong length, expected about 2000 got %d", n)
+	}
+	g.ExpireSeen(30 * time.Minute)
+	if n := g.SeenCount(); n < 950 || n > 1000 {
+		t.Errorf("History wrong length after expire, expected about 1000 got %d", n)
+	}
+	for _, x := range recent {
+		if !g.Seen(x) {
+			t.Fatalf("False negative for %d after expire", x)
+		}
+	}
+}
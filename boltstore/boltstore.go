@@ -0,0 +1,334 @@
+// Package boltstore keeps the state of a serial.Generator in a bbolt
+// database, so that a single embedded file gives crash-safe tracking of
+// one-time values across restarts. A Store implements both serial.SeenStore
+// and serial.Checkpointer, and each change is committed in its own
+// transaction.
+//
+//	st, err := boltstore.Open("serial.db", nil)
+//	...
+//	gen, err := serial.New(serial.WithSeenStore(st), serial.WithCheckpoint(st, time.Second))
+package boltstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lpar/serial"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrCorrupt is reported when an entry in the database can't be decoded.
+var ErrCorrupt = errors.New("boltstore: corrupt entry")
+
+var (
+	seenBucket = []byte("seen")
+	metaBucket = []byte("meta")
+	checkpoint = []byte("checkpoint")
+)
+
+// rangeBatch is the number of entries Range reads in each transaction.
+const rangeBatch = 1000
+
+// Store is a serial.SeenStore and serial.Checkpointer backed by a bbolt
+// database.
+//
+// The SeenStore interface has no way to report errors, so a Store fails
+// closed: if the database can't be read, values are reported as seen, and if
+// a value can't be recorded, it is reported as already seen, so that a
+// one-time value is never accepted twice. Errors are passed to the handler
+// given when the Store was created.
+type Store struct {
+	db      *bolt.DB
+	onError func(error)
+}
+
+// Open opens the bbolt database with the specified path, creating it if
+// necessary, and returns a Store which uses it. The handler is called with
+// any errors which can't be returned; it may be nil.
+func Open(path string, onError func(error)) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	st, err := New(db, onError)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return st, nil
+}
+
+// New returns a Store which uses an open bbolt database, creating its
+// buckets if necessary. The handler is called with any errors which can't be
+// returned; it may be nil.
+func New(db *bolt.DB, onError func(error)) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(seenBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(metaBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db, onError: onError}, nil
+}
+
+// Close closes the database.
+func (st *Store) Close() error {
+	return st.db.Close()
+}
+
+// fail reports an error to the handler.
+func (st *Store) fail(err error) {
+	if st.onError != nil {
+		st.onError(err)
+	}
+}
+
+// Set implements serial.SeenStore.
+func (st *Store) Set(x serial.Serial, e serial.SeenEntry, now time.Time) bool {
+	v, err := encodeEntry(e)
+	if err != nil {
+		st.fail(err)
+		return false
+	}
+	added := false
+	err = st.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(seenBucket)
+		k := key(x)
+		if old := b.Get(k); old != nil {
+			oe, err := decodeEntry(old)
+			if err == nil && (oe.Expires.IsZero() || oe.Expires.After(now)) {
+				return nil
+			}
+		}
+		added = true
+		return b.Put(k, v)
+	})
+	if err != nil {
+		st.fail(err)
+		return false
+	}
+	return added
+}
+
+// Has implements serial.SeenStore.
+func (st *Store) Has(x serial.Serial) (serial.SeenEntry, bool) {
+	var e serial.SeenEntry
+	found := false
+	err := st.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(seenBucket).Get(key(x))
+		if v == nil {
+			return nil
+		}
+		found = true
+		var err error
+		e, err = decodeEntry(v)
+		return err
+	})
+	if err != nil {
+		st.fail(err)
+		return serial.SeenEntry{}, true
+	}
+	return e, found
+}
+
+// Delete implements serial.SeenStore.
+func (st *Store) Delete(x serial.Serial) bool {
+	found := false
+	err := st.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(seenBucket)
+		k := key(x)
+		if b.Get(k) == nil {
+			return nil
+		}
+		found = true
+		return b.Delete(k)
+	})
+	if err != nil {
+		st.fail(err)
+		return false
+	}
+	return found
+}
+
+// ExpireBefore implements serial.SeenStore.
+func (st *Store) ExpireBefore(limit serial.Serial, now time.Time) int {
+	removed := 0
+	err := st.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(seenBucket)
+		lk := key(limit)
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			e, err := decodeEntry(v)
+			if err != nil {
+				return err
+			}
+			if e.Expires.IsZero() && bytes.Compare(k, lk) < 0 ||
+				!e.Expires.IsZero() && !e.Expires.After(now) {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		removed = len(expired)
+		return nil
+	})
+	if err != nil {
+		st.fail(err)
+		return 0
+	}
+	return removed
+}
+
+// Range implements serial.SeenStore. Entries are read in batches, each in
+// its own transaction, so changes made during the call may or may not be
+// visited.
+func (st *Store) Range(f func(x serial.Serial, e serial.SeenEntry) bool) {
+	type item struct {
+		x serial.Serial
+		e serial.SeenEntry
+	}
+	var after []byte
+	for {
+		var items []item
+		err := st.db.View(func(tx *bolt.Tx) error {
+			c := tx.Bucket(seenBucket).Cursor()
+			k, v := c.First()
+			if after != nil {
+				k, v = c.Seek(after)
+				if bytes.Equal(k, after) {
+					k, v = c.Next()
+				}
+			}
+			for ; k != nil && len(items) < rangeBatch; k, v = c.Next() {
+				e, err := decodeEntry(v)
+				if err != nil {
+					return err
+				}
+				items = append(items, item{unkey(k), e})
+			}
+			return nil
+		})
+		if err != nil {
+			st.fail(err)
+			return
+		}
+		for _, it := range items {
+			if !f(it.x, it.e) {
+				return
+			}
+		}
+		if len(items) < rangeBatch {
+			return
+		}
+		after = key(items[len(items)-1].x)
+	}
+}
+
+// Len implements serial.SeenStore.
+func (st *Store) Len() int {
+	n := 0
+	err := st.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(seenBucket).Stats().KeyN
+		return nil
+	})
+	if err != nil {
+		st.fail(err)
+	}
+	return n
+}
+
+// EstimatedMemory implements serial.MemoryEstimator. It returns the size of
+// the database pages holding the history, which bbolt maps into memory.
+func (st *Store) EstimatedMemory() int64 {
+	var n int64
+	err := st.db.View(func(tx *bolt.Tx) error {
+		stats := tx.Bucket(seenBucket).Stats()
+		n = int64(stats.BranchAlloc + stats.LeafAlloc + stats.InlineBucketInuse)
+		return nil
+	})
+	if err != nil {
+		st.fail(err)
+	}
+	return n
+}
+
+// LoadCheckpoint implements serial.Checkpointer.
+func (st *Store) LoadCheckpoint() (serial.Serial, error) {
+	var x serial.Serial
+	err := st.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(metaBucket).Get(checkpoint); v != nil {
+			x = unkey(v)
+		}
+		return nil
+	})
+	return x, err
+}
+
+// SaveCheckpoint implements serial.Checkpointer.
+func (st *Store) SaveCheckpoint(x serial.Serial) error {
+	return st.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(checkpoint, key(x))
+	})
+}
+
+// key returns the database key for x. The sign bit is flipped so that keys
+// sort in the same order as the values.
+func key(x serial.Serial) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, uint64(x)^1<<63)
+	return k
+}
+
+// unkey returns the value for a database key.
+func unkey(k []byte) serial.Serial {
+	return serial.Serial(binary.BigEndian.Uint64(k) ^ 1<<63)
+}
+
+// encodeEntry encodes an entry as its expiry time in Unix nanoseconds,
+// followed by its attached value in JSON, if it has one.
+func encodeEntry(e serial.SeenEntry) ([]byte, error) {
+	v := make([]byte, 8)
+	if !e.Expires.IsZero() {
+		binary.BigEndian.PutUint64(v, uint64(e.Expires.UnixNano()))
+	}
+	if e.Value == nil {
+		return v, nil
+	}
+	j, err := json.Marshal(e.Value)
+	if err != nil {
+		return nil, err
+	}
+	return append(v, j...), nil
+}
+
+// decodeEntry decodes an entry encoded by encodeEntry. Attached values are
+// decoded as for json.Unmarshal into an interface{}.
+func decodeEntry(v []byte) (serial.SeenEntry, error) {
+	var e serial.SeenEntry
+	if len(v) < 8 {
+		return e, ErrCorrupt
+	}
+	if ns := int64(binary.BigEndian.Uint64(v)); ns != 0 {
+		e.Expires = time.Unix(0, ns)
+	}
+	if len(v) > 8 {
+		if err := json.Unmarshal(v[8:], &e.Value); err != nil {
+			return e, err
+		}
+	}
+	return e, nil
+}
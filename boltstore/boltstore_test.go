@@ -0,0 +1,106 @@
+package boltstore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lpar/serial"
+)
+
+func open(t *testing.T, path string) *Store {
+	st, err := Open(path, func(err error) { t.Errorf("Unexpected store error: %v", err) })
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	return st
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serial.db")
+	st := open(t, path)
+	g := serial.NewGenerator(serial.WithSeenStore(st), serial.WithCheckpoint(st, time.Minute))
+	x := g.Generate()
+	y := g.Generate()
+	z := g.Generate()
+	g.SetSeenWithValue(x, "alice")
+	g.SetSeenFor(y, time.Hour)
+	if g.SeenOrSet(z) || !g.SeenOrSet(z) {
+		t.Errorf("Expected SeenOrSet(%d) to succeed exactly once", z)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	st = open(t, path)
+	defer st.Close()
+	g = serial.NewGenerator(serial.WithSeenStore(st), serial.WithCheckpoint(st, time.Minute))
+	if w := g.Generate(); w <= z {
+		t.Errorf("Expected value after %d got %d", z, w)
+	}
+	if v, ok := g.SeenValue(x); !ok || v != "alice" {
+		t.Errorf("Expected alice and 'seen' got %v and %v", v, ok)
+	}
+	if e, ok := st.Has(y); !ok || e.Expires.IsZero() {
+		t.Errorf("Expected %d seen with expiry, got %v and %v", y, e, ok)
+	}
+	if n := g.SeenCount(); n != 3 {
+		t.Errorf("Expected 3 seen values got %d", n)
+	}
+	if !g.UnsetSeen(z) || g.Seen(z) {
+		t.Errorf("Expected %d to be unset", z)
+	}
+	// Expire x by age, but not y, which has a TTL.
+	if n := st.ExpireBefore(z, time.Now()); n != 1 {
+		t.Errorf("Expected 1 value expired got %d", n)
+	}
+	if n := st.ExpireBefore(z, time.Now().Add(2*time.Hour)); n != 1 {
+		t.Errorf("Expected 1 value expired got %d", n)
+	}
+}
+
+func TestRange(t *testing.T) {
+	st := open(t, filepath.Join(t.TempDir(), "serial.db"))
+	defer st.Close()
+	g := serial.NewGenerator(serial.WithSeenStore(st))
+	xs := g.GenerateN(rangeBatch*2 + 10)
+	for _, x := range xs {
+		g.SetSeen(x)
+	}
+	i := 0
+	st.Range(func(x serial.Serial, e serial.SeenEntry) bool {
+		if x != xs[i] {
+			t.Errorf("Expected %d got %d", xs[i], x)
+		}
+		i++
+		return true
+	})
+	if i != len(xs) {
+		t.Errorf("Expected %d values got %d", len(xs), i)
+	}
+}
+
+func TestKeyOrder(t *testing.T) {
+	xs := []serial.Serial{-1 << 63, -1, 0, 1, 1<<63 - 1}
+	for i := 1; i < len(xs); i++ {
+		if string(key(xs[i-1])) >= string(key(xs[i])) {
+			t.Errorf("Expected key for %d before key for %d", xs[i-1], xs[i])
+		}
+		if unkey(key(xs[i])) != xs[i] {
+			t.Errorf("Expected %d got %d", xs[i], unkey(key(xs[i])))
+		}
+	}
+}
+
+func TestEstimatedMemory(t *testing.T) {
+	st := open(t, filepath.Join(t.TempDir(), "serial.db"))
+	defer st.Close()
+	g := serial.NewGenerator(serial.WithSeenStore(st))
+	empty := g.EstimatedMemory()
+	for i := 0; i < 1000; i++ {
+		g.SetSeen(g.Generate())
+	}
+	if n := g.EstimatedMemory(); n <= empty || n < 1000*8 {
+		t.Errorf("Expected estimate to grow from %d, got %d", empty, n)
+	}
+}
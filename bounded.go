@@ -0,0 +1,133 @@
+package serial
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+)
+
+// ErrMaxSeen is returned when asked to create a generator with a maximum
+// seen history size less than 1.
+var ErrMaxSeen = errors.New("serial: invalid maximum seen history size")
+
+// boundedStore is a seenStore which holds at most max values, evicting the
+// least recently added when it is full. The list holds boundedEntry values.
+type boundedStore struct {
+	mutex sync.Mutex
+	max   int
+	m     map[Serial]*list.Element
+	order *list.List
+	lagw  lagWindow
+}
+
+// boundedEntry is an element of a boundedStore's list.
+type boundedEntry struct {
+	x Serial
+	e seenEntry
+}
+
+// WithMaxSeen limits the seen history to max values. When it is full, flagging
+// another value as seen evicts the value which was flagged least recently,
+// regardless of whether it has expired. This puts a hard limit on the memory
+// used by the history, even if ExpireSeen isn't called often enough; but an
+// evicted value will no longer be reported as seen, so the limit should be
+// set well above the number of values expected within the expiry period.
+//
+// The bounded history uses a single lock rather than the default sharded
+// history, so concurrent callers of Seen and SetSeen contend more.
+func WithMaxSeen(max int) Option {
+	return func(g *Generator) error {
+		if max < 1 {
+			return ErrMaxSeen
+		}
+		g.store = &boundedStore{max: max, m: make(map[Serial]*list.Element), order: list.New()}
+		return nil
+	}
+}
+
+func (st *boundedStore) add(x Serial, e seenEntry, now int64) bool {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	delta := int64(1)
+	if old, ok := st.m[x]; ok {
+		if !old.Value.(boundedEntry).e.dead(now) {
+			return false
+		}
+		// Expired but not yet removed, so it can be replaced.
+		st.order.Remove(old)
+		delta--
+	}
+	st.m[x] = st.order.PushBack(boundedEntry{x, e})
+	for st.order.Len() > st.max {
+		oldest := st.order.Remove(st.order.Front()).(boundedEntry)
+		delete(st.m, oldest.x)
+		delta--
+	}
+	st.lagw.record(now, delta)
+	return true
+}
+
+func (st *boundedStore) has(x Serial) (seenEntry, bool) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	if el, ok := st.m[x]; ok {
+		return el.Value.(boundedEntry).e, true
+	}
+	return seenEntry{}, false
+}
+
+func (st *boundedStore) remove(x Serial, now int64) bool {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	el, ok := st.m[x]
+	if ok {
+		st.order.Remove(el)
+		delete(st.m, x)
+		st.lagw.record(now, -1)
+	}
+	return ok
+}
+
+func (st *boundedStore) each(f func(x Serial, e seenEntry) bool) {
+	st.mutex.Lock()
+	items := make([]boundedEntry, 0, st.order.Len())
+	for el := st.order.Front(); el != nil; el = el.Next() {
+		items = append(items, el.Value.(boundedEntry))
+	}
+	st.mutex.Unlock()
+	for _, it := range items {
+		if !f(it.x, it.e) {
+			return
+		}
+	}
+}
+
+func (st *boundedStore) expire(limit Serial, now int64, removed *[]Serial) int {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	n := 0
+	for x, el := range st.m {
+		if el.Value.(boundedEntry).e.expired(x, limit, now) {
+			st.order.Remove(el)
+			delete(st.m, x)
+			n++
+			if removed != nil {
+				*removed = append(*removed, x)
+			}
+		}
+	}
+	st.lagw.record(now, -int64(n))
+	return n
+}
+
+func (st *boundedStore) lag(now int64) int64 {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	return st.lagw.sum(now)
+}
+
+func (st *boundedStore) len() int {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	return len(st.m)
+}
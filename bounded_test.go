@@ -0,0 +1,36 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaxSeen(t *testing.T) {
+	if _, err := New(WithMaxSeen(0)); err != ErrMaxSeen {
+		t.Errorf("Expected ErrMaxSeen, got %v", err)
+	}
+	g := NewGenerator(WithMaxSeen(100))
+	xs := g.GenerateN(150)
+	for _, x := range xs {
+		g.SetSeen(x)
+	}
+	if n := g.SeenCount(); n != 100 {
+		t.Errorf("History wrong length, expected 100 got %d", n)
+	}
+	for i, x := range xs {
+		if g.Seen(x) != (i >= 50) {
+			t.Errorf("Wrong seen state for value %d: %v", i, g.Seen(x))
+		}
+	}
+	if lag := g.ExpirationLag(); lag != 100 {
+		t.Errorf("Wrong lag, expected 100 got %d", lag)
+	}
+	g.ExpireSeen(time.Duration(0))
+	if n := g.SeenCount(); n != 0 {
+		t.Errorf("History wrong length after expire, expected 0 got %d", n)
+	}
+	g.SetSeen(xs[0])
+	if !g.Seen(xs[0]) {
+		t.Error("Flagged value as seen after expire, got 'not seen'")
+	}
+}
@@ -0,0 +1,114 @@
+package serial
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ErrInvalidDecimal is returned when parsing a string which isn't a valid
+// decimal serial value, with a check digit if one is expected.
+var ErrInvalidDecimal = errors.New("serial: invalid decimal serial")
+
+// dammTable is the quasigroup of order 10 used by the Damm algorithm.
+var dammTable = [10][10]byte{
+	{0, 3, 1, 7, 5, 9, 8, 6, 4, 2},
+	{7, 0, 9, 2, 1, 5, 4, 8, 6, 3},
+	{4, 2, 0, 6, 8, 7, 1, 3, 5, 9},
+	{1, 7, 5, 0, 9, 8, 3, 4, 2, 6},
+	{6, 1, 2, 3, 0, 4, 5, 9, 7, 8},
+	{3, 6, 7, 4, 2, 0, 9, 5, 8, 1},
+	{5, 8, 6, 9, 7, 2, 0, 1, 3, 4},
+	{8, 9, 4, 5, 3, 6, 2, 0, 1, 7},
+	{9, 4, 3, 8, 6, 1, 7, 2, 0, 5},
+	{2, 5, 8, 1, 4, 3, 6, 7, 9, 0},
+}
+
+// Luhn returns the serial value in decimal, followed by a check digit
+// calculated using the Luhn algorithm, as used for credit card numbers. The
+// check digit detects any single mistyped digit, and most transpositions of
+// adjacent digits.
+func (x Serial) Luhn() string {
+	s := strconv.FormatUint(uint64(x), 10)
+	return s + string('0'+luhnDigit(s))
+}
+
+// ParseLuhn parses a serial value formatted by Luhn, returning ErrChecksum if
+// the check digit doesn't match.
+func ParseLuhn(s string) (Serial, error) {
+	x, err := parseDecimal(s)
+	if err != nil {
+		return 0, err
+	}
+	if luhnDigit(s[:len(s)-1]) != s[len(s)-1]-'0' {
+		return 0, ErrChecksum
+	}
+	return x, nil
+}
+
+// Damm returns the serial value in decimal, followed by a check digit
+// calculated using the Damm algorithm. The check digit detects any single
+// mistyped digit, and all transpositions of adjacent digits.
+func (x Serial) Damm() string {
+	s := strconv.FormatUint(uint64(x), 10)
+	return s + string('0'+dammDigit(s))
+}
+
+// ParseDamm parses a serial value formatted by Damm, returning ErrChecksum if
+// the check digit doesn't match.
+func ParseDamm(s string) (Serial, error) {
+	x, err := parseDecimal(s)
+	if err != nil {
+		return 0, err
+	}
+	// Running the whole string including the check digit through the
+	// table gives zero if it's correct.
+	if dammDigit(s) != 0 {
+		return 0, ErrChecksum
+	}
+	return x, nil
+}
+
+// parseDecimal parses all but the last digit of s as a serial value, after
+// checking that s consists only of digits.
+func parseDecimal(s string) (Serial, error) {
+	if len(s) < 2 {
+		return 0, ErrInvalidDecimal
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, ErrInvalidDecimal
+		}
+	}
+	n, err := strconv.ParseUint(s[:len(s)-1], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidDecimal
+	}
+	return Serial(n), nil
+}
+
+// luhnDigit calculates the Luhn check digit for a string of decimal digits.
+func luhnDigit(s string) byte {
+	sum := 0
+	double := true
+	for i := len(s) - 1; i >= 0; i-- {
+		d := int(s[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte((10 - sum%10) % 10)
+}
+
+// dammDigit calculates the Damm check digit for a string of decimal digits.
+func dammDigit(s string) byte {
+	var interim byte
+	for i := 0; i < len(s); i++ {
+		interim = dammTable[interim][s[i]-'0']
+	}
+	return interim
+}
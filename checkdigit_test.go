@@ -0,0 +1,46 @@
+package serial
+
+import (
+	"testing"
+)
+
+func TestLuhn(t *testing.T) {
+	// Example from the Wikipedia article on the Luhn algorithm.
+	if s := Serial(7992739871).Luhn(); s != "79927398713" {
+		t.Errorf("Wrong Luhn check digit, expected 79927398713 got %s", s)
+	}
+	x := gen.Generate()
+	y, err := ParseLuhn(x.Luhn())
+	if err != nil || y != x {
+		t.Errorf("Round trip failed, expected %d got %d %v", x, y, err)
+	}
+	if _, err := ParseLuhn("79927398710"); err != ErrChecksum {
+		t.Errorf("Expected ErrChecksum, got %v", err)
+	}
+	if _, err := ParseLuhn("79927389713"); err != ErrChecksum {
+		t.Errorf("Expected ErrChecksum for transposition, got %v", err)
+	}
+}
+
+func TestDamm(t *testing.T) {
+	// Example from the Wikipedia article on the Damm algorithm.
+	if s := Serial(572).Damm(); s != "5724" {
+		t.Errorf("Wrong Damm check digit, expected 5724 got %s", s)
+	}
+	x := gen.Generate()
+	y, err := ParseDamm(x.Damm())
+	if err != nil || y != x {
+		t.Errorf("Round trip failed, expected %d got %d %v", x, y, err)
+	}
+	if _, err := ParseDamm("5274"); err != ErrChecksum {
+		t.Errorf("Expected ErrChecksum for transposition, got %v", err)
+	}
+}
+
+func TestParseDecimalErrors(t *testing.T) {
+	for _, s := range []string{"", "5", "57a4", "-5724", "999999999999999999999"} {
+		if _, err := ParseDamm(s); err != ErrInvalidDecimal {
+			t.Errorf("Expected ErrInvalidDecimal for %q, got %v", s, err)
+		}
+	}
+}
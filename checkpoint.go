@@ -0,0 +1,141 @@
+package serial
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrCheckpoint is returned when asked to create a generator which
+// checkpoints its last issued value without a Checkpointer, or with a
+// negative lead time.
+var ErrCheckpoint = errors.New("serial: invalid checkpoint configuration")
+
+// Checkpointer saves and loads a generator's high-water mark, so that it can
+// carry on from where it left off after a restart.
+type Checkpointer interface {
+	// LoadCheckpoint returns the value last saved, or zero if nothing has
+	// been saved.
+	LoadCheckpoint() (Serial, error)
+	// SaveCheckpoint durably saves x, replacing the value last saved.
+	SaveCheckpoint(x Serial) error
+}
+
+// WithCheckpoint makes the generator checkpoint its values with cp, so that
+// after a restart it never issues a value it may have issued before, even if
+// the clock was stepped back while it was down. The checkpoint is loaded when
+// the generator is created, and the generator starts after it.
+//
+// Rather than saving every value, the generator saves a high-water mark lead
+// ahead of the time of the value being issued, and saves again only once it
+// issues a value past the mark. The longer the lead, the less often values
+// have to wait for a save, but the further the generator may have to jump
+// ahead of the clock after a restart. Values in counter mode don't embed a
+// time, so the lead is ignored and every value is saved as it is issued.
+//
+// If a save fails, the value being issued is discarded; TryGenerate returns
+// the error, and Generate, Fill and ReserveBlock panic with it. Values from
+// Generate128 aren't checkpointed.
+func WithCheckpoint(cp Checkpointer, lead time.Duration) Option {
+	return func(g *Generator) error {
+		if cp == nil || lead < 0 {
+			return ErrCheckpoint
+		}
+		g.checkpoint = cp
+		g.lead = lead
+		return nil
+	}
+}
+
+// loadCheckpoint loads the generator's checkpoint, if it has one, and moves
+// the generator past it.
+func (g *Generator) loadCheckpoint() error {
+	if g.checkpoint == nil {
+		return nil
+	}
+	x, err := g.checkpoint.LoadCheckpoint()
+	if err != nil {
+		return err
+	}
+	if err := g.checkStartup(x, g.lead); err != nil {
+		return err
+	}
+	g.raise(x)
+	g.saved.Store(int64(x))
+	return nil
+}
+
+// checkpointed returns once a checkpoint covering x has been saved.
+func (g *Generator) checkpointed(x Serial) error {
+	if g.checkpoint == nil || int64(x) <= g.saved.Load() {
+		return nil
+	}
+	g.cpmutex.Lock()
+	if int64(x) <= g.saved.Load() {
+		g.cpmutex.Unlock()
+		return nil
+	}
+	mark := x
+	if !g.counter {
+		mark = g.layout.pack(g.layout.ticks(g.layout.timeOf(x).Add(g.lead)), 0, 0)
+	}
+	if mark < x {
+		mark = x
+	}
+	err := g.checkpoint.SaveCheckpoint(mark)
+	if err == nil {
+		g.saved.Store(int64(mark))
+	}
+	g.cpmutex.Unlock()
+	return err
+}
+
+// FileCheckpoint is a Checkpointer which keeps the checkpoint in the file
+// with the specified path, as a decimal number. Each save writes a new file
+// and renames it into place, so the checkpoint survives a crash part way
+// through.
+type FileCheckpoint string
+
+// LoadCheckpoint implements Checkpointer. It returns zero if the file
+// doesn't exist.
+func (f FileCheckpoint) LoadCheckpoint() (Serial, error) {
+	b, err := os.ReadFile(string(f))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	s := strings.TrimSpace(string(b))
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, &ParseError{Input: s, Format: "decimal", Err: ErrInvalidDecimal}
+	}
+	return Serial(n), nil
+}
+
+// SaveCheckpoint implements Checkpointer.
+func (f FileCheckpoint) SaveCheckpoint(x Serial) error {
+	path := string(f)
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.WriteString(x.String() + "\n")
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package serial
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileCheckpoint(t *testing.T) {
+	cp := FileCheckpoint(filepath.Join(t.TempDir(), "serial.checkpoint"))
+	clk := &fakeClock{now: time.Unix(2000, 0)}
+	g := NewGenerator(WithClock(clk), WithCheckpoint(cp, time.Minute))
+	x := g.Generate()
+	saved, err := cp.LoadCheckpoint()
+	if err != nil {
+		t.Fatalf("LoadCheckpoint failed: %v", err)
+	}
+	if want := Serial(time.Unix(2060, 0).UnixNano()); saved != want {
+		t.Errorf("Expected checkpoint %d got %d", want, saved)
+	}
+
+	// Restart with the clock stepped back.
+	clk.now = time.Unix(1000, 0)
+	g2 := NewGenerator(WithClock(clk), WithCheckpoint(cp, time.Minute))
+	if y := g2.Generate(); y <= saved || y <= x {
+		t.Errorf("Expected value after %d got %d", saved, y)
+	}
+}
+
+func TestCheckpointSaves(t *testing.T) {
+	cp := &countingCheckpoint{}
+	clk := &fakeClock{now: time.Unix(2000, 0)}
+	g := NewGenerator(WithClock(clk), WithCheckpoint(cp, time.Minute))
+	for i := 0; i < 100; i++ {
+		g.Generate()
+		clk.Advance(time.Second)
+	}
+	if cp.saves != 2 {
+		t.Errorf("Expected 2 saves got %d", cp.saves)
+	}
+	cp.err = errors.New("disk full")
+	clk.Advance(time.Minute)
+	if _, err := g.TryGenerate(); err != cp.err {
+		t.Errorf("Expected save error got %v", err)
+	}
+}
+
+func TestFileCheckpointInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serial.checkpoint")
+	if err := os.WriteFile(path, []byte("bogus\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(WithCheckpoint(FileCheckpoint(path), time.Minute)); !errors.Is(err, ErrInvalidDecimal) {
+		t.Errorf("Expected ErrInvalidDecimal got %v", err)
+	}
+}
+
+// countingCheckpoint is a Checkpointer which counts saves and can be made to
+// fail.
+type countingCheckpoint struct {
+	saved Serial
+	saves int
+	err   error
+}
+
+func (cp *countingCheckpoint) LoadCheckpoint() (Serial, error) {
+	return cp.saved, nil
+}
+
+func (cp *countingCheckpoint) SaveCheckpoint(x Serial) error {
+	if cp.err != nil {
+		return cp.err
+	}
+	cp.saved = x
+	cp.saves++
+	return nil
+}
+
+func TestCheckpointCounter(t *testing.T) {
+	cp := FileCheckpoint(filepath.Join(t.TempDir(), "serial.checkpoint"))
+	g := NewGenerator(WithCounter(1), WithCheckpoint(cp, time.Minute))
+	if x, y := g.Generate(), g.Generate(); x != 1 || y != 2 {
+		t.Fatalf("Expected 1 and 2 got %d and %d", x, y)
+	}
+	g = NewGenerator(WithCounter(1), WithCheckpoint(cp, time.Minute))
+	if x := g.Generate(); x != 3 {
+		t.Errorf("Expected 3 after restart got %d", x)
+	}
+}
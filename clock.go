@@ -0,0 +1,47 @@
+package serial
+
+import (
+	"time"
+)
+
+// Clock provides the current time to a Generator. The default is the system
+// clock, but tests and simulations can supply their own so that they control
+// the passage of time.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the default Clock, which reads the system clock.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// fixedClock is a Clock which is stopped at a single moment.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// startClock is a Clock which is stopped at the time embedded in start by
+// the generator's layout, which is read when the time is, so that it is the
+// layout left by all of the generator's options.
+type startClock struct {
+	g     *Generator
+	start Serial
+}
+
+func (c startClock) Now() time.Time {
+	return c.g.layout.timeOf(c.start)
+}
+
+// WithClock sets the clock a generator uses to timestamp serial values and
+// to decide which seen values have expired.
+func WithClock(c Clock) Option {
+	return func(g *Generator) error {
+		g.clock = c
+		return nil
+	}
+}
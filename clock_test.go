@@ -0,0 +1,95 @@
+package serial
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock which only moves when told to.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	g := NewGenerator(WithClock(clock))
+	start := Serial(clock.now.UnixNano())
+	if x := g.Generate(); x != start {
+		t.Errorf("Wrong value from fake clock, expected %d got %d", start, x)
+	}
+	if x := g.Generate(); x != start+1 {
+		t.Errorf("Wrong value from stopped clock, expected %d got %d", start+1, x)
+	}
+	g.SetSeen(start)
+	clock.Advance(time.Hour)
+	if d := g.Age(start); d != time.Hour {
+		t.Errorf("Wrong age, expected 1h got %v", d)
+	}
+	g.ExpireSeen(2 * time.Hour)
+	if !g.Seen(start) {
+		t.Error("Value expired before its age limit")
+	}
+	g.ExpireSeen(30 * time.Minute)
+	if g.Seen(start) {
+		t.Error("Value didn't expire after its age limit")
+	}
+}
+
+func TestDeterministicGenerator(t *testing.T) {
+	g1 := NewDeterministicGenerator(1000)
+	g2 := NewDeterministicGenerator(1000)
+	for i := 0; i < 100; i++ {
+		x1 := g1.Generate()
+		x2 := g2.Generate()
+		if x1 != x2 || x1 != Serial(1000+i) {
+			t.Fatalf("Sequences diverged, expected %d got %d and %d", 1000+i, x1, x2)
+		}
+	}
+}
+
+func TestDeterministicGeneratorLayout(t *testing.T) {
+	epoch := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, opts := range [][]Option{
+		{WithEpoch(epoch)},
+		{WithLayout(SnowflakeLayout)},
+		{WithLayout(SnowflakeLayout), WithEpoch(epoch)},
+	} {
+		g, err := func() (g *Generator, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("%v", r)
+				}
+			}()
+			return NewDeterministicGenerator(1000, opts...), nil
+		}()
+		if err != nil {
+			t.Errorf("Expected deterministic generator got %v", err)
+			continue
+		}
+		for i := 0; i < 3; i++ {
+			if x := g.Generate(); x != Serial(1000+i) {
+				t.Errorf("Expected %d got %d", 1000+i, x)
+			}
+		}
+	}
+}
+
+func TestDeterministicGeneratorOptions(t *testing.T) {
+	g := NewDeterministicGenerator(1000, WithMaxSeen(1))
+	x := g.Generate()
+	y := g.Generate()
+	g.SetSeen(x)
+	g.SetSeen(y)
+	if g.Seen(x) || !g.Seen(y) || x != 1000 {
+		t.Errorf("Expected options to apply to deterministic generator")
+	}
+}
@@ -0,0 +1,50 @@
+package serial
+
+import "errors"
+
+// ErrClone is returned by Clone when the generator has a checkpoint, a
+// journal or mapped state, which a clone would otherwise share with it.
+var ErrClone = errors.New("serial: cannot clone a generator with a checkpoint, journal or mapped state")
+
+// Clone creates a new generator with the same options as this one, which
+// carries on from the last value this one issued, and if seen is true, has a
+// copy of its seen history. After that, the two are independent, so a warmed
+// up template can be forked into generators for separate domains, such as
+// tenants. As with Snapshot, the history is copied and the last value read
+// without values being flagged or expired in between.
+//
+// The clone shares whatever was passed to the options, such as the clock, an
+// Allocator, or a store passed to WithSeenStore, in which case the history
+// is shared rather than copied. A history kept in Bloom filters can't be
+// copied. Hooks registered with OnExpire and
+// OnGenerate, event subscriptions and statistics aren't copied. Generators
+// with a checkpoint, journal or mapped state can't be cloned, since both
+// generators would write to it.
+func (g *Generator) Clone(seen bool) (*Generator, error) {
+	if g.checkpoint != nil || g.journal != nil || g.mapped != nil {
+		return nil, ErrClone
+	}
+	opts := g.opts
+	if opts == nil {
+		// A generator initialized by GobDecode.
+		opts = []Option{WithLayout(g.layout), WithNode(g.node)}
+	}
+	c, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	_, shared := g.store.(*externalStore)
+	g.snapMutex.Lock()
+	if seen && !shared && c.store != g.store {
+		now := g.clock.Now().UnixNano()
+		g.store.each(func(x Serial, e seenEntry) bool {
+			c.store.add(x, e, now)
+			return true
+		})
+	}
+	last := Serial(g.lastSerial.Load())
+	g.snapMutex.Unlock()
+	c.raise(last)
+	c.lastClock.Store(g.lastClock.Load())
+	return c, nil
+}
@@ -0,0 +1,55 @@
+package serial
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"time"
+)
+
+func TestClone(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	g := NewGenerator(WithClock(clock), WithLayout(SnowflakeLayout), WithNode(7))
+	x := g.Generate()
+	g.SetSeenWithValue(x, "template")
+	c, err := g.Clone(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if y := c.Generate(); y <= x || c.node != 7 || !sameLayout(c.layout, SnowflakeLayout) {
+		t.Errorf("Expected clone to follow %d with node 7, got %d", x, y)
+	}
+	if v, ok := c.SeenValue(x); !ok || v != "template" {
+		t.Errorf("Expected clone to have seen %d, got %v %v", x, v, ok)
+	}
+	c.UnsetSeen(x)
+	z := c.Generate()
+	c.SetSeen(z)
+	if !g.Seen(x) || g.Seen(z) {
+		t.Error("Expected clone's history to be independent")
+	}
+	c, err = g.Clone(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Seen(x) || c.Generate() <= x {
+		t.Error("Expected clone without history to carry on from the template")
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(g); err != nil {
+		t.Fatal(err)
+	}
+	d := new(Generator)
+	if err := gob.NewDecoder(&buf).Decode(d); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := d.Clone(true); err != nil || c.node != 7 || !c.Seen(x) {
+		t.Errorf("Expected clone of decoded generator, got %v", err)
+	}
+
+	g = NewGenerator(WithCheckpoint(&countingCheckpoint{}, time.Second))
+	if _, err := g.Clone(false); err != ErrClone {
+		t.Errorf("Expected ErrClone got %v", err)
+	}
+}
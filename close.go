@@ -0,0 +1,82 @@
+package serial
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+// ErrClosed is returned by TryGenerate, GenerateCtx, TryFill, Reserve and
+// TryGenerate128 once the generator has been closed, and is the value
+// Generate, GenerateN, Fill, ReserveBlock and Generate128 panic with.
+var ErrClosed = errors.New("serial: generator closed")
+
+// CloseCtx shuts the generator down. No more values are issued once it has
+// been called: TryGenerate, GenerateCtx, TryFill, Reserve and
+// TryGenerate128 return ErrClosed, and Generate, GenerateN, Fill,
+// ReserveBlock and Generate128 panic with it, so code which may run during a
+// shutdown should use the former. It stops any background goroutines the
+// generator is running, including the one filling its pool, and waits for
+// them to finish, then flushes the generator's journal and mapped state, if
+// it has them, to stable storage. They are left open, since they belong to
+// the caller. Checkpoints need no flushing, since they are saved before
+// values are issued.
+//
+// If the context is done before the background goroutines have finished,
+// CloseCtx returns the context's error without flushing anything; the
+// goroutines still stop soon after. Calls which were already generating
+// values when CloseCtx was called may complete, so it should be called once
+// the generator's users have stopped. The seen history can still be used
+// after the generator is closed, but expiry only happens when ExpireSeen is
+// called, and the clock is no longer monitored for drift. It is safe to call
+// CloseCtx more than once.
+func (g *Generator) CloseCtx(ctx context.Context) error {
+	g.closed.Store(true)
+	g.closeOnce.Do(func() {
+		if g.stop != nil {
+			close(g.stop)
+		}
+	})
+	for _, done := range []<-chan struct{}{g.done, g.stopPool()} {
+		if done == nil {
+			continue
+		}
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if g.journal != nil {
+		if err := g.journal.Sync(); err != nil && err != os.ErrClosed {
+			return err
+		}
+	}
+	if g.mapped != nil {
+		return g.mapped.Sync()
+	}
+	return nil
+}
+
+// Close is like CloseCtx, but waits as long as it takes. It implements
+// io.Closer.
+func (g *Generator) Close() error {
+	return g.CloseCtx(context.Background())
+}
+
+// stopPool stops the goroutine filling the generator's pool, if it has one,
+// and returns a channel which is closed once it has finished, or nil.
+func (g *Generator) stopPool() <-chan struct{} {
+	if g.pool == nil {
+		return nil
+	}
+	return g.pool.stop()
+}
+
+// checkOpen returns ErrClosed if the generator has been closed.
+func (g *Generator) checkOpen() error {
+	if g.closed.Load() {
+		return ErrClosed
+	}
+	return nil
+}
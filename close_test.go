@@ -0,0 +1,68 @@
+package serial
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCloseCtx(t *testing.T) {
+	j, err := OpenJournal(filepath.Join(t.TempDir(), "serials.log"), 1000, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	g := NewGenerator(WithJournal(j), WithAutoExpire(time.Hour, time.Hour))
+	g.Generate()
+	if err := g.CloseCtx(context.Background()); err != nil {
+		t.Errorf("CloseCtx returned %v", err)
+	}
+	if _, err := g.TryGenerate(); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from TryGenerate got %v", err)
+	}
+	if _, err := g.Reserve(10); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from Reserve got %v", err)
+	}
+	if err := g.TryFill(make([]Serial, 2)); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from TryFill got %v", err)
+	}
+	if _, err := NewPager(g, true).Lease(10); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from Lease got %v", err)
+	}
+	if _, err := g.TryGenerate128(); err != ErrClosed {
+		t.Errorf("Expected ErrClosed from TryGenerate128 got %v", err)
+	}
+	for name, f := range map[string]func(){
+		"Generate128":  func() { g.Generate128() },
+		"Generate":     func() { g.Generate() },
+		"GenerateN":    func() { g.GenerateN(2) },
+		"ReserveBlock": func() { g.ReserveBlock(2) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r != ErrClosed {
+					t.Errorf("Expected %s to panic with ErrClosed, got %v", name, r)
+				}
+			}()
+			f()
+		}()
+	}
+	if err := g.Close(); err != nil {
+		t.Errorf("Second Close returned %v", err)
+	}
+	g.SetSeen(1)
+	if !g.Seen(1) {
+		t.Error("Expected seen history to work after Close")
+	}
+}
+
+func TestCloseCtxDone(t *testing.T) {
+	g := NewGenerator()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// With no background goroutine, there is nothing to wait for.
+	if err := g.CloseCtx(ctx); err != nil {
+		t.Errorf("CloseCtx returned %v", err)
+	}
+}
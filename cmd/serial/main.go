@@ -0,0 +1,231 @@
+// Command serial generates and inspects serial values from the command line,
+// and manages a persisted seen history.
+//
+// Usage:
+//
+//	serial generate [-n count] [-format name]
+//	serial convert [-format name] value...
+//	serial time value...
+//	serial seen -file path check|set|unset value...
+//	serial seen -file path expire age
+//	serial seen -file path list
+//
+// Values are accepted in any format understood by serial.Parse, and printed
+// in decimal unless another format is requested: hex, base62, base32,
+// base32check, luhn, damm, ulid or uuid. The seen file holds history in the
+// format written by Generator.ExportSeen.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lpar/serial"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "serial:", err)
+		os.Exit(1)
+	}
+}
+
+var errUsage = errors.New("usage: serial generate|convert|time|seen [flags] [args]")
+
+// run runs the command with the specified arguments, writing its output to
+// w.
+func run(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "generate":
+		return generate(args, w)
+	case "convert":
+		return convert(args, w)
+	case "time":
+		return showTime(args, w)
+	case "seen":
+		return seen(args, w)
+	}
+	return errUsage
+}
+
+func generate(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	n := fs.Int("n", 1, "number of values to generate")
+	format := fs.String("format", "decimal", "output format")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *n < 1 {
+		return errors.New("count must be positive")
+	}
+	gen := serial.NewGenerator()
+	for _, x := range gen.GenerateN(*n) {
+		s, err := formatSerial(gen, x, *format)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, s)
+	}
+	return nil
+}
+
+func convert(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	format := fs.String("format", "decimal", "output format")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	gen := serial.NewGenerator()
+	return eachValue(fs.Args(), func(x serial.Serial) error {
+		s, err := formatSerial(gen, x, *format)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, s)
+		return nil
+	})
+}
+
+func showTime(args []string, w io.Writer) error {
+	return eachValue(args, func(x serial.Serial) error {
+		fmt.Fprintln(w, x.Time().UTC().Format(time.RFC3339Nano))
+		return nil
+	})
+}
+
+func seen(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("seen", flag.ContinueOnError)
+	file := fs.String("file", "", "seen history file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" || fs.NArg() == 0 {
+		return errors.New("usage: serial seen -file path check|set|unset|expire|list [args]")
+	}
+	gen := serial.NewGenerator()
+	if err := load(gen, *file); err != nil {
+		return err
+	}
+	op, args := fs.Arg(0), fs.Args()[1:]
+	switch op {
+	case "check":
+		return eachValue(args, func(x serial.Serial) error {
+			fmt.Fprintln(w, x, gen.Seen(x))
+			return nil
+		})
+	case "list":
+		gen.RangeSeen(func(x serial.Serial) bool {
+			fmt.Fprintln(w, x)
+			return true
+		})
+		return nil
+	case "set":
+		err := eachValue(args, func(x serial.Serial) error {
+			gen.SetSeen(x)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	case "unset":
+		err := eachValue(args, func(x serial.Serial) error {
+			gen.UnsetSeen(x)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	case "expire":
+		if len(args) != 1 {
+			return errors.New("usage: serial seen -file path expire age")
+		}
+		age, err := time.ParseDuration(args[0])
+		if err != nil {
+			return err
+		}
+		gen.ExpireSeen(age)
+	default:
+		return fmt.Errorf("unknown seen operation %q", op)
+	}
+	return save(gen, *file)
+}
+
+// load imports the seen history from a file, if it exists.
+func load(gen *serial.Generator, path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return gen.ImportSeen(f)
+}
+
+// save exports the seen history to a file, replacing it.
+func save(gen *serial.Generator, path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	err = gen.ExportSeen(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// eachValue parses each argument as a serial value and calls f with it.
+func eachValue(args []string, f func(serial.Serial) error) error {
+	for _, s := range args {
+		x, err := serial.Parse(s)
+		if err != nil {
+			return err
+		}
+		if err := f(x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatSerial formats x in the named format.
+func formatSerial(gen *serial.Generator, x serial.Serial, format string) (string, error) {
+	switch format {
+	case "decimal":
+		return x.String(), nil
+	case "hex":
+		return x.Hex(), nil
+	case "base62":
+		return x.Base62(), nil
+	case "base32":
+		return x.Base32(), nil
+	case "base32check":
+		return x.Base32Check(), nil
+	case "luhn":
+		return x.Luhn(), nil
+	case "damm":
+		return x.Damm(), nil
+	case "ulid":
+		return gen.ULID(x).String(), nil
+	case "uuid":
+		return gen.UUIDv7(x).String(), nil
+	}
+	return "", fmt.Errorf("unknown format %q", format)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func output(t *testing.T, args ...string) []string {
+	var buf bytes.Buffer
+	if err := run(args, &buf); err != nil {
+		t.Fatalf("serial %s failed: %v", strings.Join(args, " "), err)
+	}
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+func TestGenerate(t *testing.T) {
+	if lines := output(t, "generate", "-n", "5", "-format", "base62"); len(lines) != 5 {
+		t.Errorf("Expected 5 values got %d", len(lines))
+	}
+}
+
+func TestConvert(t *testing.T) {
+	lines := output(t, "convert", "-format", "hex", "1234567890")
+	if lines[0] != "0x499602d2" {
+		t.Errorf("Expected 0x499602d2 got %s", lines[0])
+	}
+	lines = output(t, "convert", "0x499602d2")
+	if lines[0] != "1234567890" {
+		t.Errorf("Expected 1234567890 got %s", lines[0])
+	}
+	if err := run([]string{"convert", "-format", "bogus", "1"}, &bytes.Buffer{}); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestTime(t *testing.T) {
+	lines := output(t, "time", "1500000000123456789")
+	if lines[0] != "2017-07-14T02:40:00.123456789Z" {
+		t.Errorf("Expected 2017-07-14T02:40:00.123456789Z got %s", lines[0])
+	}
+}
+
+func TestSeen(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "seen.jsonl")
+	x := output(t, "generate")[0]
+	output(t, "seen", "-file", file, "set", x)
+	if lines := output(t, "seen", "-file", file, "check", x); lines[0] != x+" true" {
+		t.Errorf("Expected %s true got %s", x, lines[0])
+	}
+	if lines := output(t, "seen", "-file", file, "list"); len(lines) != 1 || lines[0] != x {
+		t.Errorf("Expected %s got %v", x, lines)
+	}
+	output(t, "seen", "-file", file, "unset", x)
+	if lines := output(t, "seen", "-file", file, "check", x); lines[0] != x+" false" {
+		t.Errorf("Expected %s false got %s", x, lines[0])
+	}
+}
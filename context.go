@@ -0,0 +1,20 @@
+package serial
+
+import "context"
+
+// contextKey is the type of the context key for serial values.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx which carries the serial value x, so that
+// a value assigned at the edge of a system, such as a request ID, can be
+// passed down through the calls which handle it.
+func NewContext(ctx context.Context, x Serial) context.Context {
+	return context.WithValue(ctx, contextKey{}, x)
+}
+
+// FromContext returns the serial value carried by ctx, and whether there
+// was one.
+func FromContext(ctx context.Context) (Serial, bool) {
+	x, ok := ctx.Value(contextKey{}).(Serial)
+	return x, ok
+}
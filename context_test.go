@@ -0,0 +1,24 @@
+package serial
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := FromContext(ctx); ok {
+		t.Error("Expected no serial in empty context")
+	}
+	x := gen.Generate()
+	ctx = NewContext(ctx, x)
+	if y, ok := FromContext(ctx); !ok || y != x {
+		t.Errorf("Expected %d got %d", x, y)
+	}
+	// Another package's key of the same underlying type doesn't collide.
+	type otherKey struct{}
+	ctx = context.WithValue(ctx, otherKey{}, Serial(1))
+	if y, _ := FromContext(ctx); y != x {
+		t.Errorf("Expected %d got %d", x, y)
+	}
+}
@@ -0,0 +1,28 @@
+package serial
+
+import "errors"
+
+// ErrCounter is returned when asked to create a generator in counter mode
+// along with node IDs, random bits, an Allocator, or a SeenStore other than
+// MemoryStore.
+var ErrCounter = errors.New("serial: counter mode is incompatible with other options")
+
+// WithCounter puts the generator in counter mode, where serial values are
+// simply seed, seed+1, seed+2 and so on, regardless of the clock, for
+// applications which need small, dense numbers which people can read. The
+// generator is otherwise used as normal: values are unique and ascending, and
+// can be flagged as seen.
+//
+// Values in counter mode don't embed a time, so Time, Age and SeenBetween
+// give meaningless results for them, and WithMaxSkew has no effect. Instead,
+// ExpireSeen and ExpireSeenBefore expire seen values by the time at which
+// they were flagged as seen. Counter mode can't be combined with node IDs,
+// random bits, an Allocator, or a SeenStore other than MemoryStore, whose
+// interface only allows expiry by value.
+func WithCounter(seed Serial) Option {
+	return func(g *Generator) error {
+		g.counter = true
+		g.lastSerial.Store(int64(seed) - 1)
+		return nil
+	}
+}
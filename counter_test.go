@@ -0,0 +1,64 @@
+package serial
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestCounter(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithCounter(1), WithLayout(SnowflakeLayout)},
+		{WithCounter(1), WithRandomBits(4)},
+		{WithCounter(1), WithSeenStore(&testStore{m: make(map[Serial]SeenEntry)})},
+	} {
+		if _, err := New(opts...); err != ErrCounter {
+			t.Errorf("Expected ErrCounter got %v", err)
+		}
+	}
+	clk := &fakeClock{now: time.Unix(1700000000, 0)}
+	g := NewGenerator(WithClock(clk), WithCounter(1))
+	if x := g.Generate(); x != 1 {
+		t.Errorf("Expected 1 got %d", x)
+	}
+	if xs := g.GenerateN(3); xs[0] != 2 || xs[2] != 4 {
+		t.Errorf("Expected 2 to 4 got %v", xs)
+	}
+	if first, err := g.Reserve(10); err != nil || first != 5 {
+		t.Errorf("Expected block from 5, got %d, %v", first, err)
+	}
+	clk.Advance(-time.Hour)
+	if x := g.Generate(); x != 15 {
+		t.Errorf("Expected 15 regardless of the clock, got %d", x)
+	}
+	clk.Advance(time.Hour)
+	g.SetSeen(1)
+	clk.Advance(30 * time.Minute)
+	g.SetSeen(2)
+	g.SetSeenFor(3, time.Minute)
+	clk.Advance(10 * time.Minute)
+	g.ExpireSeen(time.Hour)
+	if !g.Seen(1) || !g.Seen(2) || g.Seen(3) {
+		t.Error("Expected expiry by time flagged, and TTL to apply")
+	}
+	var buf bytes.Buffer
+	if err := g.ExportSeen(&buf); err != nil {
+		t.Fatal(err)
+	}
+	clk.Advance(45 * time.Minute)
+	g.ExpireSeen(time.Hour)
+	if g.Seen(1) || !g.Seen(2) {
+		t.Error("Expected value flagged over an hour ago to expire")
+	}
+	h := NewGenerator(WithClock(clk), WithCounter(1))
+	if err := h.ImportSeen(&buf); err != nil {
+		t.Fatal(err)
+	}
+	h.ExpireSeen(time.Hour)
+	if h.Seen(1) || !h.Seen(2) {
+		t.Error("Expected imported values to keep their times flagged")
+	}
+	if x := h.Generate(); x != 3 {
+		t.Errorf("Expected 3 after importing 2, got %d", x)
+	}
+}
@@ -0,0 +1,54 @@
+package serial
+
+import (
+	"strconv"
+	"time"
+)
+
+// Parts holds the components of a serial value, as returned by Decompose.
+type Parts struct {
+	// Time is the embedded time, at the layout's resolution.
+	Time time.Time
+	// Node is the node ID, or 0 if the layout has no node bits.
+	Node int64
+	// Sequence is the sequence number within the tick, or 0 if the layout
+	// has no sequence bits. For a generator created with WithCounter, it is
+	// the whole value.
+	Sequence int64
+	// Random holds the value's random bits, for a generator created with
+	// WithRandomBits.
+	Random uint64
+}
+
+// String returns a debugging form of the parts, such as
+// "2024-05-01T12:00:00.123456789Z node=3 seq=17", with the time in UTC.
+// Random bits are included in hexadecimal if there are any set.
+func (p Parts) String() string {
+	s := p.Time.UTC().Format(time.RFC3339Nano) + " node=" + strconv.FormatInt(p.Node, 10) +
+		" seq=" + strconv.FormatInt(p.Sequence, 10)
+	if p.Random != 0 {
+		s += " rand=0x" + strconv.FormatUint(p.Random, 16)
+	}
+	return s
+}
+
+// Decompose splits a serial value into the components packed into it by the
+// layout, so that it can be traced back to when and where it was generated.
+func (l Layout) Decompose(x Serial) Parts {
+	_, node, seq := l.unpack(x)
+	return Parts{Time: l.timeOf(x), Node: node, Sequence: seq}
+}
+
+// Decompose splits a serial value from the generator into its components,
+// taking account of its layout, epoch and any random bits. A value from a
+// generator created with WithCounter has no embedded time, so it is returned
+// whole as the sequence number.
+func (g *Generator) Decompose(x Serial) Parts {
+	if g.counter {
+		return Parts{Sequence: int64(x)}
+	}
+	mask := Serial(1)<<g.randomBits - 1
+	p := g.layout.Decompose(x &^ mask)
+	p.Random = uint64(x & mask)
+	return p
+}
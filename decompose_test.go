@@ -0,0 +1,36 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecompose(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 123000000, time.UTC)
+	clock := &fakeClock{now: now}
+	g := NewGenerator(WithClock(clock), WithLayout(SnowflakeLayout), WithNode(3))
+	var x Serial
+	for i := 0; i < 18; i++ {
+		x = g.Generate()
+	}
+	p := g.Decompose(x)
+	if !p.Time.Equal(now) || p.Node != 3 || p.Sequence != 17 {
+		t.Errorf("Wrong parts for %d: %+v", x, p)
+	}
+	if s := p.String(); s != "2024-05-01T12:00:00.123Z node=3 seq=17" {
+		t.Errorf("Wrong debug form %q", s)
+	}
+	g = NewGenerator(WithClock(clock))
+	if p := g.Decompose(g.Generate()); !p.Time.Equal(now) || p.Node != 0 || p.Sequence != 0 {
+		t.Errorf("Wrong parts for nanosecond value: %+v", p)
+	}
+	g = NewGenerator(WithClock(clock), WithRandomBits(8))
+	x = g.Generate()
+	if p := g.Decompose(x); p.Random != uint64(x&255) || p.Time.After(now) || now.Sub(p.Time) > 256 {
+		t.Errorf("Wrong parts for random value %d: %+v", x, p)
+	}
+	g = NewGenerator(WithCounter(100))
+	if p := g.Decompose(g.Generate()); p.Sequence != 100 || !p.Time.IsZero() {
+		t.Errorf("Wrong parts for counter value: %+v", p)
+	}
+}
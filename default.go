@@ -0,0 +1,45 @@
+package serial
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	defaultOnce sync.Once
+	defaultGen  *Generator
+)
+
+// Default returns the package's default generator, which is used by the
+// package-level functions Generate, Seen, SetSeen and ExpireSeen. It is
+// created with no options the first time it is needed.
+func Default() *Generator {
+	defaultOnce.Do(func() {
+		defaultGen = NewGenerator()
+	})
+	return defaultGen
+}
+
+// Generate generates a serial value from the default generator, for small
+// programs which only need one domain of serial values. It panics in the same
+// circumstances as Generator.Generate.
+func Generate() Serial {
+	return Default().Generate()
+}
+
+// Seen reports whether the specified Serial value has been flagged as seen
+// in the default generator.
+func Seen(x Serial) bool {
+	return Default().Seen(x)
+}
+
+// SetSeen flags the specified Serial value as seen in the default generator.
+func SetSeen(x Serial) {
+	Default().SetSeen(x)
+}
+
+// ExpireSeen expires the default generator's seen history, as for
+// Generator.ExpireSeen.
+func ExpireSeen(agelimit time.Duration) (removed, remaining int) {
+	return Default().ExpireSeen(agelimit)
+}
@@ -0,0 +1,21 @@
+package serial
+
+import "testing"
+
+func TestDefault(t *testing.T) {
+	if Default() != Default() {
+		t.Error("Expected the same default generator each time")
+	}
+	x := Generate()
+	if y := Generate(); y <= x {
+		t.Errorf("Expected value after %d got %d", x, y)
+	}
+	SetSeen(x)
+	if !Seen(x) || !Default().Seen(x) {
+		t.Errorf("Expected %d seen", x)
+	}
+	ExpireSeen(0)
+	if Seen(x) {
+		t.Errorf("Expected %d expired", x)
+	}
+}
@@ -0,0 +1,57 @@
+package serial
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDrift is returned when asked to create a generator which monitors its
+// clock for drift with an interval or threshold which isn't positive, or with
+// no function to call.
+var ErrDrift = errors.New("serial: invalid drift monitor")
+
+// driftMonitor holds the state of a generator's clock drift monitor.
+type driftMonitor struct {
+	interval  time.Duration
+	threshold time.Duration
+	f         func(time.Duration)
+	ref       time.Time
+	wall      time.Time
+}
+
+// WithDriftMonitor starts a background goroutine which reads the generator's
+// clock at the specified interval, and compares the time elapsed between
+// successive readings with the time elapsed according to the system's
+// monotonic clock. If they differ by more than the threshold, as when the
+// wall clock is stepped or a VM resumes from suspend, f is called with the
+// difference: positive if the clock jumped forwards, negative if it jumped
+// backwards. The goroutine runs until Close is called.
+func WithDriftMonitor(interval, threshold time.Duration, f func(time.Duration)) Option {
+	return func(g *Generator) error {
+		if interval <= 0 || threshold <= 0 || f == nil {
+			return ErrDrift
+		}
+		g.drift = &driftMonitor{interval: interval, threshold: threshold, f: f}
+		return nil
+	}
+}
+
+// start takes the first readings for the monitor.
+func (m *driftMonitor) start(c Clock) {
+	m.ref = time.Now()
+	m.wall = c.Now()
+}
+
+// check takes fresh readings, and calls the monitor's function if the clock
+// has drifted from the monotonic reference since the last readings.
+func (m *driftMonitor) check(c Clock) {
+	ref := time.Now()
+	wall := c.Now()
+	// Round strips the monotonic reading, so the clock's own time is
+	// compared with the reference's elapsed monotonic time.
+	d := wall.Round(0).Sub(m.wall.Round(0)) - ref.Sub(m.ref)
+	m.ref, m.wall = ref, wall
+	if d > m.threshold || d < -m.threshold {
+		m.f(d)
+	}
+}
@@ -0,0 +1,64 @@
+package serial
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// offsetClock is the system clock, offset by a distance which can be changed
+// safely while it is in use.
+type offsetClock struct {
+	offset atomic.Int64
+}
+
+func (c *offsetClock) Now() time.Time {
+	return time.Now().Add(time.Duration(c.offset.Load()))
+}
+
+func TestDriftMonitor(t *testing.T) {
+	if _, err := New(WithDriftMonitor(time.Second, 0, func(time.Duration) {})); err != ErrDrift {
+		t.Errorf("Expected ErrDrift, got %v", err)
+	}
+	if _, err := New(WithDriftMonitor(time.Second, time.Second, nil)); err != ErrDrift {
+		t.Errorf("Expected ErrDrift with no function, got %v", err)
+	}
+	var drift []time.Duration
+	m := &driftMonitor{threshold: time.Minute, f: func(d time.Duration) { drift = append(drift, d) }}
+	clock := &offsetClock{}
+	m.start(clock)
+	m.check(clock)
+	clock.offset.Store(int64(time.Hour))
+	m.check(clock)
+	m.check(clock)
+	clock.offset.Store(int64(-time.Hour))
+	m.check(clock)
+	if len(drift) != 2 {
+		t.Fatalf("Expected 2 drift reports, got %v", drift)
+	}
+	if d := drift[0] - time.Hour; d < -time.Second || d > time.Second {
+		t.Errorf("Expected drift of 1h, got %v", drift[0])
+	}
+	if d := drift[1] + 2*time.Hour; d < -time.Second || d > time.Second {
+		t.Errorf("Expected drift of -2h, got %v", drift[1])
+	}
+}
+
+func TestDriftMonitorBackground(t *testing.T) {
+	clock := &offsetClock{}
+	reported := make(chan time.Duration, 10)
+	g := NewGenerator(WithClock(clock), WithDriftMonitor(5*time.Millisecond, time.Minute, func(d time.Duration) {
+		reported <- d
+	}))
+	defer g.Close()
+	time.Sleep(20 * time.Millisecond)
+	clock.offset.Store(int64(time.Hour))
+	select {
+	case d := <-reported:
+		if d < 59*time.Minute {
+			t.Errorf("Expected drift of 1h, got %v", d)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Drift wasn't reported")
+	}
+}
@@ -0,0 +1,54 @@
+package serial
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+)
+
+// ErrInvalidBinary is returned when unmarshaling binary data which isn't
+// exactly 8 bytes long.
+var ErrInvalidBinary = errors.New("serial: invalid binary serial")
+
+// String returns the serial value in decimal.
+func (x Serial) String() string {
+	return strconv.FormatInt(int64(x), 10)
+}
+
+// MarshalText implements encoding.TextMarshaler, returning the serial value
+// in decimal. This means serials can be used as keys of maps marshaled to
+// JSON, and with flag.TextVar.
+func (x Serial) MarshalText() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(x), 10), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting the serial
+// value in decimal.
+func (x *Serial) UnmarshalText(b []byte) error {
+	return x.scanString(string(b))
+}
+
+// Set implements flag.Value, accepting the serial value in decimal, so that
+// a *Serial can be passed to flag.Var.
+func (x *Serial) Set(s string) error {
+	return x.scanString(s)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, returning the serial
+// value as 8 bytes in big-endian order, so that non-negative values sort
+// bytewise in the same order as numerically.
+func (x Serial) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(x))
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, accepting the form
+// returned by MarshalBinary.
+func (x *Serial) UnmarshalBinary(b []byte) error {
+	if len(b) != 8 {
+		return ErrInvalidBinary
+	}
+	*x = Serial(binary.BigEndian.Uint64(b))
+	return nil
+}
@@ -0,0 +1,71 @@
+package serial
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"flag"
+	"testing"
+)
+
+func TestText(t *testing.T) {
+	m := map[Serial]string{12: "a", 345: "b"}
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(b); s != `{"12":"a","345":"b"}` {
+		t.Errorf("Wrong JSON for map %s", s)
+	}
+	var p map[Serial]string
+	if err := json.Unmarshal(b, &p); err != nil {
+		t.Fatal(err)
+	}
+	if len(p) != 2 || p[345] != "b" {
+		t.Errorf("Round trip of map failed, got %v", p)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var x Serial
+	fs.TextVar(&x, "serial", Serial(0), "a serial")
+	if err := fs.Parse([]string{"-serial", "123456"}); err != nil {
+		t.Fatal(err)
+	}
+	if x != 123456 {
+		t.Errorf("Wrong value from flag, expected 123456 got %d", x)
+	}
+	if err := fs.Parse([]string{"-serial", "12x"}); err == nil {
+		t.Error("Expected an error from flag with bad value")
+	}
+	var y Serial
+	fs.Var(&y, "var", "a serial")
+	if err := fs.Parse([]string{"-var", "789"}); err != nil {
+		t.Fatal(err)
+	}
+	if y != 789 {
+		t.Errorf("Wrong value from flag.Var, expected 789 got %d", y)
+	}
+}
+
+func TestBinary(t *testing.T) {
+	x1 := gen.Generate()
+	x2 := gen.Generate()
+	b1, _ := x1.MarshalBinary()
+	b2, _ := x2.MarshalBinary()
+	if len(b1) != 8 || bytes.Compare(b1, b2) >= 0 {
+		t.Errorf("Binary forms don't sort: %x then %x", b1, b2)
+	}
+	var y Serial
+	if err := y.UnmarshalBinary(b1); err != nil || y != x1 {
+		t.Errorf("Round trip failed, expected %d got %d %v", x1, y, err)
+	}
+	if err := y.UnmarshalBinary(b1[:7]); err != ErrInvalidBinary {
+		t.Errorf("Expected ErrInvalidBinary, got %v", err)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(x2); err != nil {
+		t.Fatal(err)
+	}
+	if err := gob.NewDecoder(&buf).Decode(&y); err != nil || y != x2 {
+		t.Errorf("Round trip through gob failed, expected %d got %d %v", x2, y, err)
+	}
+}
@@ -0,0 +1,51 @@
+package serial
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrEntropy is returned when asked to create a generator with a nil
+// entropy source.
+var ErrEntropy = errors.New("serial: invalid entropy source")
+
+// WithEntropy sets the source of the random bytes the generator uses for
+// random bits, KSUIDs and short codes, in place of crypto/rand. A
+// deterministic source, such as a math/rand generator with a fixed seed,
+// makes those reproducible in tests; a hardened environment can supply its
+// own random number generator. Reads are serialized, so the source needn't
+// be safe for concurrent use, and must fill the buffer or return an error.
+func WithEntropy(r io.Reader) Option {
+	return func(g *Generator) error {
+		if r == nil {
+			return ErrEntropy
+		}
+		g.entropy = &lockedReader{r: r}
+		return nil
+	}
+}
+
+// lockedReader serializes reads from a reader which may not be safe for
+// concurrent use.
+type lockedReader struct {
+	mutex sync.Mutex
+	r     io.Reader
+}
+
+func (lr *lockedReader) Read(b []byte) (int, error) {
+	lr.mutex.Lock()
+	defer lr.mutex.Unlock()
+	return io.ReadFull(lr.r, b)
+}
+
+// readRandom fills b with bytes from the generator's entropy source.
+func (g *Generator) readRandom(b []byte) error {
+	if g.entropy == nil {
+		_, err := rand.Read(b)
+		return err
+	}
+	_, err := g.entropy.Read(b)
+	return err
+}
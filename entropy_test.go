@@ -0,0 +1,54 @@
+package serial
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("no entropy")
+}
+
+func TestEntropy(t *testing.T) {
+	if _, err := New(WithEntropy(nil)); err != ErrEntropy {
+		t.Errorf("Expected ErrEntropy got %v", err)
+	}
+	gen := func() *Generator {
+		return NewGenerator(WithClock(&fakeClock{now: time.Unix(1000, 0)}), WithRandomBits(8),
+			WithEntropy(rand.New(rand.NewSource(42))))
+	}
+	a, b := gen(), gen()
+	for i := 0; i < 100; i++ {
+		if x, y := a.Generate(), b.Generate(); x != y {
+			t.Fatalf("Expected the same values from the same seed, got %d and %d", x, y)
+		}
+	}
+	if x, y := a.GenerateKSUID(), b.GenerateKSUID(); x != y {
+		t.Errorf("Expected the same KSUIDs from the same seed, got %s and %s", x, y)
+	}
+	sa, err := NewShortCoder(a, 6, "", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb, _ := NewShortCoder(b, 6, "", time.Hour)
+	ca, _ := sa.Generate()
+	cb, _ := sb.Generate()
+	if ca != cb {
+		t.Errorf("Expected the same short codes from the same seed, got %s and %s", ca, cb)
+	}
+
+	// A source which runs dry is an error, not a short read.
+	g := NewGenerator(WithRandomBits(8), WithEntropy(bytes.NewReader(make([]byte, 4))))
+	if _, err := g.TryGenerate(); err == nil {
+		t.Error("Expected error from exhausted entropy source")
+	}
+	g = NewGenerator(WithRandomBits(8), WithEntropy(failingReader{}))
+	if _, err := g.TryGenerate(); err == nil || err.Error() != "no entropy" {
+		t.Errorf("Expected entropy error got %v", err)
+	}
+}
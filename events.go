@@ -0,0 +1,103 @@
+package serial
+
+import (
+	"strconv"
+	"time"
+)
+
+// EventKind identifies the action which produced an Event.
+type EventKind int
+
+const (
+	// EventGenerate is sent when a serial value is generated.
+	EventGenerate EventKind = iota + 1
+	// EventSeen is sent when a serial value is flagged as seen.
+	EventSeen
+	// EventExpire is sent when seen history is expired.
+	EventExpire
+	// EventUnset is sent when a serial value is removed from the seen
+	// history by UnsetSeen.
+	EventUnset
+	// EventRaise is sent when ImportSeen or Restore moves the generator on
+	// past an imported value which is ahead of both the last value issued
+	// and the generator's clock, so that the history and the generator
+	// disagree.
+	EventRaise
+)
+
+// String returns the name of the event kind, such as "generate".
+func (k EventKind) String() string {
+	switch k {
+	case EventGenerate:
+		return "generate"
+	case EventSeen:
+		return "seen"
+	case EventExpire:
+		return "expire"
+	case EventUnset:
+		return "unset"
+	case EventRaise:
+		return "raise"
+	}
+	return "EventKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// eventBuffer is the capacity of the channel returned by Events.
+const eventBuffer = 256
+
+// Event describes an action performed by a Generator.
+type Event struct {
+	Kind EventKind
+	// Serial is the value generated, flagged as seen or unset, or the
+	// imported value moved past for EventRaise. It is zero for EventExpire.
+	Serial Serial
+	// Count is the number of values removed from the seen history by
+	// EventExpire. It is zero for other kinds of event.
+	Count int
+	Time  time.Time
+}
+
+// Events returns a channel which receives an Event for every serial value
+// generated, every value newly flagged as seen or unset, every call to
+// ExpireSeen, and every imported value the generator has to jump past, for
+// consumers which want to follow the generator's activity. Every call
+// returns the same channel, and nothing is sent until the first call.
+//
+// Events are never allowed to slow down the generator. If the consumer falls
+// behind and the channel's buffer fills up, further events are dropped until
+// there is room again. The number of events dropped so far is available from
+// DroppedEvents. Imported values jumped past are also counted in
+// Stats.Raised, so they can't go unnoticed.
+func (g *Generator) Events() <-chan Event {
+	g.eventsOnce.Do(func() {
+		g.events.Store(make(chan Event, eventBuffer))
+	})
+	return g.events.Load().(chan Event)
+}
+
+// DroppedEvents returns the total number of events which have been discarded
+// because the consumer of Events wasn't keeping up.
+func (g *Generator) DroppedEvents() uint64 {
+	return g.dropped.Load()
+}
+
+// emit sends an event to the Events channel, if anyone has asked for it,
+// without blocking.
+func (g *Generator) emit(kind EventKind, x Serial, count int) {
+	ch, _ := g.events.Load().(chan Event)
+	if ch == nil && g.audit == nil {
+		return
+	}
+	e := Event{Kind: kind, Serial: x, Count: count, Time: g.clock.Now()}
+	if g.audit != nil {
+		g.audit.record(e)
+	}
+	if ch == nil {
+		return
+	}
+	select {
+	case ch <- e:
+	default:
+		g.dropped.Add(1)
+	}
+}
@@ -0,0 +1,46 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	g := NewGenerator()
+	g.SetSeen(g.Generate())
+	ch := g.Events()
+	if ch != g.Events() {
+		t.Error("Events returned a different channel on second call")
+	}
+	x := g.Generate()
+	g.SetSeen(x)
+	g.SetSeen(x)
+	g.ExpireSeen(time.Duration(0))
+	want := []Event{
+		{Kind: EventGenerate, Serial: x},
+		{Kind: EventSeen, Serial: x},
+		{Kind: EventExpire, Count: 2},
+	}
+	for _, w := range want {
+		e := <-ch
+		if e.Kind != w.Kind || e.Serial != w.Serial || e.Count != w.Count {
+			t.Errorf("Wrong event, expected %+v got %+v", w, e)
+		}
+	}
+	select {
+	case e := <-ch:
+		t.Errorf("Unexpected extra event %+v", e)
+	default:
+	}
+}
+
+func TestDroppedEvents(t *testing.T) {
+	g := NewGenerator()
+	g.Events()
+	for i := 0; i < eventBuffer+10; i++ {
+		g.Generate()
+	}
+	if d := g.DroppedEvents(); d != 10 {
+		t.Errorf("Wrong dropped count, expected 10 got %d", d)
+	}
+}
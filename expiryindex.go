@@ -0,0 +1,108 @@
+package serial
+
+import "sort"
+
+// expiryBucketBits is the number of low bits of an expiry key ignored when
+// grouping entries into buckets. For keys in nanoseconds, each bucket spans
+// about a second.
+const expiryBucketBits = 30
+
+// expiryIndex groups the values in a shard of the seen history into buckets
+// by the key they expire by, so that expiry only has to visit the values
+// which are old enough to expire, rather than the whole history. Buckets
+// list values which have since been removed or replaced until the buckets
+// themselves expire, so whoever visits them must check the history.
+type expiryIndex struct {
+	ids     []int64
+	buckets map[int64][]Serial
+}
+
+// add indexes x under key.
+func (ix *expiryIndex) add(key int64, x Serial) {
+	id := key >> expiryBucketBits
+	b, ok := ix.buckets[id]
+	if !ok {
+		if ix.buckets == nil {
+			ix.buckets = make(map[int64][]Serial)
+		}
+		// Values usually arrive in order, so new buckets are usually
+		// appended.
+		i := len(ix.ids)
+		if i > 0 && ix.ids[i-1] > id {
+			i = sort.Search(len(ix.ids), func(j int) bool { return ix.ids[j] > id })
+		}
+		ix.ids = append(ix.ids, 0)
+		copy(ix.ids[i+1:], ix.ids[i:])
+		ix.ids[i] = id
+	}
+	ix.buckets[id] = append(b, x)
+}
+
+// expiryCursor records where an expiry with a budget stopped, so that the
+// next can carry on from there.
+type expiryCursor struct {
+	id  int64
+	pos int
+}
+
+// expire calls keep for every value indexed under a key no greater than
+// max, along with the others in the same bucket, to expire it if need be.
+// keep reports whether the value is still in the history, unexpired and
+// indexed by this index, so must stay in it. Buckets which are left empty
+// are dropped.
+//
+// If budget is positive, expire stops after visiting that many values,
+// records where it stopped in the cursor, and reports whether there were more
+// to visit. It starts from the cursor, so the cursor's id must be
+// math.MinInt64 for the first call.
+func (ix *expiryIndex) expire(max int64, budget int, c *expiryCursor, keep func(x Serial) bool) bool {
+	last := max >> expiryBucketBits
+	i := 0
+	if c != nil {
+		i = sort.Search(len(ix.ids), func(k int) bool { return ix.ids[k] >= c.id })
+	}
+	ids := ix.ids[:i]
+	visited := 0
+	more := false
+	for ; i < len(ix.ids) && ix.ids[i] <= last; i++ {
+		id := ix.ids[i]
+		b := ix.buckets[id]
+		j := 0
+		if c != nil && id == c.id {
+			j = min(c.pos, len(b))
+		}
+		kept := b[:j]
+		for ; j < len(b); j++ {
+			if budget > 0 && visited == budget {
+				break
+			}
+			visited++
+			if keep(b[j]) {
+				kept = append(kept, b[j])
+			}
+		}
+		if j < len(b) {
+			more = true
+			if c != nil {
+				c.id, c.pos = id, len(kept)
+			}
+			kept = append(kept, b[j:]...)
+		}
+		if len(kept) == 0 {
+			delete(ix.buckets, id)
+		} else {
+			ix.buckets[id] = kept
+			ids = append(ids, id)
+		}
+		if more {
+			i++
+			break
+		}
+	}
+	ix.ids = append(ids, ix.ids[i:]...)
+	if c != nil && !more {
+		// Finished, so later calls have nothing to visit.
+		c.id, c.pos = last+1, 0
+	}
+	return more
+}
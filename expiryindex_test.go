@@ -0,0 +1,91 @@
+package serial
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestExpiryIndex(t *testing.T) {
+	var ix expiryIndex
+	const bucket = 1 << expiryBucketBits
+	// Out of order, so a bucket is inserted before others.
+	for _, key := range []int64{5 * bucket, 7 * bucket, 1 * bucket, 5*bucket + 1, 3 * bucket} {
+		ix.add(key, Serial(key))
+	}
+	if len(ix.ids) != 4 || ix.ids[0] != 1 || ix.ids[1] != 3 || ix.ids[3] != 7 {
+		t.Fatalf("Wrong buckets %v", ix.ids)
+	}
+	var visited []Serial
+	ix.expire(5*bucket, 0, nil, func(x Serial) bool {
+		visited = append(visited, x)
+		return x > 5*bucket
+	})
+	if len(visited) != 4 {
+		t.Errorf("Expected 4 values visited got %v", visited)
+	}
+	if len(ix.ids) != 2 || ix.ids[0] != 5 || len(ix.buckets[5]) != 1 || len(ix.buckets) != 2 {
+		t.Errorf("Wrong buckets after expiry %v %v", ix.ids, ix.buckets)
+	}
+}
+
+func TestExpireIndexed(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	g := NewGenerator(WithClock(clock))
+	x := g.Generate()
+	g.SetSeen(x)
+	g.SetSeenFor(x+1, 2*time.Hour)
+	clock.Advance(time.Hour)
+	y := g.Generate()
+	g.SetSeen(y)
+	// Remove and flag x again, so it is indexed twice.
+	g.UnsetSeen(x)
+	g.SetSeen(x)
+	g.ExpireSeen(30 * time.Minute)
+	if g.Seen(x) || !g.Seen(x+1) || !g.Seen(y) {
+		t.Errorf("Wrong values expired: %v %v %v", g.Seen(x), g.Seen(x+1), g.Seen(y))
+	}
+	g.SetSeen(x)
+	clock.Advance(2 * time.Hour)
+	g.ExpireSeen(time.Hour)
+	if n := g.SeenCount(); n != 0 {
+		t.Errorf("Expected everything expired, %d left", n)
+	}
+}
+
+func BenchmarkExpireSeen(b *testing.B) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	g := NewGenerator(WithClock(clock))
+	for i := 0; i < 1000000; i++ {
+		g.SetSeen(g.Generate())
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.ExpireSeen(time.Hour)
+	}
+}
+
+func TestExpiryIndexBudget(t *testing.T) {
+	var ix expiryIndex
+	for x := Serial(0); x < 10; x++ {
+		ix.add(int64(x), x)
+	}
+	c := expiryCursor{id: math.MinInt64}
+	visits := 0
+	// Even values stay, so the bucket is only partly emptied each time.
+	for more := true; more; {
+		more = ix.expire(100, 3, &c, func(x Serial) bool {
+			visits++
+			return x%2 == 0
+		})
+		if visits > 10 {
+			t.Fatal("Expiry didn't make progress")
+		}
+	}
+	if visits != 10 || len(ix.buckets[0]) != 5 {
+		t.Errorf("Expected 10 visits and 5 values left, got %d and %v", visits, ix.buckets[0])
+	}
+	if ix.expire(100, 3, &c, func(Serial) bool { return true }) {
+		t.Error("Expected nothing more to visit")
+	}
+}
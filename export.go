@@ -0,0 +1,150 @@
+package serial
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// exportedEntry is the form in which ExportSeen writes each seen value.
+type exportedEntry struct {
+	Serial  Serial      `json:"serial"`
+	Expires int64       `json:"expires,omitempty"`
+	Added   int64       `json:"added,omitempty"`
+	Value   interface{} `json:"value,omitempty"`
+}
+
+// ExportSeen writes the seen history to w, so that it can be saved across
+// restarts or moved to another generator with ImportSeen. Values whose time to
+// live has run out are left out.
+//
+// The format is a stream of JSON objects, one per line, each with a "serial"
+// field holding the value, an "expires" field holding the time in Unix
+// nanoseconds at which it expires, if it was flagged with SetSeenFor, an
+// "added" field holding the time at which it was flagged, for a generator in
+// counter mode, and a "value" field holding the value attached by
+// SetSeenWithValue, if any. The attached values must be suitable for
+// encoding/json.
+//
+// The values in a history kept in Bloom filters can't be recovered, so for a
+// generator created with WithBloomFilter nothing is written.
+func (g *Generator) ExportSeen(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	now := g.clock.Now().UnixNano()
+	var err error
+	g.store.each(func(x Serial, e seenEntry) bool {
+		if e.dead(now) {
+			return true
+		}
+		ent := exportedEntry{Serial: x, Value: e.value}
+		if e.deadline > 0 {
+			ent.Expires = e.deadline
+		} else if e.deadline < 0 {
+			ent.Added = -e.deadline
+		}
+		err = enc.Encode(ent)
+		return err == nil
+	})
+	return err
+}
+
+// ImportSeen reads seen history written by ExportSeen from r, and flags each
+// value as seen, along with its time to live and attached value. Values which
+// are already flagged as seen are left as they are, and values whose time to
+// live has run out are skipped. Attached values are decoded as for
+// json.Unmarshal into an interface{}, so a struct comes back as a
+// map[string]interface{}.
+//
+// So that imported values can never be issued again, the generator is moved
+// on past the highest imported value if necessary, even if the generator's
+// clock is behind it. Since this means the history is ahead of the generator,
+// each value which does so is counted in Stats.Raised, and an EventRaise is
+// sent for it.
+func (g *Generator) ImportSeen(r io.Reader) error {
+	return g.importSeen(r, 0)
+}
+
+// importSeen implements ImportSeen. If saved isn't zero, it is the last value
+// issued according to the source of the history, and any value after it is
+// reported with EventRaise.
+func (g *Generator) importSeen(r io.Reader, saved Serial) error {
+	dec := json.NewDecoder(r)
+	for {
+		var ent exportedEntry
+		if err := dec.Decode(&ent); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		g.raiseImported(ent.Serial, saved)
+		now := g.clock.Now().UnixNano()
+		e := seenEntry{deadline: ent.Expires, value: ent.Value}
+		if e.dead(now) {
+			continue
+		}
+		if e.deadline == 0 && (ent.Added > 0 || g.counter) {
+			e.deadline = -ent.Added
+			if e.deadline == 0 {
+				e.deadline = -now
+			}
+		}
+		g.snapMutex.RLock()
+		added := g.store.add(ent.Serial, e, now)
+		g.snapMutex.RUnlock()
+		if added {
+			g.flagged(ent.Serial, e)
+		}
+	}
+}
+
+// MergeSeen flags x as seen with the details in e, as received from a peer
+// generator replicating its seen history, and reports whether x was newly
+// flagged. Merging is a union, so values already flagged as seen are left as
+// they are, and values are never unflagged. So that values aren't
+// resurrected by a peer which hasn't caught up, MergeSeen skips values whose
+// time to live has run out, and values which expire by age but which were
+// generated before the cutoff of the generator's last expiry. Unlike
+// ImportSeen, it doesn't move the generator on past merged values, which
+// were issued by peers. It returns a *QuotaError if the generator's seen
+// quota has been reached.
+func (g *Generator) MergeSeen(x Serial, e SeenEntry) (bool, error) {
+	now := g.clock.Now().UnixNano()
+	ie := importEntry(e)
+	if ie.dead(now) || ie.deadline == 0 && !g.counter && int64(x) < g.horizon.Load() {
+		return false, nil
+	}
+	added, err := g.addSeen(x, ie, now)
+	if added {
+		g.flagged(x, ie)
+	}
+	return added, err
+}
+
+// raiseImported moves the generator on past x, a value from imported
+// history, counting it and sending EventRaise if x is after saved, or if
+// saved is zero, after both the last value issued and, unless the generator
+// is in counter mode, the current tick.
+func (g *Generator) raiseImported(x, saved Serial) {
+	ahead := x > saved
+	if saved == 0 {
+		ahead = int64(x) > g.lastSerial.Load()
+		if ahead && !g.counter {
+			ahead = x >= g.ahead(g.clock.Now())
+		}
+	}
+	g.raise(x)
+	if ahead {
+		g.counters.raised.Add(1)
+		g.emit(EventRaise, x, 0)
+	}
+}
+
+// raise ensures that the generator's last issued value is at least x, so
+// that x and everything before it are never generated.
+func (g *Generator) raise(x Serial) {
+	for {
+		last := g.lastSerial.Load()
+		if last >= int64(x) || g.lastSerial.CompareAndSwap(last, int64(x)) {
+			return
+		}
+	}
+}
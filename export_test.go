@@ -0,0 +1,150 @@
+package serial
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportImportSeen(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk))
+	plain := g.Generate()
+	ttl := g.Generate()
+	gone := g.Generate()
+	valued := g.Generate()
+	g.SetSeen(plain)
+	g.SetSeenFor(ttl, time.Hour)
+	g.SetSeenFor(gone, time.Second)
+	g.SetSeenWithValue(valued, "alice")
+	clk.Advance(2 * time.Second)
+
+	var buf bytes.Buffer
+	if err := g.ExportSeen(&buf); err != nil {
+		t.Fatalf("ExportSeen failed: %v", err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 3 {
+		t.Errorf("Expected 3 exported values got %d", n)
+	}
+
+	g2 := NewGenerator(WithClock(clk))
+	if err := g2.ImportSeen(&buf); err != nil {
+		t.Fatalf("ImportSeen failed: %v", err)
+	}
+	if !g2.Seen(plain) || !g2.Seen(ttl) || !g2.Seen(valued) {
+		t.Errorf("Expected imported values to be seen")
+	}
+	if g2.Seen(gone) {
+		t.Errorf("Expected %d to have expired, got 'seen'", gone)
+	}
+	if v, _ := g2.SeenValue(valued); v != "alice" {
+		t.Errorf("Expected value alice got %v", v)
+	}
+	clk.Advance(2 * time.Hour)
+	if g2.Seen(ttl) {
+		t.Errorf("Expected %d to have expired, got 'seen'", ttl)
+	}
+}
+
+func TestImportSeenWatermark(t *testing.T) {
+	// A snapshot taken ahead of the new generator's clock, as if the clock
+	// had been stepped back while the process was down.
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	x := Serial(time.Unix(2000, 0).UnixNano())
+	g := NewGenerator(WithClock(clk))
+	in := strings.NewReader(`{"serial":` + x.String() + "}\n")
+	if err := g.ImportSeen(in); err != nil {
+		t.Fatalf("ImportSeen failed: %v", err)
+	}
+	if y := g.Generate(); y <= x {
+		t.Errorf("Expected value after %d got %d", x, y)
+	}
+}
+
+func TestImportSeenRaise(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk))
+	ch := g.Events()
+	past := Serial(time.Unix(500, 0).UnixNano())
+	x := Serial(time.Unix(2000, 0).UnixNano())
+	in := strings.NewReader(`{"serial":` + past.String() + "}\n" +
+		`{"serial":` + x.String() + "}\n" + `{"serial":` + (x - 1).String() + "}\n")
+	if err := g.ImportSeen(in); err != nil {
+		t.Fatalf("ImportSeen failed: %v", err)
+	}
+	var raised []Serial
+	for len(ch) > 0 {
+		if e := <-ch; e.Kind == EventRaise {
+			raised = append(raised, e.Serial)
+		}
+	}
+	if len(raised) != 1 || raised[0] != x {
+		t.Errorf("Expected EventRaise for %d only, got %v", x, raised)
+	}
+	if n := g.Stats().Raised; n != 1 {
+		t.Errorf("Expected 1 raised got %d", n)
+	}
+	// The count is kept even when the events are dropped.
+	var b strings.Builder
+	for i := 1; i <= eventBuffer+10; i++ {
+		b.WriteString(`{"serial":` + (x + Serial(i)).String() + "}\n")
+	}
+	if err := g.ImportSeen(strings.NewReader(b.String())); err != nil {
+		t.Fatalf("ImportSeen failed: %v", err)
+	}
+	if n := g.Stats().Raised; n != eventBuffer+11 {
+		t.Errorf("Expected %d raised got %d", eventBuffer+11, n)
+	}
+}
+
+func TestImportSeenInvalid(t *testing.T) {
+	g := NewGenerator()
+	if err := g.ImportSeen(strings.NewReader(`{"serial":"bogus"}`)); err == nil {
+		t.Error("Expected error importing invalid history")
+	}
+}
+
+func TestMergeSeen(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk), WithSeenQuota(2))
+	x := g.Generate()
+	ahead := Serial(time.Unix(2000, 0).UnixNano())
+	for _, c := range []struct {
+		x     Serial
+		e     SeenEntry
+		added bool
+	}{
+		{x, SeenEntry{}, true},
+		{x, SeenEntry{Value: "again"}, false},
+		{ahead, SeenEntry{Value: "v"}, true},
+		{x + 1, SeenEntry{Expires: clk.now}, false},
+	} {
+		if added, err := g.MergeSeen(c.x, c.e); err != nil || added != c.added {
+			t.Errorf("Expected %v merging %d, got %v, %v", c.added, c.x, added, err)
+		}
+	}
+	if v, _ := g.SeenValue(ahead); v != "v" {
+		t.Errorf("Expected merged value, got %v", v)
+	}
+	if y := g.Generate(); y >= ahead {
+		t.Errorf("Expected generator not to move past merged value, got %d", y)
+	}
+
+	// Values the generator has already expired aren't resurrected.
+	clk.Advance(time.Hour)
+	g.ExpireSeen(time.Minute)
+	if added, _ := g.MergeSeen(x, SeenEntry{}); added {
+		t.Error("Expected expired value not to be merged")
+	}
+	if added, _ := g.MergeSeen(x, SeenEntry{Expires: clk.now.Add(time.Hour)}); !added {
+		t.Error("Expected value with time to live to be merged")
+	}
+	z := g.Generate()
+	if added, err := g.MergeSeen(z, SeenEntry{}); !added || err != nil {
+		t.Errorf("Expected new value to be merged, got %v, %v", added, err)
+	}
+	if _, err := g.MergeSeen(z+1, SeenEntry{}); err == nil {
+		t.Error("Expected quota error")
+	}
+}
@@ -0,0 +1,26 @@
+package serial
+
+import "expvar"
+
+// Publish publishes the generator's state with expvar under the specified
+// name, so that it appears in /debug/vars. The published value is a JSON
+// object with the figures from Stats, and the last value issued:
+//
+//	{"generated": 42, "last": 1700000000000000041, "seen": 3, ...}
+//
+// As with expvar.Publish, it panics if the name is already in use.
+func (g *Generator) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		s := g.Stats()
+		return map[string]interface{}{
+			"generated":   s.Generated,
+			"last":        g.lastSerial.Load(),
+			"seen":        s.Seen,
+			"expirations": s.Expirations,
+			"expired":     s.Expired,
+			"rollbacks":   s.Rollbacks,
+			"ahead":       s.Ahead,
+			"raised":      s.Raised,
+		}
+	}))
+}
@@ -0,0 +1,26 @@
+package serial
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	g := NewGenerator()
+	g.Publish("serial_test_publish")
+	g.GenerateN(3)
+	x := g.Generate()
+	g.SetSeen(x)
+	var vars struct {
+		Generated uint64
+		Last      Serial
+		Seen      int
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("serial_test_publish").String()), &vars); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if vars.Generated != 4 || vars.Last != x || vars.Seen != 1 {
+		t.Errorf("Unexpected values %+v", vars)
+	}
+}
@@ -0,0 +1,67 @@
+package serial
+
+// Format returns the serial value in Crockford's Base32, laid out according
+// to a pattern such as "XXXX-XXXX-XXXX", for printing on invoices or licence
+// keys. Each X in the pattern is replaced by a symbol, and every other
+// character is copied as it is. If the pattern has more X's than the value
+// needs, it is padded with leading zeros; if it has fewer, the extra symbols
+// are added before the first X, so the value is never truncated. Nanosecond
+// values need 13 symbols, and values less than 2^60 need 12.
+//
+// ParseFormatted reads the value back, whatever the pattern.
+func (x Serial) Format(pattern string) string {
+	n := 0
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == 'X' {
+			n++
+		}
+	}
+	sym := x.Base32()
+	for len(sym) > n && sym[0] == '0' {
+		sym = sym[1:]
+	}
+	for len(sym) < n {
+		sym = "0" + sym
+	}
+	b := make([]byte, 0, len(pattern)+len(sym)-n)
+	extra := len(sym) - n
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != 'X' {
+			b = append(b, pattern[i])
+			continue
+		}
+		b = append(b, sym[:extra+1]...)
+		sym = sym[extra+1:]
+		extra = 0
+	}
+	// Only a pattern with no X's leaves symbols over.
+	return string(append(b, sym...))
+}
+
+// ParseFormatted parses a serial value formatted by Format with any pattern,
+// or written in Crockford's Base32 with any separators. Letters and digits
+// are read as Base32 symbols, ignoring case, with I and L read as 1 and O as
+// 0, and any other characters, such as hyphens, spaces or dots, are ignored.
+// It returns ErrInvalidBase32 if there are no symbols, or too many.
+func ParseFormatted(s string) (Serial, error) {
+	var (
+		n     uint64
+		empty = true
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z') {
+			continue
+		}
+		v := crockfordValue(c)
+		if v < 0 || n>>59 != 0 {
+			return 0, ErrInvalidBase32
+		}
+		n = n<<5 | uint64(v)
+		empty = false
+	}
+	if empty {
+		return 0, ErrInvalidBase32
+	}
+	return Serial(n), nil
+}
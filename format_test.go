@@ -0,0 +1,44 @@
+package serial
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	g := NewGenerator()
+	for _, c := range []struct {
+		x       Serial
+		pattern string
+		want    string
+	}{
+		{0, "XXXX-XXXX", "0000-0000"},
+		{33, "XXX-XXX", "000-011"},
+		{1<<60 - 1, "XXXX-XXXX-XXXX", "ZZZZ-ZZZZ-ZZZZ"},
+		{1 << 60, "XXXX-XXXX-XXXX", "10000-0000-0000"},
+		{1<<63 - 1, "XX XX", "7ZZZZZZZZZZ ZZ"},
+		{33, "INV", "INV11"},
+	} {
+		if s := c.x.Format(c.pattern); s != c.want {
+			t.Errorf("Expected %q for %d with %q, got %q", c.want, c.x, c.pattern, s)
+		}
+	}
+	for _, x := range []Serial{0, 1, g.Generate(), 1<<63 - 1, -1} {
+		s := x.Format("XXXXX-XXXX-XXXX")
+		y, err := ParseFormatted(strings.ToLower(strings.Replace(s, "-", " ", 1)))
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", s, err)
+		}
+		if y != x {
+			t.Errorf("Round trip failed, expected %d got %d via %q", x, y, s)
+		}
+	}
+	if x, err := ParseFormatted("0O-IL"); err != nil || x != 33 {
+		t.Errorf("Expected 33 got %d %v", x, err)
+	}
+	for _, s := range []string{"", "--", "U000", "10000000000000", "ZZZZZZZZZZZZZZ"} {
+		if _, err := ParseFormatted(s); err != ErrInvalidBase32 {
+			t.Errorf("Expected ErrInvalidBase32 for %q, got %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+// Package gossip replicates the seen histories of serial generators between
+// peers, so that a cluster of replicas gets best-effort one-time-use
+// semantics without a central Redis or database.
+//
+//	gen, err := serial.New(serial.WithAutoExpire(time.Minute, 24*time.Hour))
+//	...
+//	r, err := gossip.New(gen, gossip.HTTPTransport{}, []string{"http://10.0.0.2:8080/gossip", "http://10.0.0.3:8080/gossip"})
+//	...
+//	defer r.Close()
+//	http.Handle("/gossip", r.Handler())
+//
+// A Replicator collects the values newly flagged as seen by its generator,
+// and at each interval sends them as a Delta to a random selection of peers,
+// which merge them into their own histories with serial.Generator.MergeSeen.
+// Values a peer hadn't seen before are passed on in turn, so they spread
+// through the cluster even when each replica only talks to a few others.
+// Merging is a union, so deltas can arrive in any order, more than once, and
+// from any peer, and the histories converge. Values unflagged by UnsetSeen
+// aren't unflagged on peers.
+//
+// Replication is asynchronous, so two replicas can both accept the same value
+// during the interval before they hear about each other's use of it. It
+// narrows that window from forever to roughly the interval times the number
+// of hops, rather than closing it.
+//
+// Entries which expire by time to live carry their expiry time with them, so
+// they expire on every replica at once. Entries which expire by age expire
+// consistently if every replica uses the same age limit, as with
+// serial.WithAutoExpire or ExpireSeenBefore, and a replica won't merge a value
+// it has already expired, so late deltas don't resurrect old values.
+package gossip
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lpar/serial"
+)
+
+var (
+	// ErrInterval is returned by New when the interval isn't positive.
+	ErrInterval = errors.New("gossip: invalid interval")
+	// ErrFanout is returned by New when the fanout isn't positive.
+	ErrFanout = errors.New("gossip: invalid fanout")
+	// ErrBatchSize is returned by New when the batch size or buffer size
+	// isn't positive.
+	ErrBatchSize = errors.New("gossip: invalid batch size")
+)
+
+// Entry is a value in a Delta, with the details of its seen entry.
+type Entry struct {
+	Serial serial.Serial `json:"serial"`
+	// Expires is the time in Unix nanoseconds at which the entry expires, if
+	// it was flagged with a time to live, or zero if it expires by age.
+	Expires int64 `json:"expires,omitempty"`
+	// Value is the value attached by SetSeenWithValue, if any. It must be
+	// suitable for the transport, such as for encoding/json with
+	// HTTPTransport.
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Delta is a batch of values newly flagged as seen, sent from one replica to
+// another.
+type Delta struct {
+	// From is the name of the sending replica, as set by WithName.
+	From    string  `json:"from,omitempty"`
+	Entries []Entry `json:"entries"`
+}
+
+// Transport delivers deltas to peers. HTTPTransport is a simple one which
+// sends them to the Handler of the peer's Replicator; others can use any
+// messaging system, as long as the peer's Replicator has Receive called with
+// each delta.
+type Transport interface {
+	// Send delivers d to the peer, which is identified by one of the names
+	// passed to New or SetPeers.
+	Send(ctx context.Context, peer string, d Delta) error
+}
+
+// TransportFunc is an ordinary function used as a Transport.
+type TransportFunc func(ctx context.Context, peer string, d Delta) error
+
+// Send calls f.
+func (f TransportFunc) Send(ctx context.Context, peer string, d Delta) error {
+	return f(ctx, peer, d)
+}
+
+// Option configures a Replicator.
+type Option func(*Replicator) error
+
+// WithInterval sets how often values are sent to peers. The default is one
+// second.
+func WithInterval(d time.Duration) Option {
+	return func(r *Replicator) error {
+		if d <= 0 {
+			return ErrInterval
+		}
+		r.interval = d
+		return nil
+	}
+}
+
+// WithFanout sets how many peers, chosen at random, are sent each delta. The
+// default is 3; every peer is sent each delta if there are no more than that.
+func WithFanout(n int) Option {
+	return func(r *Replicator) error {
+		if n <= 0 {
+			return ErrFanout
+		}
+		r.fanout = n
+		return nil
+	}
+}
+
+// WithBatchSize sets the largest number of entries sent in a single delta,
+// and the largest number of entries waiting to be sent. Values flagged as
+// seen while the buffer is full aren't replicated, and are counted by
+// Dropped. The defaults are 1000 and 100000.
+func WithBatchSize(batch, buffer int) Option {
+	return func(r *Replicator) error {
+		if batch <= 0 || buffer <= 0 {
+			return ErrBatchSize
+		}
+		r.batch = batch
+		r.buffer = buffer
+		return nil
+	}
+}
+
+// WithName sets the name sent as the From field of each delta, so that
+// receivers can tell where it came from.
+func WithName(name string) Option {
+	return func(r *Replicator) error {
+		r.name = name
+		return nil
+	}
+}
+
+// WithErrorHandler sets a function to be called when a delta can't be sent to
+// a peer during the background replication, since there is no caller to
+// return the error to. The entries it held are not resent to that peer.
+func WithErrorHandler(f func(peer string, err error)) Option {
+	return func(r *Replicator) error {
+		r.onError = f
+		return nil
+	}
+}
+
+// Replicator exchanges a generator's seen history with its peers.
+type Replicator struct {
+	gen       *serial.Generator
+	transport Transport
+	name      string
+	interval  time.Duration
+	fanout    int
+	batch     int
+	buffer    int
+	onError   func(string, error)
+
+	mutex   sync.Mutex
+	peers   []string
+	pending []Entry
+	closed  bool
+	dropped atomic.Uint64
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// New creates a Replicator which sends the values gen flags as seen to peers
+// through the transport, in the background, until Close is called. Values
+// already in gen's history aren't sent; a new replica can catch up from a
+// peer's Snapshot or ExportSeen before it starts serving.
+func New(gen *serial.Generator, t Transport, peers []string, opts ...Option) (*Replicator, error) {
+	r := &Replicator{
+		gen:       gen,
+		transport: t,
+		interval:  time.Second,
+		fanout:    3,
+		batch:     1000,
+		buffer:    100000,
+		peers:     append([]string(nil), peers...),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+	gen.OnSeen(r.seen)
+	go r.run()
+	return r, nil
+}
+
+// seen queues a value newly flagged as seen, to be sent to peers.
+func (r *Replicator) seen(x serial.Serial, e serial.SeenEntry) {
+	ent := Entry{Serial: x, Value: e.Value}
+	if !e.Expires.IsZero() {
+		ent.Expires = e.Expires.UnixNano()
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.closed {
+		return
+	}
+	if len(r.pending) >= r.buffer {
+		r.dropped.Add(1)
+		return
+	}
+	r.pending = append(r.pending, ent)
+}
+
+// SetPeers replaces the list of peers, for example when the cluster scales up
+// or down.
+func (r *Replicator) SetPeers(peers []string) {
+	r.mutex.Lock()
+	r.peers = append([]string(nil), peers...)
+	r.mutex.Unlock()
+}
+
+// Dropped returns the number of values which weren't replicated because the
+// buffer of entries waiting to be sent was full.
+func (r *Replicator) Dropped() uint64 {
+	return r.dropped.Load()
+}
+
+// Receive merges a delta sent by a peer into the generator's seen history,
+// and returns how many of its values were newly flagged as seen. Those are
+// passed on to this replica's own peers in turn. It stops at the first error,
+// such as a *serial.QuotaError.
+func (r *Replicator) Receive(d Delta) (int, error) {
+	var n int
+	for _, ent := range d.Entries {
+		var e serial.SeenEntry
+		if ent.Expires != 0 {
+			e.Expires = time.Unix(0, ent.Expires)
+		}
+		e.Value = ent.Value
+		added, err := r.gen.MergeSeen(ent.Serial, e)
+		if err != nil {
+			return n, err
+		}
+		if added {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Flush sends the values waiting to be sent to a random selection of peers
+// immediately, rather than waiting for the next interval, and returns the
+// errors from any which failed, joined. The values aren't sent again, even
+// to peers which failed.
+func (r *Replicator) Flush(ctx context.Context) error {
+	r.mutex.Lock()
+	pending := r.pending
+	r.pending = nil
+	peers := r.peers
+	r.mutex.Unlock()
+	if len(pending) == 0 || len(peers) == 0 {
+		return nil
+	}
+	if len(peers) > r.fanout {
+		chosen := make([]string, r.fanout)
+		for i, j := range rand.Perm(len(peers))[:r.fanout] {
+			chosen[i] = peers[j]
+		}
+		peers = chosen
+	}
+	var errs []error
+	for len(pending) > 0 {
+		n := min(len(pending), r.batch)
+		d := Delta{From: r.name, Entries: pending[:n]}
+		pending = pending[n:]
+		for _, peer := range peers {
+			if err := r.transport.Send(ctx, peer, d); err != nil {
+				if r.onError != nil {
+					r.onError(peer, err)
+				}
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// run flushes the values waiting to be sent at each interval until Close is
+// called.
+func (r *Replicator) run() {
+	defer close(r.done)
+	t := time.NewTicker(r.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-t.C:
+			ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+			_ = r.Flush(ctx)
+			cancel()
+		}
+	}
+}
+
+// Close stops the background replication, and makes a final attempt to send
+// the values waiting to be sent, returning any errors as for Flush. Values
+// flagged as seen afterwards aren't replicated, though Receive can still be
+// called.
+func (r *Replicator) Close() error {
+	var err error
+	r.once.Do(func() {
+		close(r.stop)
+		<-r.done
+		ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+		err = r.Flush(ctx)
+		cancel()
+		r.mutex.Lock()
+		r.closed = true
+		r.pending = nil
+		r.mutex.Unlock()
+	})
+	return err
+}
@@ -0,0 +1,226 @@
+package gossip
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lpar/serial"
+)
+
+// cluster connects replicators in memory, counting the deltas sent.
+type cluster struct {
+	mutex sync.Mutex
+	nodes map[string]*Replicator
+	sent  int
+	fail  map[string]bool
+}
+
+func (c *cluster) Send(ctx context.Context, peer string, d Delta) error {
+	c.mutex.Lock()
+	r, fail := c.nodes[peer], c.fail[peer]
+	c.sent++
+	c.mutex.Unlock()
+	if fail {
+		return errors.New("unreachable")
+	}
+	_, err := r.Receive(d)
+	return err
+}
+
+func newCluster(t *testing.T, names []string, opts ...Option) (*cluster, map[string]*serial.Generator) {
+	c := &cluster{nodes: make(map[string]*Replicator), fail: make(map[string]bool)}
+	gens := make(map[string]*serial.Generator)
+	for i, name := range names {
+		gen := serial.NewGenerator(serial.WithLayout(serial.SnowflakeLayout), serial.WithNode(int64(i)))
+		var peers []string
+		for _, p := range names {
+			if p != name {
+				peers = append(peers, p)
+			}
+		}
+		r, err := New(gen, c, peers, append([]Option{WithName(name), WithInterval(time.Hour)}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { r.Close() })
+		c.nodes[name] = r
+		gens[name] = gen
+	}
+	return c, gens
+}
+
+func TestOptions(t *testing.T) {
+	gen := serial.NewGenerator()
+	for _, c := range []struct {
+		opt Option
+		err error
+	}{
+		{WithInterval(0), ErrInterval},
+		{WithFanout(0), ErrFanout},
+		{WithBatchSize(0, 10), ErrBatchSize},
+		{WithBatchSize(10, 0), ErrBatchSize},
+	} {
+		if _, err := New(gen, &cluster{}, nil, c.opt); err != c.err {
+			t.Errorf("Expected %v got %v", c.err, err)
+		}
+	}
+}
+
+func TestReplicate(t *testing.T) {
+	c, gens := newCluster(t, []string{"a", "b", "c"})
+	a, b := gens["a"], gens["b"]
+	x, y := a.Generate(), b.Generate()
+	a.SetSeen(x)
+	b.SetSeenFor(y, time.Hour)
+	a.SetSeenWithValue(x+1, "attached")
+	if err := c.nodes["a"].Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.nodes["b"].Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for name, gen := range gens {
+		if !gen.Seen(x) || !gen.Seen(y) {
+			t.Errorf("Expected %s to have seen %d and %d", name, x, y)
+		}
+	}
+	if v, _ := gens["c"].SeenValue(x + 1); v != "attached" {
+		t.Errorf("Expected attached value to be replicated, got %v", v)
+	}
+
+	// Values merged from a peer are passed on, and merging them again is
+	// harmless, so the histories converge.
+	for _, name := range []string{"a", "b", "c"} {
+		if err := c.nodes[name].Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, gen := range gens {
+		if n := gen.SeenCount(); n != 3 {
+			t.Errorf("Expected 3 values seen by %s, got %d", name, n)
+		}
+	}
+	c.mutex.Lock()
+	sent := c.sent
+	c.mutex.Unlock()
+	if err := c.nodes["a"].Flush(context.Background()); err != nil || c.sent != sent {
+		t.Errorf("Expected nothing sent with nothing pending, got %v and %d deltas", err, c.sent-sent)
+	}
+}
+
+func TestFanout(t *testing.T) {
+	c, gens := newCluster(t, []string{"a", "b", "c", "d", "e"}, WithFanout(1), WithBatchSize(2, 10))
+	a := gens["a"]
+	for i := 0; i < 5; i++ {
+		a.SetSeen(a.Generate())
+	}
+	if err := c.nodes["a"].Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if c.sent != 3 {
+		t.Errorf("Expected 3 batches to one peer, got %d deltas", c.sent)
+	}
+	var reached int
+	for name, gen := range gens {
+		if name != "a" && gen.SeenCount() == 5 {
+			reached++
+		}
+	}
+	if reached != 1 {
+		t.Errorf("Expected one peer reached, got %d", reached)
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	c, gens := newCluster(t, []string{"a", "b"}, WithBatchSize(10, 3))
+	a := gens["a"]
+	for i := 0; i < 5; i++ {
+		a.SetSeen(a.Generate())
+	}
+	if n := c.nodes["a"].Dropped(); n != 2 {
+		t.Errorf("Expected 2 dropped, got %d", n)
+	}
+	if err := c.nodes["a"].Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := gens["b"].SeenCount(); n != 3 {
+		t.Errorf("Expected 3 values replicated, got %d", n)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var failed []string
+	c, gens := newCluster(t, []string{"a", "b", "c"}, WithErrorHandler(func(peer string, err error) {
+		failed = append(failed, peer)
+	}))
+	c.fail["c"] = true
+	a := gens["a"]
+	a.SetSeen(a.Generate())
+	if err := c.nodes["a"].Flush(context.Background()); err == nil || err.Error() != "unreachable" {
+		t.Errorf("Expected error from unreachable peer, got %v", err)
+	}
+	if len(failed) != 1 || failed[0] != "c" {
+		t.Errorf("Expected error handler called for c, got %v", failed)
+	}
+	if gens["b"].SeenCount() != 1 {
+		t.Error("Expected reachable peer to be sent the delta")
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	c, gens := newCluster(t, []string{"a", "b"})
+	a, b := gens["a"], gens["b"]
+	old := a.Generate()
+	b.ExpireSeenBefore(time.Now().Add(time.Second))
+	n, err := c.nodes["b"].Receive(Delta{Entries: []Entry{
+		{Serial: old},
+		{Serial: old + 1, Expires: time.Now().Add(-time.Second).UnixNano()},
+		{Serial: old + 2, Expires: time.Now().Add(time.Hour).UnixNano()},
+	}})
+	if err != nil || n != 1 {
+		t.Errorf("Expected only the live value merged, got %d, %v", n, err)
+	}
+	if b.Seen(old) || !b.Seen(old+2) {
+		t.Error("Expected expired values skipped")
+	}
+}
+
+func TestBackground(t *testing.T) {
+	c := &cluster{nodes: make(map[string]*Replicator)}
+	a, b := serial.NewGenerator(), serial.NewGenerator()
+	ra, err := New(a, c, []string{"b"}, WithInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rb, err := New(b, c, []string{"a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rb.Close()
+	c.nodes["a"], c.nodes["b"] = ra, rb
+	x := a.Generate()
+	a.SetSeen(x)
+	deadline := time.Now().Add(5 * time.Second)
+	for !b.Seen(x) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !b.Seen(x) {
+		t.Error("Expected value replicated in the background")
+	}
+
+	// Close sends what's pending, and then stops replicating.
+	y := a.Generate()
+	a.SetSeen(y)
+	if err := ra.Close(); err != nil {
+		t.Fatal(err)
+	}
+	z := a.Generate()
+	a.SetSeen(z)
+	time.Sleep(30 * time.Millisecond)
+	if !b.Seen(y) || b.Seen(z) {
+		t.Errorf("Expected %d replicated on close and %d not after", y, z)
+	}
+}
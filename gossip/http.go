@@ -0,0 +1,78 @@
+package gossip
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// MaxDeltaBytes is the largest request body the handler returned by Handler
+// accepts.
+const MaxDeltaBytes = 16 << 20
+
+// HTTPTransport sends each delta as JSON in the body of a POST request to the
+// peer, which is the URL at which the peer's Handler is served.
+type HTTPTransport struct {
+	// Client is the client used to send requests. If it is nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+}
+
+// Send implements Transport.
+func (t HTTPTransport) Send(ctx context.Context, peer string, d Delta) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, peer, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("gossip: peer %s returned %s", peer, resp.Status)
+	}
+	return nil
+}
+
+// Handler returns an http.Handler which accepts deltas sent by HTTPTransport
+// and passes them to Receive. It responds 204 No Content once a delta has
+// been merged.
+func (r *Replicator) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var d Delta
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, MaxDeltaBytes)).Decode(&d); err != nil {
+			var mbe *http.MaxBytesError
+			if errors.As(err, &mbe) {
+				http.Error(w, "delta too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "invalid delta", http.StatusBadRequest)
+			return
+		}
+		if _, err := r.Receive(d); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,64 @@
+package gossip
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lpar/serial"
+)
+
+func TestHTTP(t *testing.T) {
+	a, b := serial.NewGenerator(), serial.NewGenerator()
+	rb, err := New(b, HTTPTransport{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rb.Close()
+	srv := httptest.NewServer(rb.Handler())
+	defer srv.Close()
+	ra, err := New(a, HTTPTransport{Client: srv.Client()}, []string{srv.URL}, WithInterval(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ra.Close()
+	x := a.Generate()
+	a.SetSeenWithValue(x, map[string]interface{}{"user": "alice"})
+	a.SetSeenFor(x+1, time.Hour)
+	if err := ra.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := b.SeenValue(x); !ok || v.(map[string]interface{})["user"] != "alice" {
+		t.Errorf("Expected value replicated over HTTP, got %v", v)
+	}
+	if !b.Seen(x + 1) {
+		t.Error("Expected value with time to live replicated over HTTP")
+	}
+
+	for _, c := range []struct {
+		method, body string
+		status       int
+	}{
+		{"GET", "", http.StatusMethodNotAllowed},
+		{"POST", "{", http.StatusBadRequest},
+		{"POST", `{"entries":[{"serial":"x"}]}`, http.StatusBadRequest},
+		{"POST", `{"entries":[]}`, http.StatusNoContent},
+	} {
+		rec := httptest.NewRecorder()
+		rb.Handler().ServeHTTP(rec, httptest.NewRequest(c.method, "/", strings.NewReader(c.body)))
+		if rec.Code != c.status {
+			t.Errorf("Expected %d for %s %q, got %d", c.status, c.method, c.body, rec.Code)
+		}
+	}
+
+	bad := httptest.NewServer(http.NotFoundHandler())
+	defer bad.Close()
+	a.SetSeen(a.Generate())
+	ra.SetPeers([]string{bad.URL})
+	if err := ra.Flush(context.Background()); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected error from failing peer, got %v", err)
+	}
+}
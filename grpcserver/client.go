@@ -0,0 +1,103 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/lpar/serial"
+	"google.golang.org/grpc"
+)
+
+// Client issues serial values from a remote Server. Its methods have the
+// same signatures as those of serial.Generator, so code which depends on an
+// interface satisfied by a Generator can use either.
+//
+// Where a Generator method can't fail, the Client method can't return an
+// error either, so it fails closed: Seen and SeenOrSet report values as seen
+// if the server can't be reached, so that a one-time value is never accepted
+// twice. Errors from those methods, and from SetSeen, are passed to the
+// handler given when the Client was created.
+type Client struct {
+	c       SerialServiceClient
+	onError func(error)
+}
+
+// NewClient returns a Client which uses the specified connection. The
+// handler is called with any errors which can't be returned; it may be nil.
+func NewClient(conn grpc.ClientConnInterface, onError func(error)) *Client {
+	return &Client{c: NewSerialServiceClient(conn), onError: onError}
+}
+
+// fail reports an error to the handler.
+func (c *Client) fail(err error) {
+	if c.onError != nil {
+		c.onError(err)
+	}
+}
+
+// TryGenerate returns a new serial value from the server.
+func (c *Client) TryGenerate() (serial.Serial, error) {
+	resp, err := c.c.Generate(context.Background(), &GenerateRequest{})
+	if err != nil {
+		return 0, err
+	}
+	return serial.Serial(resp.Serial), nil
+}
+
+// Generate returns a new serial value from the server. It panics if the
+// server can't be reached; use TryGenerate to handle that case.
+func (c *Client) Generate() serial.Serial {
+	x, err := c.TryGenerate()
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+// GenerateN returns n new serial values from the server, in ascending order,
+// fetching them in batches of up to MaxBatch. It panics if the server can't
+// be reached.
+func (c *Client) GenerateN(n int) []serial.Serial {
+	xs := make([]serial.Serial, 0, n)
+	for len(xs) < n {
+		count := n - len(xs)
+		if count > MaxBatch {
+			count = MaxBatch
+		}
+		resp, err := c.c.GenerateBatch(context.Background(), &GenerateBatchRequest{Count: int32(count)})
+		if err != nil {
+			panic(err)
+		}
+		for _, x := range resp.Serials {
+			xs = append(xs, serial.Serial(x))
+		}
+	}
+	return xs
+}
+
+// SetSeen flags a serial value as seen on the server.
+func (c *Client) SetSeen(x serial.Serial) {
+	if _, err := c.c.MarkSeen(context.Background(), &MarkSeenRequest{Serial: int64(x)}); err != nil {
+		c.fail(err)
+	}
+}
+
+// SeenOrSet flags a serial value as seen on the server, and reports whether
+// it had already been seen.
+func (c *Client) SeenOrSet(x serial.Serial) bool {
+	resp, err := c.c.MarkSeen(context.Background(), &MarkSeenRequest{Serial: int64(x)})
+	if err != nil {
+		c.fail(err)
+		return true
+	}
+	return resp.AlreadySeen
+}
+
+// Seen reports whether a serial value has been seen on the server.
+func (c *Client) Seen(x serial.Serial) bool {
+	resp, err := c.c.CheckSeen(context.Background(), &CheckSeenRequest{Serial: int64(x)})
+	if err != nil {
+		c.fail(err)
+		return true
+	}
+	return resp.Seen
+}
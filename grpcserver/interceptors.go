@@ -0,0 +1,121 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/lpar/serial"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDKey is the metadata key which carries an RPC's serial value, the
+// gRPC equivalent of the X-Request-ID header used by serialhttp.
+const RequestIDKey = "x-request-id"
+
+// incomingID returns the serial value for an incoming RPC: the one in its
+// metadata, if serial.Parse accepts it, or else a new one from gen.
+func incomingID(ctx context.Context, gen *serial.Generator) (serial.Serial, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if vs := md.Get(RequestIDKey); len(vs) > 0 {
+		if x, err := serial.Parse(vs[0]); err == nil {
+			return x, nil
+		}
+	}
+	x, err := gen.GenerateCtx(ctx)
+	if err != nil {
+		return 0, statusError(err)
+	}
+	return x, nil
+}
+
+// UnaryServerInterceptor returns an interceptor which assigns a serial value
+// to each unary RPC, generated by gen. If the RPC's metadata already has an
+// x-request-id which serial.Parse accepts, its value is used instead, so
+// that an ID assigned by the caller is carried through. The value is stored
+// in the handler's context, where serial.FromContext can find it, and sent
+// back to the caller in the response header, in decimal. If a value can't be
+// generated, for example because gen has been closed during a graceful
+// shutdown, the RPC fails with the same status as from the Server.
+func UnaryServerInterceptor(gen *serial.Generator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		x, err := incomingID(ctx, gen)
+		if err != nil {
+			return nil, err
+		}
+		grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, x.String()))
+		return handler(serial.NewContext(ctx, x), req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor which assigns a serial value
+// to each streaming RPC, as for UnaryServerInterceptor.
+func StreamServerInterceptor(gen *serial.Generator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		x, err := incomingID(ss.Context(), gen)
+		if err != nil {
+			return err
+		}
+		ss.SetHeader(metadata.Pairs(RequestIDKey, x.String()))
+		return handler(srv, &idStream{ServerStream: ss, ctx: serial.NewContext(ss.Context(), x)})
+	}
+}
+
+// idStream is a grpc.ServerStream whose context carries a serial value.
+type idStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the stream's context.
+func (s *idStream) Context() context.Context {
+	return s.ctx
+}
+
+// outgoingContext returns ctx with a serial value attached to its outgoing
+// metadata: the one ctx carries, as set by a server interceptor or the
+// serialhttp middleware, or else a new one from gen. If the outgoing
+// metadata already has one, ctx is returned unchanged.
+func outgoingContext(ctx context.Context, gen *serial.Generator) (context.Context, error) {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(RequestIDKey)) > 0 {
+		return ctx, nil
+	}
+	x, ok := serial.FromContext(ctx)
+	if !ok {
+		var err error
+		if x, err = gen.GenerateCtx(ctx); err != nil {
+			return nil, statusError(err)
+		}
+		ctx = serial.NewContext(ctx, x)
+	}
+	return metadata.AppendToOutgoingContext(ctx, RequestIDKey, x.String()), nil
+}
+
+// UnaryClientInterceptor returns an interceptor which attaches a serial value
+// to the metadata of each outgoing unary RPC, so that calls can be
+// correlated across services. The value is the one carried by the call's
+// context, as stored by serial.NewContext or a server interceptor, so an ID
+// is propagated from one service to the next; if the context has none, a
+// new one is generated by gen. If that fails, for example because gen has
+// been closed, the call fails without being made.
+func UnaryClientInterceptor(gen *serial.Generator) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := outgoingContext(ctx, gen)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor returns an interceptor which attaches a serial
+// value to the metadata of each outgoing streaming RPC, as for
+// UnaryClientInterceptor.
+func StreamClientInterceptor(gen *serial.Generator) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := outgoingContext(ctx, gen)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
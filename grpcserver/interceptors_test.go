@@ -0,0 +1,151 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/lpar/serial"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// idServer records the serial value in the context of each CheckSeen call.
+type idServer struct {
+	*Server
+	got serial.Serial
+}
+
+func (s *idServer) CheckSeen(ctx context.Context, req *CheckSeenRequest) (*CheckSeenResponse, error) {
+	s.got, _ = serial.FromContext(ctx)
+	return s.Server.CheckSeen(ctx, req)
+}
+
+func TestInterceptors(t *testing.T) {
+	srvGen := serial.NewGenerator()
+	cliGen := serial.NewGenerator()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(UnaryServerInterceptor(srvGen)))
+	ids := &idServer{Server: NewServer(srvGen)}
+	RegisterSerialServiceServer(srv, ids)
+	go srv.Serve(lis)
+	defer srv.Stop()
+	dial := func(opts ...grpc.DialOption) SerialServiceClient {
+		opts = append(opts,
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return lis.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return NewSerialServiceClient(conn)
+	}
+	c := dial(grpc.WithUnaryInterceptor(UnaryClientInterceptor(cliGen)))
+	call := func(c SerialServiceClient, ctx context.Context) serial.Serial {
+		var md metadata.MD
+		if _, err := c.CheckSeen(ctx, &CheckSeenRequest{}, grpc.Header(&md)); err != nil {
+			t.Fatal(err)
+		}
+		if vs := md.Get(RequestIDKey); len(vs) != 1 || vs[0] != ids.got.String() {
+			t.Errorf("Expected response header %d got %v", ids.got, vs)
+		}
+		return ids.got
+	}
+
+	// The ID in the caller's context is propagated.
+	if got := call(c, serial.NewContext(context.Background(), 42)); got != 42 {
+		t.Errorf("Expected 42 got %d", got)
+	}
+	// Without one, the client mints one.
+	before := cliGen.Generate()
+	if got := call(c, context.Background()); got <= before || got >= cliGen.Generate() {
+		t.Errorf("Expected ID from the client's generator, got %d", got)
+	}
+	// Without a client interceptor, the server mints one.
+	before = srvGen.Generate()
+	if got := call(dial(), context.Background()); got <= before {
+		t.Errorf("Expected ID from the server's generator, got %d", got)
+	}
+	// Metadata set by the caller is left alone.
+	ctx := metadata.AppendToOutgoingContext(context.Background(), RequestIDKey, "0x499602d2")
+	if got := call(c, ctx); got != 1234567890 {
+		t.Errorf("Expected 1234567890 got %d", got)
+	}
+}
+
+// fakeServerStream is a grpc.ServerStream with just a context and headers.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *fakeServerStream) Context() context.Context { return s.ctx }
+
+func (s *fakeServerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDKey, "12345"))
+	ss := &fakeServerStream{ctx: ctx}
+	var got serial.Serial
+	err := StreamServerInterceptor(serial.NewGenerator())(nil, ss, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+		got, _ = serial.FromContext(stream.Context())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 12345 || ss.header.Get(RequestIDKey)[0] != "12345" {
+		t.Errorf("Expected 12345 in context and header, got %d and %v", got, ss.header)
+	}
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	var md metadata.MD
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil, nil
+	}
+	ctx := serial.NewContext(context.Background(), 99)
+	StreamClientInterceptor(serial.NewGenerator())(ctx, &grpc.StreamDesc{}, nil, "/m", streamer)
+	if vs := md.Get(RequestIDKey); len(vs) != 1 || vs[0] != "99" {
+		t.Errorf("Expected outgoing ID 99 got %v", vs)
+	}
+}
+
+func TestInterceptorsClosed(t *testing.T) {
+	gen := serial.NewGenerator()
+	gen.Close()
+	called := false
+	_, err := UnaryServerInterceptor(gen)(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+	if called || status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable without calling the handler, got %v and %v", err, called)
+	}
+	err = StreamServerInterceptor(gen)(nil, &fakeServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{}, func(interface{}, grpc.ServerStream) error {
+		called = true
+		return nil
+	})
+	if called || status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable from stream without calling the handler, got %v and %v", err, called)
+	}
+	err = UnaryClientInterceptor(gen)(context.Background(), "/m", nil, nil, nil, func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+		called = true
+		return nil
+	})
+	if called || status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable without calling the server, got %v and %v", err, called)
+	}
+}
@@ -0,0 +1,230 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: serial.proto
+
+package grpcserver
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	SerialService_Generate_FullMethodName      = "/lpar.serial.v1.SerialService/Generate"
+	SerialService_GenerateBatch_FullMethodName = "/lpar.serial.v1.SerialService/GenerateBatch"
+	SerialService_MarkSeen_FullMethodName      = "/lpar.serial.v1.SerialService/MarkSeen"
+	SerialService_CheckSeen_FullMethodName     = "/lpar.serial.v1.SerialService/CheckSeen"
+)
+
+// SerialServiceClient is the client API for SerialService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SerialServiceClient interface {
+	// Generate returns a new serial value.
+	Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (*GenerateResponse, error)
+	// GenerateBatch returns a batch of new serial values, in ascending order.
+	GenerateBatch(ctx context.Context, in *GenerateBatchRequest, opts ...grpc.CallOption) (*GenerateBatchResponse, error)
+	// MarkSeen flags a serial value as seen, and reports whether it had
+	// already been seen. The check and the update are atomic.
+	MarkSeen(ctx context.Context, in *MarkSeenRequest, opts ...grpc.CallOption) (*MarkSeenResponse, error)
+	// CheckSeen reports whether a serial value has been seen.
+	CheckSeen(ctx context.Context, in *CheckSeenRequest, opts ...grpc.CallOption) (*CheckSeenResponse, error)
+}
+
+type serialServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSerialServiceClient(cc grpc.ClientConnInterface) SerialServiceClient {
+	return &serialServiceClient{cc}
+}
+
+func (c *serialServiceClient) Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (*GenerateResponse, error) {
+	out := new(GenerateResponse)
+	err := c.cc.Invoke(ctx, SerialService_Generate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serialServiceClient) GenerateBatch(ctx context.Context, in *GenerateBatchRequest, opts ...grpc.CallOption) (*GenerateBatchResponse, error) {
+	out := new(GenerateBatchResponse)
+	err := c.cc.Invoke(ctx, SerialService_GenerateBatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serialServiceClient) MarkSeen(ctx context.Context, in *MarkSeenRequest, opts ...grpc.CallOption) (*MarkSeenResponse, error) {
+	out := new(MarkSeenResponse)
+	err := c.cc.Invoke(ctx, SerialService_MarkSeen_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serialServiceClient) CheckSeen(ctx context.Context, in *CheckSeenRequest, opts ...grpc.CallOption) (*CheckSeenResponse, error) {
+	out := new(CheckSeenResponse)
+	err := c.cc.Invoke(ctx, SerialService_CheckSeen_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SerialServiceServer is the server API for SerialService service.
+// All implementations must embed UnimplementedSerialServiceServer
+// for forward compatibility
+type SerialServiceServer interface {
+	// Generate returns a new serial value.
+	Generate(context.Context, *GenerateRequest) (*GenerateResponse, error)
+	// GenerateBatch returns a batch of new serial values, in ascending order.
+	GenerateBatch(context.Context, *GenerateBatchRequest) (*GenerateBatchResponse, error)
+	// MarkSeen flags a serial value as seen, and reports whether it had
+	// already been seen. The check and the update are atomic.
+	MarkSeen(context.Context, *MarkSeenRequest) (*MarkSeenResponse, error)
+	// CheckSeen reports whether a serial value has been seen.
+	CheckSeen(context.Context, *CheckSeenRequest) (*CheckSeenResponse, error)
+	mustEmbedUnimplementedSerialServiceServer()
+}
+
+// UnimplementedSerialServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedSerialServiceServer struct {
+}
+
+func (UnimplementedSerialServiceServer) Generate(context.Context, *GenerateRequest) (*GenerateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Generate not implemented")
+}
+func (UnimplementedSerialServiceServer) GenerateBatch(context.Context, *GenerateBatchRequest) (*GenerateBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GenerateBatch not implemented")
+}
+func (UnimplementedSerialServiceServer) MarkSeen(context.Context, *MarkSeenRequest) (*MarkSeenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MarkSeen not implemented")
+}
+func (UnimplementedSerialServiceServer) CheckSeen(context.Context, *CheckSeenRequest) (*CheckSeenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckSeen not implemented")
+}
+func (UnimplementedSerialServiceServer) mustEmbedUnimplementedSerialServiceServer() {}
+
+// UnsafeSerialServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SerialServiceServer will
+// result in compilation errors.
+type UnsafeSerialServiceServer interface {
+	mustEmbedUnimplementedSerialServiceServer()
+}
+
+func RegisterSerialServiceServer(s grpc.ServiceRegistrar, srv SerialServiceServer) {
+	s.RegisterService(&SerialService_ServiceDesc, srv)
+}
+
+func _SerialService_Generate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SerialServiceServer).Generate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SerialService_Generate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SerialServiceServer).Generate(ctx, req.(*GenerateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SerialService_GenerateBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SerialServiceServer).GenerateBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SerialService_GenerateBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SerialServiceServer).GenerateBatch(ctx, req.(*GenerateBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SerialService_MarkSeen_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkSeenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SerialServiceServer).MarkSeen(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SerialService_MarkSeen_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SerialServiceServer).MarkSeen(ctx, req.(*MarkSeenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SerialService_CheckSeen_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckSeenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SerialServiceServer).CheckSeen(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SerialService_CheckSeen_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SerialServiceServer).CheckSeen(ctx, req.(*CheckSeenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SerialService_ServiceDesc is the grpc.ServiceDesc for SerialService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SerialService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "lpar.serial.v1.SerialService",
+	HandlerType: (*SerialServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Generate",
+			Handler:    _SerialService_Generate_Handler,
+		},
+		{
+			MethodName: "GenerateBatch",
+			Handler:    _SerialService_GenerateBatch_Handler,
+		},
+		{
+			MethodName: "MarkSeen",
+			Handler:    _SerialService_MarkSeen_Handler,
+		},
+		{
+			MethodName: "CheckSeen",
+			Handler:    _SerialService_CheckSeen_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "serial.proto",
+}
@@ -0,0 +1,81 @@
+// Package grpcserver provides a gRPC service which issues serial values from
+// a single authoritative serial.Generator, and a client for it, so that
+// several services can share one issuer. The service is defined in
+// serial.proto. It also provides interceptors which give every RPC a serial
+// value as a correlation ID, like the serialhttp middleware does for HTTP
+// requests.
+package grpcserver
+
+//go:generate buf generate
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lpar/serial"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MaxBatch is the largest number of values GenerateBatch will return.
+const MaxBatch = 10000
+
+// Server implements SerialServiceServer using a serial.Generator.
+type Server struct {
+	UnimplementedSerialServiceServer
+	gen *serial.Generator
+}
+
+// NewServer returns a Server which issues values from gen.
+func NewServer(gen *serial.Generator) *Server {
+	return &Server{gen: gen}
+}
+
+// Generate implements SerialServiceServer.
+func (s *Server) Generate(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
+	x, err := s.gen.GenerateCtx(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &GenerateResponse{Serial: int64(x)}, nil
+}
+
+// GenerateBatch implements SerialServiceServer.
+func (s *Server) GenerateBatch(ctx context.Context, req *GenerateBatchRequest) (*GenerateBatchResponse, error) {
+	if req.Count < 1 || req.Count > MaxBatch {
+		return nil, status.Errorf(codes.InvalidArgument, "count must be from 1 to %d", MaxBatch)
+	}
+	// The batch is claimed in one go, so it is issued whole or not at all.
+	xs := make([]serial.Serial, req.Count)
+	if err := s.gen.TryFill(xs); err != nil {
+		return nil, statusError(err)
+	}
+	ys := make([]int64, len(xs))
+	for i, x := range xs {
+		ys[i] = int64(x)
+	}
+	return &GenerateBatchResponse{Serials: ys}, nil
+}
+
+// MarkSeen implements SerialServiceServer.
+func (s *Server) MarkSeen(ctx context.Context, req *MarkSeenRequest) (*MarkSeenResponse, error) {
+	seen, err := s.gen.TrySeenOrSet(serial.Serial(req.Serial))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &MarkSeenResponse{AlreadySeen: seen}, nil
+}
+
+// CheckSeen implements SerialServiceServer.
+func (s *Server) CheckSeen(ctx context.Context, req *CheckSeenRequest) (*CheckSeenResponse, error) {
+	return &CheckSeenResponse{Seen: s.gen.Seen(serial.Serial(req.Serial))}, nil
+}
+
+// statusError converts an error from the generator to a gRPC status. A
+// request which exceeds a quota or rate limit gets ResourceExhausted.
+func statusError(err error) error {
+	if errors.Is(err, serial.ErrQuotaExceeded) || errors.Is(err, serial.ErrRateLimited) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
@@ -0,0 +1,121 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/lpar/serial"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// issuer is satisfied by both serial.Generator and Client.
+type issuer interface {
+	Generate() serial.Serial
+	TryGenerate() (serial.Serial, error)
+	GenerateN(n int) []serial.Serial
+	SetSeen(x serial.Serial)
+	SeenOrSet(x serial.Serial) bool
+	Seen(x serial.Serial) bool
+}
+
+var (
+	_ issuer = (*serial.Generator)(nil)
+	_ issuer = (*Client)(nil)
+)
+
+func dial(t *testing.T, gen *serial.Generator) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	RegisterSerialServiceServer(srv, NewServer(gen))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestClient(t *testing.T) {
+	gen := serial.NewGenerator()
+	c := NewClient(dial(t, gen), func(err error) { t.Errorf("Unexpected error: %v", err) })
+	x := c.Generate()
+	xs := c.GenerateN(MaxBatch + 5)
+	if len(xs) != MaxBatch+5 {
+		t.Errorf("Expected %d values got %d", MaxBatch+5, len(xs))
+	}
+	for i, y := range xs {
+		if y <= x {
+			t.Errorf("Value %d out of order, %d after %d", i, y, x)
+			break
+		}
+		x = y
+	}
+	if c.Seen(x) {
+		t.Errorf("Expected %d not seen", x)
+	}
+	c.SetSeen(x)
+	if !c.Seen(x) || !gen.Seen(x) {
+		t.Errorf("Expected %d seen", x)
+	}
+	y := c.Generate()
+	if c.SeenOrSet(y) || !c.SeenOrSet(y) {
+		t.Errorf("Expected SeenOrSet(%d) to succeed exactly once", y)
+	}
+}
+
+func TestGenerateBatchInvalid(t *testing.T) {
+	client := NewSerialServiceClient(dial(t, serial.NewGenerator()))
+	_, err := client.GenerateBatch(context.Background(), &GenerateBatchRequest{Count: MaxBatch + 1})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument got %v", err)
+	}
+}
+
+func TestClientFailClosed(t *testing.T) {
+	conn := dial(t, serial.NewGenerator())
+	errs := 0
+	c := NewClient(conn, func(error) { errs++ })
+	conn.Close()
+	if !c.Seen(1) || !c.SeenOrSet(1) {
+		t.Error("Expected values reported as seen when the server can't be reached")
+	}
+	if _, err := c.TryGenerate(); err == nil {
+		t.Error("Expected error generating when the server can't be reached")
+	}
+	if errs != 2 {
+		t.Errorf("Expected 2 errors reported got %d", errs)
+	}
+}
+
+func TestGenerateBatchWhole(t *testing.T) {
+	gen := serial.NewGenerator(serial.WithIssueQuota(5, time.Hour))
+	client := NewSerialServiceClient(dial(t, gen))
+	_, err := client.GenerateBatch(context.Background(), &GenerateBatchRequest{Count: 6})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted got %v", err)
+	}
+	if n := gen.Stats().Generated; n != 0 {
+		t.Errorf("Expected a failed batch to issue nothing, got %d", n)
+	}
+	resp, err := client.GenerateBatch(context.Background(), &GenerateBatchRequest{Count: 5})
+	if err != nil || len(resp.Serials) != 5 {
+		t.Fatalf("Expected a batch of 5, got %v", err)
+	}
+	for i := 1; i < len(resp.Serials); i++ {
+		if resp.Serials[i] <= resp.Serials[i-1] {
+			t.Errorf("Expected ascending batch got %v", resp.Serials)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package serial
+
+import "time"
+
+// HLCLayout packs a millisecond timestamp and a 16 bit logical counter, as is
+// usual for a hybrid logical clock. 47 bits of milliseconds last until the
+// year 6429.
+var HLCLayout = Layout{TimeBits: 47, SequenceBits: 16, Resolution: time.Millisecond}
+
+// Observe merges a serial value received from another generator into this
+// one's clock, so that every value this generator issues afterwards is
+// greater than x. Values then preserve causality across a cluster, as with a
+// hybrid logical clock: if a node generates a value after observing one
+// generated elsewhere, its value is greater, however far apart the nodes'
+// clocks are. The generators must share a layout and epoch; HLCLayout is a
+// suitable one, but any layout works, with or without node IDs.
+//
+// When x is ahead of the clock, the following values are pushed ahead with it
+// until the clock catches up, as for values generated faster than the clock
+// ticks. WithMaxSkew can be used to refuse to follow a node whose clock is
+// too far ahead.
+func (g *Generator) Observe(x Serial) {
+	ticks, node, seq := g.layout.unpack(x)
+	if node > g.node {
+		// The next value must be in a later tick to be greater than x,
+		// since its node ID is less.
+		seq = g.layout.maxSeq()
+	}
+	g.raise(g.layout.pack(ticks, g.node, seq))
+}
@@ -0,0 +1,37 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestObserve(t *testing.T) {
+	clk1 := &fakeClock{now: time.Unix(1000, 0)}
+	clk2 := &fakeClock{now: time.Unix(900, 0)}
+	g1 := NewGenerator(WithClock(clk1), WithLayout(HLCLayout))
+	g2 := NewGenerator(WithClock(clk2), WithLayout(HLCLayout))
+	x := g1.Generate()
+	g2.Observe(x)
+	y := g2.Generate()
+	if y <= x {
+		t.Errorf("Expected %d after observing %d", y, x)
+	}
+	// Observing an older value afterwards changes nothing.
+	g1.Observe(y)
+	g1.Observe(y - 100)
+	if z := g1.Generate(); z <= y {
+		t.Errorf("Expected %d after %d", z, y)
+	}
+	for _, nodes := range [][2]int64{{1, 2}, {2, 1}, {1, 1}} {
+		l := Layout{TimeBits: 41, NodeBits: 10, SequenceBits: 12, Resolution: time.Millisecond}
+		a := NewGenerator(WithClock(clk1), WithLayout(l), WithNode(nodes[0]))
+		b := NewGenerator(WithClock(clk2), WithLayout(l), WithNode(nodes[1]))
+		for i := 0; i < 3; i++ {
+			x := a.Generate()
+			b.Observe(x)
+			if y := b.Generate(); y <= x {
+				t.Errorf("Nodes %v: expected %d after observing %d", nodes, y, x)
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package serial
+
+// OnGenerate registers a function to be called with each value issued by
+// Generate, TryGenerate, GenerateCtx, GenerateN or Fill, so that concerns
+// such as logging, metrics or persistence can observe every value without
+// wrapping the generator. Functions are called in the order they were
+// registered, by the goroutine which generated the value, after the
+// generator's locks have been released and before the value is returned.
+// They should be quick, since they delay the caller. Blocks claimed with
+// ReserveBlock aren't reported value by value.
+func (g *Generator) OnGenerate(f func(Serial)) {
+	g.hookMutex.Lock()
+	hooks, _ := g.onGenerate.Load().([]func(Serial))
+	g.onGenerate.Store(append(hooks[:len(hooks):len(hooks)], f))
+	g.hookMutex.Unlock()
+}
+
+// generated reports newly issued values to the OnGenerate hooks and the
+// Events channel.
+func (g *Generator) generated(xs []Serial) {
+	hooks, _ := g.onGenerate.Load().([]func(Serial))
+	for _, x := range xs {
+		for _, f := range hooks {
+			f(x)
+		}
+		g.emit(EventGenerate, x, 0)
+	}
+}
+
+// OnSeen registers a function to be called with each value newly flagged as
+// seen, by any means, along with its entry, so that the application can
+// follow the seen history, for example to replicate it. Values already
+// flagged as seen aren't reported again. Functions are called in the order
+// they were registered, by the goroutine which flagged the value, after the
+// history's locks have been released. They should be quick, since they delay
+// the caller.
+func (g *Generator) OnSeen(f func(Serial, SeenEntry)) {
+	g.hookMutex.Lock()
+	hooks, _ := g.onSeen.Load().([]func(Serial, SeenEntry))
+	g.onSeen.Store(append(hooks[:len(hooks):len(hooks)], f))
+	g.hookMutex.Unlock()
+}
+
+// flagged reports a value newly flagged as seen to the OnSeen hooks and the
+// Events channel.
+func (g *Generator) flagged(x Serial, e seenEntry) {
+	hooks, _ := g.onSeen.Load().([]func(Serial, SeenEntry))
+	if len(hooks) > 0 {
+		se := exportEntry(e)
+		for _, f := range hooks {
+			f(x, se)
+		}
+	}
+	g.emit(EventSeen, x, 0)
+}
+
+// OnExpire registers a function to be called with each value removed from
+// the seen history by expiry, whether by ExpireSeen, ExpireSeenBefore or
+// WithAutoExpire, so that the application can clean up anything associated
+// with it, such as a session. Functions are called in the order they were
+// registered, after the history's locks have been released, by the goroutine
+// which expired the history.
+//
+// Values can't be recovered from a history kept in Bloom filters or in a
+// SeenStore other than MemoryStore, so for those, the functions are never
+// called.
+func (g *Generator) OnExpire(f func(Serial)) {
+	g.hookMutex.Lock()
+	g.onExpire = append(g.onExpire[:len(g.onExpire):len(g.onExpire)], f)
+	g.hookMutex.Unlock()
+}
+
+// expireHooks returns the functions registered with OnExpire.
+func (g *Generator) expireHooks() []func(Serial) {
+	g.hookMutex.Lock()
+	hooks := g.onExpire
+	g.hookMutex.Unlock()
+	return hooks
+}
@@ -0,0 +1,81 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOnExpire(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithMaxSeen(10)}} {
+		clk := &fakeClock{now: time.Unix(1000, 0)}
+		g := NewGenerator(append([]Option{WithClock(clk)}, opts...)...)
+		x, y := g.Generate(), g.Generate()
+		g.SetSeen(x)
+		g.SetSeen(y)
+		clk.Advance(time.Hour)
+		z := g.Generate()
+		g.SetSeen(z)
+		var got []Serial
+		calls := 0
+		g.OnExpire(func(x Serial) {
+			got = append(got, x)
+			// Hooks may use the generator.
+			g.SetSeen(x + 1000)
+		})
+		g.OnExpire(func(Serial) { calls++ })
+		g.ExpireSeen(time.Minute)
+		Serials(got).Sort()
+		if len(got) != 2 || got[0] != x || got[1] != y || calls != 2 {
+			t.Errorf("Expected hooks called for %d and %d, got %v and %d calls", x, y, got, calls)
+		}
+		if !g.Seen(z) {
+			t.Error("Unexpired value removed")
+		}
+	}
+}
+
+func TestOnGenerate(t *testing.T) {
+	g := NewGenerator()
+	var got []Serial
+	g.OnGenerate(func(x Serial) { got = append(got, x) })
+	calls := 0
+	g.OnGenerate(func(Serial) { calls++ })
+	x := g.Generate()
+	xs := g.GenerateN(3)
+	g.ReserveBlock(10)
+	want := append([]Serial{x}, xs...)
+	if len(got) != len(want) || calls != len(want) {
+		t.Fatalf("Expected %v got %v and %d calls", want, got, calls)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %d got %d", want[i], got[i])
+		}
+	}
+}
+
+func TestOnSeen(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk))
+	type call struct {
+		x Serial
+		e SeenEntry
+	}
+	var got []call
+	g.OnSeen(func(x Serial, e SeenEntry) { got = append(got, call{x, e}) })
+	x, y, z := g.Generate(), g.Generate(), g.Generate()
+	g.SetSeen(x)
+	g.SetSeen(x)
+	g.SetSeenWithValue(y, "order 1")
+	g.SetSeenFor(z, time.Minute)
+	if g.SeenOrSet(z) != true {
+		t.Error("Expected z to be seen already")
+	}
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 calls, got %v", got)
+	}
+	if got[0].x != x || got[1].x != y || got[1].e.Value != "order 1" || got[2].x != z ||
+		!got[2].e.Expires.Equal(clk.now.Add(time.Minute)) {
+		t.Errorf("Wrong calls %v", got)
+	}
+}
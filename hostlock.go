@@ -0,0 +1,87 @@
+package serial
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrHostFile is returned by HostAllocator.Lease when its shared file holds
+// something other than a high-water mark.
+var ErrHostFile = errors.New("serial: invalid host allocator file")
+
+// HostAllocator is an Allocator which coordinates generators in different
+// processes on the same machine, such as forked workers, through a shared
+// file. Each lease takes an exclusive lock on the file, reads the highest
+// value handed out so far, and writes back the end of the new block, so no
+// two processes using the same file can be given the same value, even if
+// they start in the same nanosecond.
+//
+// Blocks start at the current Unix time in nanoseconds, or just after the
+// last block handed out if that is later, so values are like those from a
+// generator with the default layout. Each process should open the file
+// itself, and use the allocator with WithAllocator; a block size of 1 keeps
+// values in order across processes, at the cost of locking the file for
+// every value. File locking is only available on Unix systems.
+type HostAllocator struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// OpenHostAllocator opens the shared file with the specified path, creating
+// it if necessary. On systems without file locking, it returns an error
+// which matches errors.ErrUnsupported.
+func OpenHostAllocator(path string) (*HostAllocator, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := unlockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &HostAllocator{file: f}, nil
+}
+
+// Lease implements Allocator.
+func (a *HostAllocator) Lease(size int) (Range, error) {
+	if size < 1 {
+		return Range{}, ErrLease
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if err := lockFile(a.file); err != nil {
+		return Range{}, err
+	}
+	defer unlockFile(a.file)
+	var b [8]byte
+	n, err := a.file.ReadAt(b[:], 0)
+	if err != nil && err != io.EOF {
+		return Range{}, err
+	}
+	if n != 0 && n != len(b) {
+		return Range{}, ErrHostFile
+	}
+	first := Serial(time.Now().UnixNano())
+	if hwm := Serial(binary.BigEndian.Uint64(b[:])); first <= hwm {
+		first = hwm + 1
+	}
+	r := Range{First: first, Last: first + Serial(size-1)}
+	binary.BigEndian.PutUint64(b[:], uint64(r.Last))
+	if _, err := a.file.WriteAt(b[:], 0); err != nil {
+		return Range{}, err
+	}
+	return r, nil
+}
+
+// Close closes the shared file.
+func (a *HostAllocator) Close() error {
+	return a.file.Close()
+}
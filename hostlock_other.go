@@ -0,0 +1,18 @@
+//go:build !unix
+
+package serial
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile reports that file locking isn't supported.
+func lockFile(f *os.File) error {
+	return errors.ErrUnsupported
+}
+
+// unlockFile reports that file locking isn't supported.
+func unlockFile(f *os.File) error {
+	return errors.ErrUnsupported
+}
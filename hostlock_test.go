@@ -0,0 +1,57 @@
+//go:build unix
+
+package serial
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestHostAllocator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serial.lock")
+	// Separate opens of the file stand in for separate processes.
+	a1, err := OpenHostAllocator(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a1.Close()
+	a2, err := OpenHostAllocator(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a2.Close()
+	g1 := NewGenerator(WithAllocator(a1, 1))
+	g2 := NewGenerator(WithAllocator(a2, 10))
+	var (
+		mutex sync.Mutex
+		seen  = make(map[Serial]bool)
+		wg    sync.WaitGroup
+	)
+	for _, g := range []*Generator{g1, g2, g1, g2} {
+		wg.Add(1)
+		go func(g *Generator) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				x := g.Generate()
+				mutex.Lock()
+				if seen[x] {
+					t.Errorf("Got the same value twice: %d", x)
+				}
+				seen[x] = true
+				mutex.Unlock()
+			}
+		}(g)
+	}
+	wg.Wait()
+	if _, err := a1.Lease(0); err != ErrLease {
+		t.Errorf("Expected ErrLease got %v", err)
+	}
+	if err := os.WriteFile(path, []byte("bad"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a1.Lease(1); err != ErrHostFile {
+		t.Errorf("Expected ErrHostFile got %v", err)
+	}
+}
@@ -0,0 +1,275 @@
+package serial
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrJournal is returned by ReplayJournal when a journal file contains a
+// record it can't understand.
+var ErrJournal = errors.New("serial: invalid journal record")
+
+// Journal is an append-only log of the serial values issued by a generator,
+// for applications which need a durable record of every value. Each record
+// holds a value, or a range of values issued together, along with the time
+// at which they were issued, as a line of text:
+//
+//	1700000000000000000 2023-11-14T22:13:20Z
+//	1700000000000000001..1700000000000000100 2023-11-14T22:13:20Z
+//
+// Once the current journal file reaches its maximum size, it is renamed with
+// a numeric suffix, such as serials.log.000001, and a new file is started.
+//
+// A Journal is attached to a generator with WithJournal, and should be
+// closed once the generator is no longer in use.
+type Journal struct {
+	mutex   sync.Mutex
+	path    string
+	file    *os.File
+	size    int64
+	maxSize int64
+	sync    bool
+	rotated int
+}
+
+// OpenJournal opens the journal file with the specified path for appending,
+// creating it if necessary. If maxSize is positive, the file is rotated
+// before it would grow beyond maxSize bytes. If sync is true, each record is
+// flushed to stable storage before the values it holds are returned to the
+// caller, which is slow but survives a power failure; otherwise records
+// survive the process crashing, but may be lost if the machine does.
+func OpenJournal(path string, maxSize int64, sync bool) (*Journal, error) {
+	rotated, err := journalFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	j := &Journal{path: path, maxSize: maxSize, sync: sync}
+	if len(rotated) > 0 {
+		j.rotated, _ = strconv.Atoi(strings.TrimPrefix(rotated[len(rotated)-1], path+"."))
+	}
+	if err := j.open(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// WithJournal records every value issued by the generator in the specified
+// journal, in the order the values are issued. To guarantee the order,
+// values are issued one call at a time, so concurrent callers contend for the
+// journal rather than using the faster lock-free path.
+//
+// If a record can't be written, the values it holds are discarded;
+// TryGenerate returns the error, and Generate, Fill and ReserveBlock panic
+// with it. Values from Generate128 aren't journalled.
+func WithJournal(j *Journal) Option {
+	return func(g *Generator) error {
+		g.journal = j
+		return nil
+	}
+}
+
+// open opens the current journal file.
+func (j *Journal) open() error {
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	j.file = f
+	j.size = fi.Size()
+	return nil
+}
+
+// write appends a record of the values in r, issued at time t. It needs to
+// be called with the journal's mutex held.
+func (j *Journal) write(r Range, t time.Time) error {
+	if j.file == nil {
+		return os.ErrClosed
+	}
+	rec := r.First.String()
+	if r.Last != r.First {
+		rec += ".." + r.Last.String()
+	}
+	rec += " " + t.UTC().Format(time.RFC3339Nano) + "\n"
+	if j.maxSize > 0 && j.size > 0 && j.size+int64(len(rec)) > j.maxSize {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := j.file.WriteString(rec)
+	j.size += int64(n)
+	if err == nil && j.sync {
+		err = j.file.Sync()
+	}
+	return err
+}
+
+// rotate renames the current journal file and starts a new one.
+func (j *Journal) rotate() error {
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+	j.file = nil
+	j.rotated++
+	if err := os.Rename(j.path, fmt.Sprintf("%s.%06d", j.path, j.rotated)); err != nil {
+		return err
+	}
+	return j.open()
+}
+
+// Sync flushes the journal to stable storage.
+func (j *Journal) Sync() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.file == nil {
+		return os.ErrClosed
+	}
+	return j.file.Sync()
+}
+
+// Close flushes the journal to stable storage and closes it. Generators
+// using the journal can no longer issue values.
+func (j *Journal) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Sync()
+	if cerr := j.file.Close(); err == nil {
+		err = cerr
+	}
+	j.file = nil
+	return err
+}
+
+// ReplayJournal reads the journal with the specified path, including any
+// rotated files, and calls f for each record in the order they were written.
+// A single value is passed as a Range whose First and Last are the same. If
+// f returns an error, replay stops and the error is returned.
+func ReplayJournal(path string, f func(r Range, t time.Time) error) error {
+	files, err := journalFiles(path)
+	if err != nil {
+		return err
+	}
+	files = append(files, path)
+	for _, name := range files {
+		if err := replayFile(name, f); err != nil && !(name == path && os.IsNotExist(err)) {
+			return err
+		}
+	}
+	return nil
+}
+
+// replayFile replays the records in a single journal file.
+func replayFile(name string, f func(r Range, t time.Time) error) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	sc := bufio.NewScanner(file)
+	for sc.Scan() {
+		r, t, err := parseRecord(sc.Text())
+		if err != nil {
+			return fmt.Errorf("serial: %s: %w", name, err)
+		}
+		if err := f(r, t); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// parseRecord parses a line of a journal file.
+func parseRecord(line string) (Range, time.Time, error) {
+	var r Range
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return r, time.Time{}, ErrJournal
+	}
+	first, last := fields[0], fields[0]
+	if i := strings.Index(first, ".."); i >= 0 {
+		first, last = first[:i], first[i+2:]
+	}
+	a, err1 := strconv.ParseInt(first, 10, 64)
+	b, err2 := strconv.ParseInt(last, 10, 64)
+	t, err3 := time.Parse(time.RFC3339Nano, fields[1])
+	if err1 != nil || err2 != nil || err3 != nil || b < a {
+		return r, time.Time{}, ErrJournal
+	}
+	return Range{First: Serial(a), Last: Serial(b)}, t, nil
+}
+
+// journalFiles returns the names of the rotated files for the journal with
+// the specified path, oldest first.
+func journalFiles(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".[0-9][0-9][0-9][0-9][0-9][0-9]*")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, m := range matches {
+		if _, err := strconv.Atoi(strings.TrimPrefix(m, path+".")); err == nil {
+			files = append(files, m)
+		}
+	}
+	sort.Slice(files, func(i, k int) bool {
+		a, _ := strconv.Atoi(strings.TrimPrefix(files[i], path+"."))
+		b, _ := strconv.Atoi(strings.TrimPrefix(files[k], path+"."))
+		return a < b
+	})
+	return files, nil
+}
+
+// lockJournal locks the generator's journal, if it has one, so that the
+// values issued before it is unlocked are journalled in order.
+func (g *Generator) lockJournal() {
+	if g.journal != nil {
+		g.journal.mutex.Lock()
+	}
+}
+
+// unlockJournal records the values issued at time now in the generator's
+// journal, if it has one, and unlocks it.
+func (g *Generator) unlockJournal(now time.Time, rs ...Range) error {
+	if g.journal == nil {
+		return nil
+	}
+	var err error
+	for _, r := range rs {
+		if err = g.journal.write(r, now); err != nil {
+			break
+		}
+	}
+	g.journal.mutex.Unlock()
+	return err
+}
+
+// runs returns the runs of consecutive values in xs.
+func runs(xs []Serial) []Range {
+	var rs []Range
+	for _, x := range xs {
+		if n := len(rs); n > 0 && rs[n-1].Last+1 == x {
+			rs[n-1].Last = x
+		} else {
+			rs = append(rs, Range{First: x, Last: x})
+		}
+	}
+	return rs
+}
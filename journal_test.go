@@ -0,0 +1,96 @@
+package serial
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serials.log")
+	j, err := OpenJournal(path, 1000, false)
+	if err != nil {
+		t.Fatalf("OpenJournal failed: %v", err)
+	}
+	g := NewGenerator(WithJournal(j))
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				g.Generate()
+			}
+		}()
+	}
+	wg.Wait()
+	g.GenerateN(10)
+	block := g.ReserveBlock(100)
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if files, _ := filepath.Glob(path + ".*"); len(files) < 2 {
+		t.Errorf("Expected journal to have been rotated, got %d old files", len(files))
+	}
+
+	var last Serial
+	count := int64(0)
+	var lastRange Range
+	err = ReplayJournal(path, func(r Range, ts time.Time) error {
+		if r.First <= last {
+			t.Errorf("Journal out of order, %d after %d", r.First, last)
+		}
+		last = r.Last
+		count += r.Len()
+		lastRange = r
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReplayJournal failed: %v", err)
+	}
+	if count != 310 {
+		t.Errorf("Expected 310 values in journal got %d", count)
+	}
+	if lastRange != block {
+		t.Errorf("Expected last record %v got %v", block, lastRange)
+	}
+	if _, err := g.TryGenerate(); err == nil {
+		t.Error("Expected error generating with closed journal")
+	}
+}
+
+func TestJournalReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serials.log")
+	for i := 0; i < 2; i++ {
+		j, err := OpenJournal(path, 100, true)
+		if err != nil {
+			t.Fatalf("OpenJournal failed: %v", err)
+		}
+		g := NewGenerator(WithJournal(j))
+		for k := 0; k < 10; k++ {
+			g.Generate()
+		}
+		j.Close()
+	}
+	count := 0
+	ReplayJournal(path, func(r Range, ts time.Time) error {
+		count++
+		return nil
+	})
+	if count != 20 {
+		t.Errorf("Expected 20 records got %d", count)
+	}
+}
+
+func TestReplayJournalInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serials.log")
+	if err := os.WriteFile(path, []byte("bogus\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := ReplayJournal(path, func(r Range, ts time.Time) error { return nil })
+	if err == nil {
+		t.Error("Expected error replaying invalid journal")
+	}
+}
@@ -0,0 +1,65 @@
+package serial
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// MarshalJSON implements json.Marshaler, returning the serial value as a
+// JSON number, rather than the string MarshalText would give. Since Serial is
+// a json.Marshaler, the ",string" option has no effect on it; use
+// SerialString for a serial marshaled as a string.
+func (x Serial) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(x), 10), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting the serial value as a
+// JSON number or as a string of decimal digits. A JSON null leaves the value
+// unchanged.
+func (x *Serial) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	if len(b) >= 2 && b[0] == '"' && b[len(b)-1] == '"' {
+		b = b[1 : len(b)-1]
+	}
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return ErrInvalidDecimal
+	}
+	*x = Serial(n)
+	return nil
+}
+
+// SerialString is a Serial which is marshaled to JSON as a string of decimal
+// digits rather than as a number. JavaScript stores all numbers as 64 bit
+// floats, which only hold integers up to 53 bits exactly, so serials sent to
+// JavaScript clients as numbers will be silently corrupted. A Serial is
+// always marshaled as a number, even with the ",string" option, so use
+// SerialString for struct fields, slices and maps which should hold strings.
+// It converts to and from Serial:
+//
+//	resp := struct {
+//		Refs []serial.SerialString `json:"refs"`
+//	}{Refs: []serial.SerialString{serial.SerialString(x)}}
+type SerialString Serial
+
+// MarshalJSON implements json.Marshaler, returning the serial value as a
+// JSON string of decimal digits.
+func (x SerialString) MarshalJSON() ([]byte, error) {
+	b := make([]byte, 0, 22)
+	b = append(b, '"')
+	b = strconv.AppendInt(b, int64(x), 10)
+	return append(b, '"'), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting the same forms as
+// Serial.
+func (x *SerialString) UnmarshalJSON(b []byte) error {
+	return (*Serial)(x).UnmarshalJSON(b)
+}
+
+// String returns the serial value in decimal.
+func (x SerialString) String() string {
+	return Serial(x).String()
+}
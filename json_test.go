@@ -0,0 +1,78 @@
+package serial
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type jsonRecord struct {
+	ID    Serial   `json:"id"`
+	Refs  []Serial `json:"refs"`
+	Maybe *Serial  `json:"maybe"`
+}
+
+func TestJSON(t *testing.T) {
+	r := jsonRecord{ID: 1<<62 + 1, Refs: []Serial{1, 2}}
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(b); s != `{"id":4611686018427387905,"refs":[1,2],"maybe":null}` {
+		t.Errorf("Wrong numeric JSON %s", s)
+	}
+	var p jsonRecord
+	if err := json.Unmarshal(b, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != r.ID || len(p.Refs) != 2 || p.Refs[1] != 2 || p.Maybe != nil {
+		t.Errorf("Round trip failed, got %+v", p)
+	}
+	if err := json.Unmarshal([]byte(`{"id":12,"refs":["3",4]}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != 12 || p.Refs[0] != 3 || p.Refs[1] != 4 {
+		t.Errorf("Failed to unmarshal mixed forms, got %+v", p)
+	}
+	for _, s := range []string{`{"id":"abc"}`, `{"id":1.5}`, `{"id":"12}`} {
+		if err := json.Unmarshal([]byte(s), &p); err == nil {
+			t.Errorf("Expected an error unmarshaling %s", s)
+		}
+	}
+}
+
+type jsonStringRecord struct {
+	ID    SerialString   `json:"id"`
+	Refs  []SerialString `json:"refs"`
+	Maybe *SerialString  `json:"maybe"`
+}
+
+func TestJSONString(t *testing.T) {
+	x := SerialString(1<<62 + 1)
+	r := jsonStringRecord{ID: x, Refs: []SerialString{1, 2}, Maybe: &x}
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"4611686018427387905","refs":["1","2"],"maybe":"4611686018427387905"}`
+	if s := string(b); s != want {
+		t.Errorf("Expected %s got %s", want, s)
+	}
+	var p jsonStringRecord
+	if err := json.Unmarshal(b, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != x || len(p.Refs) != 2 || p.Refs[1] != 2 || p.Maybe == nil || *p.Maybe != x {
+		t.Errorf("Round trip failed, got %+v", p)
+	}
+	var q struct{ Refs []SerialString }
+	if err := json.Unmarshal([]byte(`{"Refs":[3,"4"]}`), &q); err != nil || len(q.Refs) != 2 || q.Refs[0] != 3 || q.Refs[1] != 4 {
+		t.Errorf("Failed to unmarshal mixed forms, got %+v %v", q, err)
+	}
+	// A Serial stays a number, even with the ",string" option.
+	b, err = json.Marshal(struct {
+		ID Serial `json:"id,string"`
+	}{12})
+	if s := string(b); err != nil || s != `{"id":12}` {
+		t.Errorf("Expected numeric JSON got %s %v", s, err)
+	}
+}
@@ -0,0 +1,103 @@
+package serial
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// ksuidEpoch is the epoch of KSUID timestamps, in Unix seconds.
+const ksuidEpoch = 1400000000
+
+// KSUID is a 160 bit K-Sortable Unique IDentifier, consisting of a 32 bit
+// timestamp in seconds followed by 128 random bits, as described at
+// <https://github.com/segmentio/ksuid>. Its string form is 27 characters of
+// Base62, and sorts in the same order as the underlying bytes.
+//
+// Unlike Serial values, KSUIDs don't depend on a single generator to keep
+// them unique. The random payload makes collisions vanishingly unlikely even
+// between uncoordinated processes, and makes the values impossible to guess,
+// at the cost of only ordering them to the nearest second.
+type KSUID [20]byte
+
+// ErrInvalidKSUID is returned when parsing a string which isn't a valid KSUID.
+var ErrInvalidKSUID = errors.New("serial: invalid KSUID")
+
+// GenerateKSUID generates a new KSUID from the current time and the system's
+// cryptographically secure random number generator, or the source set by
+// WithEntropy. It panics if random numbers are unavailable.
+func (g *Generator) GenerateKSUID() KSUID {
+	var k KSUID
+	binary.BigEndian.PutUint32(k[0:4], uint32(g.clock.Now().Unix()-ksuidEpoch))
+	if err := g.readRandom(k[4:]); err != nil {
+		panic(err)
+	}
+	return k
+}
+
+// Time returns the timestamp embedded in the KSUID.
+func (k KSUID) Time() time.Time {
+	return time.Unix(int64(binary.BigEndian.Uint32(k[0:4]))+ksuidEpoch, 0)
+}
+
+// String returns the canonical 27 character form of the KSUID.
+func (k KSUID) String() string {
+	b, _ := k.MarshalText()
+	return string(b)
+}
+
+// MarshalText implements encoding.TextMarshaler, returning the canonical form
+// of the KSUID.
+func (k KSUID) MarshalText() ([]byte, error) {
+	// Repeatedly divide the 160 bit number, held as five 32 bit words, by 62.
+	var words [5]uint64
+	for i := range words {
+		words[i] = uint64(binary.BigEndian.Uint32(k[i*4:]))
+	}
+	b := make([]byte, 27)
+	for i := 26; i >= 0; i-- {
+		var rem uint64
+		for j := range words {
+			v := rem<<32 | words[j]
+			words[j] = v / 62
+			rem = v % 62
+		}
+		b[i] = base62[rem]
+	}
+	return b, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting the form
+// returned by MarshalText.
+func (k *KSUID) UnmarshalText(b []byte) error {
+	if len(b) != 27 {
+		return ErrInvalidKSUID
+	}
+	var words [5]uint64
+	for _, c := range b {
+		v := base62Value(c)
+		if v < 0 {
+			return ErrInvalidKSUID
+		}
+		carry := uint64(v)
+		for j := len(words) - 1; j >= 0; j-- {
+			n := words[j]*62 + carry
+			words[j] = n & 0xffffffff
+			carry = n >> 32
+		}
+		if carry != 0 {
+			return ErrInvalidKSUID
+		}
+	}
+	for i, w := range words {
+		binary.BigEndian.PutUint32(k[i*4:], uint32(w))
+	}
+	return nil
+}
+
+// ParseKSUID parses the string form of a KSUID.
+func ParseKSUID(s string) (KSUID, error) {
+	var k KSUID
+	err := k.UnmarshalText([]byte(s))
+	return k, err
+}
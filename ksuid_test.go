@@ -0,0 +1,51 @@
+package serial
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestKSUID(t *testing.T) {
+	g := NewGenerator()
+	k1 := g.GenerateKSUID()
+	k2 := g.GenerateKSUID()
+	if k1 == k2 {
+		t.Error("Got the same KSUID twice!")
+	}
+	if d := time.Since(k1.Time()); d < 0 || d > 2*time.Second {
+		t.Errorf("Embedded timestamp is %v away from now", d)
+	}
+	for _, k := range []KSUID{k1, k2, {}, {0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}} {
+		s := k.String()
+		if len(s) != 27 {
+			t.Fatalf("Wrong length KSUID %q", s)
+		}
+		p, err := ParseKSUID(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != k {
+			t.Errorf("Round trip failed, expected %v got %v", k, p)
+		}
+	}
+}
+
+func TestKSUIDKnown(t *testing.T) {
+	// Example from the reference implementation's documentation.
+	k, err := ParseKSUID("0ujtsYcgvSTl8PAuAdqWYSMnLOv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts := k.Time().Unix(); ts != 1507608047 {
+		t.Errorf("Wrong timestamp, expected 1507608047 got %d", ts)
+	}
+	if p := fmt.Sprintf("%X", k[4:]); p != "B5A1CD34B5F99D1154FB6853345C9735" {
+		t.Errorf("Wrong payload %s", p)
+	}
+	for _, s := range []string{"", "0ujtsYcgvSTl8PAuAdqWYSMnLO", "0ujtsYcgvSTl8PAuAdqWYSMnLO_", "aWgEPTl1tmebfsQzFP4bxwgy80W"} {
+		if _, err := ParseKSUID(s); err != ErrInvalidKSUID {
+			t.Errorf("Expected ErrInvalidKSUID for %q, got %v", s, err)
+		}
+	}
+}
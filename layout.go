@@ -0,0 +1,223 @@
+package serial
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layout describes how a timestamp, a node ID and a sequence number are
+// packed into a Serial, from the most significant bits down. The timestamp
+// counts ticks of the specified Resolution since the Epoch, or since the Unix
+// epoch if Epoch is the zero time. The sequence
+// number counts serials generated within the same tick, and the node ID
+// distinguishes generators running independently on different machines.
+//
+// The three fields can total at most 63 bits, so that serial values are never
+// negative. Fewer bits can be used to keep values small; for example, a total
+// of 53 bits keeps values exactly representable as JavaScript numbers. A
+// recent Epoch also keeps values small and extends the time before the
+// timestamp runs out of bits, as well as obscuring the actual time at which
+// values were generated.
+type Layout struct {
+	TimeBits     uint
+	NodeBits     uint
+	SequenceBits uint
+	Resolution   time.Duration
+	Epoch        time.Time
+}
+
+// NanosecondLayout is the default layout, where a serial is simply a Unix
+// time in nanoseconds.
+var NanosecondLayout = Layout{TimeBits: 63, Resolution: time.Nanosecond}
+
+// SnowflakeLayout packs a millisecond timestamp, a 10 bit node ID and an 11
+// bit sequence number, similar to Twitter's Snowflake IDs. 42 bits of
+// milliseconds last until 2109.
+var SnowflakeLayout = Layout{TimeBits: 42, NodeBits: 10, SequenceBits: 11, Resolution: time.Millisecond}
+
+// ErrLayout is returned when asked to create a generator with an unusable
+// layout.
+var ErrLayout = errors.New("serial: invalid layout")
+
+// ErrEpoch is returned when asked to create a generator whose layout has an
+// epoch later than the current time of its clock.
+var ErrEpoch = errors.New("serial: epoch is in the future")
+
+// check verifies that the layout is usable.
+func (l Layout) check() error {
+	if l.TimeBits == 0 || l.TimeBits+l.NodeBits+l.SequenceBits > 63 || l.Resolution <= 0 {
+		return ErrLayout
+	}
+	return nil
+}
+
+// maxNode returns the largest node ID which fits the layout.
+func (l Layout) maxNode() int64 {
+	return 1<<l.NodeBits - 1
+}
+
+// maxSeq returns the largest sequence number which fits the layout.
+func (l Layout) maxSeq() int64 {
+	return 1<<l.SequenceBits - 1
+}
+
+// ticks converts a time to the number of ticks of the layout's resolution
+// since its epoch.
+func (l Layout) ticks(t time.Time) int64 {
+	return (t.UnixNano() - l.epoch()) / int64(l.Resolution)
+}
+
+// ceilTicks is like ticks, but rounds up to a whole tick, and never returns
+// less than zero.
+func (l Layout) ceilTicks(t time.Time) int64 {
+	ns := t.UnixNano() - l.epoch()
+	if ns <= 0 {
+		return 0
+	}
+	return (ns + int64(l.Resolution) - 1) / int64(l.Resolution)
+}
+
+// Between returns the range of values whose embedded times fall within the
+// window from from up to but not including to, whichever node generated
+// them, so that "all values issued between two times" can be passed around
+// and checked with Range.Contains. The range is empty if to isn't after
+// from.
+func (l Layout) Between(from, to time.Time) Range {
+	return Range{First: l.pack(l.ceilTicks(from), 0, 0), Last: l.pack(l.ceilTicks(to), 0, 0) - 1}
+}
+
+// timeOf returns the time embedded in a serial value.
+func (l Layout) timeOf(x Serial) time.Time {
+	ticks, _, _ := l.unpack(x)
+	return time.Unix(0, l.epoch()+ticks*int64(l.Resolution))
+}
+
+// epoch returns the layout's epoch in Unix nanoseconds.
+func (l Layout) epoch() int64 {
+	if l.Epoch.IsZero() {
+		return 0
+	}
+	return l.Epoch.UnixNano()
+}
+
+// pack assembles a Serial from its components.
+func (l Layout) pack(ticks, node, seq int64) Serial {
+	return Serial(ticks<<(l.NodeBits+l.SequenceBits) | node<<l.SequenceBits | seq)
+}
+
+// unpack splits a Serial into its components.
+func (l Layout) unpack(x Serial) (ticks, node, seq int64) {
+	ticks = int64(x) >> (l.NodeBits + l.SequenceBits)
+	node = int64(x) >> l.SequenceBits & l.maxNode()
+	seq = int64(x) & l.maxSeq()
+	return
+}
+
+// next returns the serial which follows last for the specified node at time
+// now. If the clock hasn't moved on since last was generated, the sequence
+// number is incremented; if that runs out, the timestamp is pushed forward a
+// tick.
+func (l Layout) next(last Serial, node int64, now time.Time) Serial {
+	ticks := l.ticks(now)
+	lticks, _, lseq := l.unpack(last)
+	var seq int64
+	if ticks <= lticks {
+		ticks = lticks
+		seq = lseq + 1
+		if seq > l.maxSeq() {
+			ticks++
+			seq = 0
+		}
+	}
+	return l.pack(ticks, node, seq)
+}
+
+// String returns the layout in the form accepted by Set: "nanosecond" or
+// "snowflake" for the predefined layouts with no epoch, and otherwise a
+// comma-separated list of fields such as
+// "time=41,node=10,sequence=12,resolution=1ms,epoch=2020-01-01T00:00:00Z".
+func (l Layout) String() string {
+	switch {
+	case sameLayout(l, NanosecondLayout):
+		return "nanosecond"
+	case sameLayout(l, SnowflakeLayout):
+		return "snowflake"
+	}
+	s := "time=" + strconv.FormatUint(uint64(l.TimeBits), 10) +
+		",node=" + strconv.FormatUint(uint64(l.NodeBits), 10) +
+		",sequence=" + strconv.FormatUint(uint64(l.SequenceBits), 10) +
+		",resolution=" + l.Resolution.String()
+	if !l.Epoch.IsZero() {
+		s += ",epoch=" + l.Epoch.Format(time.RFC3339Nano)
+	}
+	return s
+}
+
+// Set implements flag.Value, accepting a layout in the form returned by
+// String, so that a *Layout can be passed to flag.Var. A list of fields may
+// start with the name of a predefined layout, so
+// "snowflake,epoch=2020-01-01T00:00:00Z" is SnowflakeLayout with a custom
+// epoch. The result must be a usable layout,
+// or ErrLayout is returned and the layout is left unchanged.
+func (l *Layout) Set(s string) error {
+	var n Layout
+	for i, f := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(f), "=")
+		if !ok {
+			if i > 0 {
+				return ErrLayout
+			}
+			switch key {
+			case "nanosecond":
+				n = NanosecondLayout
+			case "snowflake":
+				n = SnowflakeLayout
+			default:
+				return ErrLayout
+			}
+			continue
+		}
+		var err error
+		switch key {
+		case "time", "node", "sequence":
+			var bits uint64
+			bits, err = strconv.ParseUint(value, 10, 8)
+			switch key {
+			case "time":
+				n.TimeBits = uint(bits)
+			case "node":
+				n.NodeBits = uint(bits)
+			default:
+				n.SequenceBits = uint(bits)
+			}
+		case "resolution":
+			n.Resolution, err = time.ParseDuration(value)
+		case "epoch":
+			n.Epoch, err = time.Parse(time.RFC3339Nano, value)
+		default:
+			return ErrLayout
+		}
+		if err != nil {
+			return ErrLayout
+		}
+	}
+	if err := n.check(); err != nil {
+		return err
+	}
+	*l = n
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, returning the layout in the
+// form returned by String.
+func (l Layout) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting the forms
+// accepted by Set.
+func (l *Layout) UnmarshalText(b []byte) error {
+	return l.Set(string(b))
+}
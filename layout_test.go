@@ -0,0 +1,195 @@
+package serial
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestSnowflake(t *testing.T) {
+	if _, err := NewSnowflakeGenerator(1024); err != ErrNodeRange {
+		t.Errorf("Expected ErrNodeRange for node 1024, got %v", err)
+	}
+	g1, err := NewSnowflakeGenerator(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g2, err := NewSnowflakeGenerator(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[Serial]bool)
+	for i := 0; i < 5000; i++ {
+		for _, g := range []*Generator{g1, g2} {
+			x := g.Generate()
+			if seen[x] {
+				t.Fatalf("Got the same value twice: %d", x)
+			}
+			seen[x] = true
+			if _, node, _ := SnowflakeLayout.unpack(x); node != g.node {
+				t.Fatalf("Wrong node in %d, expected %d got %d", x, g.node, node)
+			}
+		}
+	}
+	x := g1.Generate()
+	ticks, _, _ := SnowflakeLayout.unpack(x)
+	if d := time.Since(time.Unix(0, ticks*int64(time.Millisecond))); d < -time.Second || d > time.Second {
+		t.Errorf("Embedded timestamp is %v away from now", d)
+	}
+}
+
+func TestSequenceRollover(t *testing.T) {
+	l := Layout{TimeBits: 59, SequenceBits: 4, Resolution: time.Millisecond}
+	now := time.Unix(1000, 0)
+	var last Serial
+	for i := 0; i < 16; i++ {
+		last = l.next(last, 0, now)
+	}
+	if ticks, _, seq := l.unpack(last); ticks != l.ticks(now) || seq != 15 {
+		t.Errorf("Wrong value before rollover: ticks %d seq %d", ticks, seq)
+	}
+	last = l.next(last, 0, now)
+	if ticks, _, seq := l.unpack(last); ticks != l.ticks(now)+1 || seq != 0 {
+		t.Errorf("Wrong value after rollover: ticks %d seq %d", ticks, seq)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	if _, err := New(WithLayout(Layout{TimeBits: 50, NodeBits: 10, SequenceBits: 4})); err != ErrLayout {
+		t.Errorf("Expected ErrLayout with no resolution, got %v", err)
+	}
+	if _, err := New(WithLayout(Layout{TimeBits: 50, NodeBits: 10, SequenceBits: 4, Resolution: time.Millisecond})); err != ErrLayout {
+		t.Errorf("Expected ErrLayout with 64 bits, got %v", err)
+	}
+	if _, err := New(WithLayout(Layout{TimeBits: 41, SequenceBits: 12, Resolution: time.Millisecond}), WithNode(1)); err != ErrNodeRange {
+		t.Errorf("Expected ErrNodeRange with no node bits, got %v", err)
+	}
+	g := NewGenerator(WithLayout(Layout{TimeBits: 41, SequenceBits: 12, Resolution: time.Millisecond}))
+	for i := 0; i < 10000; i++ {
+		if x := g.Generate(); x >= 1<<53 {
+			t.Fatalf("Value %d doesn't fit in 53 bits", x)
+		}
+	}
+}
+
+func TestEpoch(t *testing.T) {
+	epoch := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, opts := range [][]Option{
+		{WithEpoch(epoch), WithLayout(SnowflakeLayout)},
+		{WithLayout(SnowflakeLayout), WithEpoch(epoch)},
+	} {
+		g := NewGenerator(opts...)
+		x := g.Generate()
+		ticks, _, _ := SnowflakeLayout.unpack(x)
+		if d := time.Since(epoch.Add(time.Duration(ticks) * time.Millisecond)); d < -time.Second || d > time.Second {
+			t.Errorf("Embedded timestamp is %v away from now", d)
+		}
+	}
+	g := NewGenerator(WithEpoch(epoch))
+	if x := g.Generate(); x > Serial(time.Since(epoch)+time.Second) {
+		t.Errorf("Value %d is too large for the epoch", x)
+	}
+	future := time.Now().Add(time.Hour)
+	for _, opts := range [][]Option{
+		{WithEpoch(future)},
+		{WithLayout(Layout{TimeBits: 41, Resolution: time.Millisecond, Epoch: future})},
+		{WithClock(&fakeClock{now: time.Unix(1000, 0)}), WithEpoch(epoch)},
+	} {
+		if _, err := New(opts...); err != ErrEpoch {
+			t.Errorf("Expected ErrEpoch got %v", err)
+		}
+	}
+}
+
+func TestResolution(t *testing.T) {
+	if _, err := New(WithResolution(0, 10)); err != ErrLayout {
+		t.Errorf("Expected ErrLayout for zero resolution, got %v", err)
+	}
+	if _, err := New(WithResolution(time.Millisecond, 63)); err != ErrLayout {
+		t.Errorf("Expected ErrLayout for 63 sequence bits, got %v", err)
+	}
+	g := NewGenerator(WithResolution(time.Millisecond, 10))
+	for i := 0; i < 5000; i++ {
+		if x := g.Generate(); x >= 1<<53 {
+			t.Fatalf("Value %d doesn't fit in 53 bits", x)
+		}
+	}
+	if d := g.Age(g.Generate()); d < -time.Second || d > time.Second {
+		t.Errorf("Age of new value is %v", d)
+	}
+	g = NewGenerator(WithLayout(SnowflakeLayout), WithResolution(time.Microsecond, 8))
+	if l := g.layout; l.NodeBits != 10 || l.SequenceBits != 8 || l.TimeBits != 45 {
+		t.Errorf("Wrong layout after setting resolution: %+v", l)
+	}
+}
+
+func TestSnowflakeOptions(t *testing.T) {
+	epoch := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	g, err := NewSnowflakeGenerator(3, WithEpoch(epoch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := g.Generate()
+	if d := time.Since(g.Time(x)); d < -time.Second || d > time.Second {
+		t.Errorf("Embedded timestamp is %v away from now", d)
+	}
+	ticks, node, _ := SnowflakeLayout.unpack(x)
+	if node != 3 || ticks > time.Since(epoch).Milliseconds()+1000 {
+		t.Errorf("Expected node 3 and ticks from 2020, got %d and %d", node, ticks)
+	}
+}
+
+func TestLayoutText(t *testing.T) {
+	custom := Layout{TimeBits: 41, NodeBits: 10, SequenceBits: 12, Resolution: time.Millisecond,
+		Epoch: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	for _, c := range []struct {
+		layout Layout
+		text   string
+	}{
+		{NanosecondLayout, "nanosecond"},
+		{SnowflakeLayout, "snowflake"},
+		{custom, "time=41,node=10,sequence=12,resolution=1ms,epoch=2020-01-01T00:00:00Z"},
+	} {
+		if s := c.layout.String(); s != c.text {
+			t.Errorf("Expected %s got %s", c.text, s)
+		}
+		var l Layout
+		if err := l.Set(c.text); err != nil {
+			t.Fatal(err)
+		}
+		if !sameLayout(l, c.layout) {
+			t.Errorf("Round trip of %s failed, got %+v", c.text, l)
+		}
+	}
+	var l Layout
+	if err := l.Set("snowflake, epoch=2020-01-01T00:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	if s := l.String(); s != "time=42,node=10,sequence=11,resolution=1ms,epoch=2020-01-01T00:00:00Z" {
+		t.Errorf("Wrong layout from preset with epoch %s", s)
+	}
+	before := l
+	for _, s := range []string{"", "millisecond", "time=64", "time=41,node=x,resolution=1ms",
+		"time=41,resolution=0s", "time=41,resolution=1ms,snowflake", "time=41,bits=3,resolution=1ms"} {
+		if err := l.Set(s); err != ErrLayout {
+			t.Errorf("Expected ErrLayout for %q got %v", s, err)
+		}
+	}
+	if !sameLayout(l, before) {
+		t.Error("Expected a failed Set to leave the layout unchanged")
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	l = NanosecondLayout
+	fs.Var(&l, "layout", "serial layout")
+	if err := fs.Parse([]string{"-layout", "snowflake"}); err != nil {
+		t.Fatal(err)
+	}
+	g, err := New(WithLayout(l), WithNode(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := g.Decompose(g.Generate()).Node; n != 3 {
+		t.Errorf("Expected node 3 from flag layout, got %d", n)
+	}
+}
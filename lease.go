@@ -0,0 +1,134 @@
+package serial
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrLease is returned when asked to create a generator which leases blocks
+// of values with a block size less than 1, or when an Allocator returns an
+// empty block.
+var ErrLease = errors.New("serial: invalid lease")
+
+// Allocator hands out leases on contiguous blocks of serial values, for
+// generators created with WithAllocator. The blocks it hands out must never
+// overlap, across all of its callers. An Allocator will usually be a client
+// of a central service; a Pager on the central generator is a suitable
+// implementation for the service itself.
+type Allocator interface {
+	// Lease returns a fresh block of size values.
+	Lease(size int) (Range, error)
+}
+
+// Lease implements Allocator, using Acquire, but returns an error rather
+// than panicking if a page can't be reserved.
+func (p *Pager) Lease(size int) (Range, error) {
+	return p.acquire(size)
+}
+
+// leaser holds the state of a generator which issues values from leased
+// blocks.
+type leaser struct {
+	alloc Allocator
+	size  int
+	mutex sync.Mutex
+	block Range
+}
+
+// WithAllocator makes the generator issue values from blocks of the
+// specified size leased from an Allocator, rather than from the clock. Values
+// are then unique across every generator leasing from the same allocator,
+// without needing node IDs, and only every size values does the generator
+// have to wait for the allocator. Values are issued in ascending order from
+// each block, but their embedded times are the times at which the blocks
+// were allocated.
+//
+// If the allocator returns an error, TryGenerate returns it, and Generate,
+// Fill and ReserveBlock panic with it. ReserveBlock leases its block directly
+// from the allocator. Values from Generate128 don't use the allocator.
+func WithAllocator(a Allocator, size int) Option {
+	return func(g *Generator) error {
+		if a == nil || size < 1 {
+			return ErrLease
+		}
+		g.leaser = &leaser{alloc: a, size: size, block: Range{First: 1, Last: 0}}
+		return nil
+	}
+}
+
+// fillLeased fills xs with values from the generator's leased blocks,
+// leasing more as needed, unless the context is done.
+func (g *Generator) fillLeased(ctx context.Context, xs []Serial) error {
+	l := g.leaser
+	g.lockJournal()
+	l.mutex.Lock()
+	var err error
+	last := Serial(g.lastSerial.Load())
+	for i := range xs {
+		if l.block.First <= last {
+			l.block.First = last + 1
+		}
+		for l.block.First > l.block.Last {
+			if err = ctx.Err(); err != nil {
+				break
+			}
+			if l.block, err = l.alloc.Lease(l.size); err != nil {
+				break
+			}
+			if l.block.Len() < 1 {
+				err = ErrLease
+				break
+			}
+			if l.block.First <= last {
+				l.block.First = last + 1
+			}
+		}
+		if err != nil {
+			l.block = Range{First: 1, Last: 0}
+			break
+		}
+		xs[i] = l.block.First
+		last = xs[i]
+		l.block.First++
+	}
+	if err == nil {
+		g.raise(last)
+	}
+	l.mutex.Unlock()
+	if err := g.finishLeased(err, runs(xs)); err != nil {
+		return err
+	}
+	g.countSerials(g.clock.Now(), xs)
+	g.generated(xs)
+	return nil
+}
+
+// reserveLeased leases a block of n values directly from the generator's
+// allocator.
+func (g *Generator) reserveLeased(n int) (Range, error) {
+	g.lockJournal()
+	r, err := g.leaser.alloc.Lease(n)
+	if err == nil && r.Len() != int64(n) {
+		err = ErrLease
+	}
+	if err == nil {
+		g.raise(r.Last)
+	}
+	return r, g.finishLeased(err, []Range{r})
+}
+
+// finishLeased journals and checkpoints leased values, unless there was an
+// error leasing them.
+func (g *Generator) finishLeased(err error, rs []Range) error {
+	if err != nil {
+		if g.journal != nil {
+			g.journal.mutex.Unlock()
+		}
+		return err
+	}
+	if err := g.unlockJournal(g.clock.Now(), rs...); err != nil {
+		return err
+	}
+	return g.checkpointed(rs[len(rs)-1].Last)
+}
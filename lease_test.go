@@ -0,0 +1,82 @@
+package serial
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// countingAllocator counts the leases it hands out, and can be made to fail.
+type countingAllocator struct {
+	Allocator
+	mutex  sync.Mutex
+	leases int
+	err    error
+}
+
+func (a *countingAllocator) Lease(size int) (Range, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.err != nil {
+		return Range{}, a.err
+	}
+	a.leases++
+	return a.Allocator.Lease(size)
+}
+
+func TestAllocator(t *testing.T) {
+	alloc := &countingAllocator{Allocator: NewPager(NewGenerator(), false)}
+	nodes := []*Generator{
+		NewGenerator(WithAllocator(alloc, 100)),
+		NewGenerator(WithAllocator(alloc, 100)),
+	}
+	var mutex sync.Mutex
+	seen := make(map[Serial]bool)
+	var wg sync.WaitGroup
+	for _, g := range nodes {
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func(g *Generator) {
+				defer wg.Done()
+				var last Serial
+				for i := 0; i < 250; i++ {
+					x := g.Generate()
+					if x <= last {
+						t.Errorf("Values out of order, %d after %d", x, last)
+					}
+					last = x
+					mutex.Lock()
+					if seen[x] {
+						t.Errorf("Duplicate value %d", x)
+					}
+					seen[x] = true
+					mutex.Unlock()
+				}
+			}(g)
+		}
+	}
+	wg.Wait()
+	if alloc.leases != 20 {
+		t.Errorf("Expected 20 leases got %d", alloc.leases)
+	}
+	xs := nodes[0].GenerateN(150)
+	if alloc.leases != 22 {
+		t.Errorf("Expected 22 leases got %d", alloc.leases)
+	}
+	r := nodes[1].ReserveBlock(1000)
+	if r.Len() != 1000 || r.First <= xs[len(xs)-1] {
+		t.Errorf("Unexpected block %v", r)
+	}
+
+	alloc.err = errors.New("allocator unavailable")
+	g := NewGenerator(WithAllocator(alloc, 100))
+	if _, err := g.TryGenerate(); err != alloc.err {
+		t.Errorf("Expected allocator error got %v", err)
+	}
+}
+
+func TestWithAllocatorInvalid(t *testing.T) {
+	if _, err := New(WithAllocator(NewPager(NewGenerator(), false), 0)); err != ErrLease {
+		t.Errorf("Expected ErrLease got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package serial
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrDomainExists is returned when asked to create a domain with the same
+// name as one which already exists.
+var ErrDomainExists = errors.New("serial: domain already exists")
+
+// Manager owns a set of named generators, one for each domain of serial
+// values, such as each tenant of a service. The generators share a common
+// configuration, or are created elsewhere and registered, and their seen
+// histories can be expired by a single background goroutine rather than one
+// per generator. A Manager is safe for concurrent use.
+type Manager struct {
+	mutex    sync.RWMutex
+	opts     []Option
+	domains  map[string]*Generator
+	interval time.Duration
+	agelimit time.Duration
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewManager creates a Manager whose domains are configured by the options
+// provided. If interval is positive, a background goroutine calls ExpireSeen
+// with the specified age limit on every domain once in each interval, until
+// Close is called; the options therefore shouldn't include WithAutoExpire.
+// The domains are expired one at a time, in turn, spread across the interval
+// with some random jitter, so that hundreds of domains don't all pause at
+// once. If interval is zero, expiry is up to the application. It returns
+// ErrAutoExpire if interval is negative.
+func NewManager(interval, agelimit time.Duration, opts ...Option) (*Manager, error) {
+	if interval < 0 {
+		return nil, ErrAutoExpire
+	}
+	m := &Manager{
+		opts:     opts,
+		domains:  make(map[string]*Generator),
+		interval: interval,
+		agelimit: agelimit,
+	}
+	if interval > 0 {
+		m.stop = make(chan struct{})
+		m.done = make(chan struct{})
+		go m.expire()
+	}
+	return m, nil
+}
+
+// CreateDomain creates a generator for the named domain, configured by the
+// manager's options followed by any options provided, so that individual
+// domains can override the shared configuration. It returns ErrDomainExists
+// if the domain already exists, or an error if the options are invalid.
+func (m *Manager) CreateDomain(name string, opts ...Option) (*Generator, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.domains[name]; ok {
+		return nil, ErrDomainExists
+	}
+	all := make([]Option, 0, len(m.opts)+len(opts))
+	all = append(append(all, m.opts...), opts...)
+	gen, err := New(all...)
+	if err != nil {
+		return nil, err
+	}
+	m.domains[name] = gen
+	return gen, nil
+}
+
+// Register adds a generator created elsewhere to the manager as the named
+// domain, so that it is expired and closed along with the others. It returns
+// ErrDomainExists if the domain already exists.
+func (m *Manager) Register(name string, gen *Generator) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.domains[name]; ok {
+		return ErrDomainExists
+	}
+	m.domains[name] = gen
+	return nil
+}
+
+// Domain returns the generator for the named domain, and whether it exists.
+func (m *Manager) Domain(name string) (*Generator, bool) {
+	m.mutex.RLock()
+	gen, ok := m.domains[name]
+	m.mutex.RUnlock()
+	return gen, ok
+}
+
+// DeleteDomain removes the named domain from the manager and closes its
+// generator, and reports whether it existed, along with any error from
+// closing the generator. Callers still holding the generator can go on using
+// its seen history, but it is no longer expired by the manager. The domain
+// is removed even if closing fails.
+func (m *Manager) DeleteDomain(name string) (bool, error) {
+	m.mutex.Lock()
+	gen, ok := m.domains[name]
+	delete(m.domains, name)
+	m.mutex.Unlock()
+	if !ok {
+		return false, nil
+	}
+	return true, gen.Close()
+}
+
+// Names returns the names of the manager's domains, in sorted order.
+func (m *Manager) Names() []string {
+	m.mutex.RLock()
+	names := make([]string, 0, len(m.domains))
+	for name := range m.domains {
+		names = append(names, name)
+	}
+	m.mutex.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// ExpireSeen calls ExpireSeen with the specified age limit on every domain.
+func (m *Manager) ExpireSeen(agelimit time.Duration) {
+	for _, gen := range m.generators() {
+		gen.ExpireSeen(agelimit)
+	}
+}
+
+// Stats returns the totals of the figures for all the manager's domains, as
+// returned by their generators' Stats methods, with the latest of their
+// LastGenerated times.
+func (m *Manager) Stats() Stats {
+	var total Stats
+	for _, gen := range m.generators() {
+		s := gen.Stats()
+		total.Generated += s.Generated
+		total.Seen += s.Seen
+		total.Expirations += s.Expirations
+		total.Expired += s.Expired
+		total.Rollbacks += s.Rollbacks
+		total.Ahead += s.Ahead
+		total.Raised += s.Raised
+		if s.LastGenerated.After(total.LastGenerated) {
+			total.LastGenerated = s.LastGenerated
+		}
+	}
+	return total
+}
+
+// generators returns the generators for the manager's domains, in order of
+// their names.
+func (m *Manager) generators() []*Generator {
+	names := m.Names()
+	gens := make([]*Generator, 0, len(names))
+	m.mutex.RLock()
+	for _, name := range names {
+		if gen, ok := m.domains[name]; ok {
+			gens = append(gens, gen)
+		}
+	}
+	m.mutex.RUnlock()
+	return gens
+}
+
+// expire runs the manager's expiry loop until it is closed, expiring one
+// domain at a time in turn.
+func (m *Manager) expire() {
+	defer close(m.done)
+	next := 0
+	for {
+		gens := m.generators()
+		wait := m.interval
+		if len(gens) > 1 {
+			wait /= time.Duration(len(gens))
+		}
+		// Up to a tenth either way.
+		wait += time.Duration(rand.Int64N(int64(wait)/5+1)) - wait/10
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+			if len(gens) > 0 {
+				gens[next%len(gens)].ExpireSeen(m.agelimit)
+				next = (next + 1) % len(gens)
+			}
+		case <-m.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// Close stops the manager's expiry goroutine, if it has one, and closes the
+// generators for all its domains, returning the errors from closing them
+// joined with errors.Join. It is safe to call more than once; later calls
+// return the same error.
+func (m *Manager) Close() error {
+	m.closeOnce.Do(func() {
+		if m.stop != nil {
+			close(m.stop)
+			<-m.done
+		}
+		var errs []error
+		for _, gen := range m.generators() {
+			errs = append(errs, gen.Close())
+		}
+		m.closeErr = errors.Join(errs...)
+	})
+	return m.closeErr
+}
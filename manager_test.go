@@ -0,0 +1,154 @@
+package serial
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManager(t *testing.T) {
+	m, err := NewManager(0, 0, WithMaxSeen(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	a, err := m.CreateDomain("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.CreateDomain("a"); err != ErrDomainExists {
+		t.Errorf("Expected ErrDomainExists got %v", err)
+	}
+	b, err := m.CreateDomain("b", WithSeenQuota(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.CreateDomain("c", WithMaxSeen(0)); err != ErrMaxSeen {
+		t.Errorf("Expected ErrMaxSeen got %v", err)
+	}
+	if gen, ok := m.Domain("a"); !ok || gen != a {
+		t.Error("Expected Domain to return the generator for a")
+	}
+	if names := m.Names(); len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("Expected [a b] got %v", names)
+	}
+	x := a.Generate()
+	a.SetSeen(x)
+	if b.Seen(x) {
+		t.Error("Domains share seen history")
+	}
+	b.SetSeen(x)
+	if err := b.TrySetSeen(x + 1); err == nil {
+		t.Error("Expected per-domain quota to apply")
+	}
+	if ok, err := m.DeleteDomain("a"); !ok || err != nil {
+		t.Errorf("Expected DeleteDomain to report true, got %v and %v", ok, err)
+	}
+	if ok, _ := m.DeleteDomain("a"); ok {
+		t.Error("Expected DeleteDomain to report false for a deleted domain")
+	}
+	if _, ok := m.Domain("a"); ok {
+		t.Error("Deleted domain still present")
+	}
+}
+
+func TestManagerExpiry(t *testing.T) {
+	if _, err := NewManager(-time.Second, 0); err != ErrAutoExpire {
+		t.Errorf("Expected ErrAutoExpire got %v", err)
+	}
+	m, err := NewManager(time.Millisecond, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen, _ := m.CreateDomain("a")
+	x := gen.Generate()
+	gen.SetSeen(x)
+	deadline := time.Now().Add(5 * time.Second)
+	for gen.Seen(x) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if gen.Seen(x) {
+		t.Error("Manager didn't expire the domain's history")
+	}
+	m.Close()
+	m.Close()
+}
+
+func TestManagerRegister(t *testing.T) {
+	m, err := NewManager(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	a, _ := m.CreateDomain("a")
+	b := NewGenerator()
+	if err := m.Register("b", b); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Register("a", b); err != ErrDomainExists {
+		t.Errorf("Expected ErrDomainExists got %v", err)
+	}
+	if gen, ok := m.Domain("b"); !ok || gen != b {
+		t.Error("Expected Domain to return the registered generator")
+	}
+	a.GenerateN(3)
+	b.SetSeen(b.Generate())
+	b.SetSeen(b.Generate())
+	s := m.Stats()
+	if s.Generated != 5 || s.Seen != 2 || s.LastGenerated.IsZero() {
+		t.Errorf("Wrong aggregate stats %+v", s)
+	}
+}
+
+func TestManagerRoundRobin(t *testing.T) {
+	m, err := NewManager(20*time.Millisecond, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	var gens []*Generator
+	for _, name := range []string{"a", "b", "c", "d"} {
+		gen, _ := m.CreateDomain(name)
+		gen.SetSeen(gen.Generate())
+		gens = append(gens, gen)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Stats().Seen > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := m.Stats().Seen; n != 0 {
+		t.Errorf("Expected every domain expired, %d values left", n)
+	}
+	for _, gen := range gens {
+		if n := gen.Stats().Expirations; n < 1 {
+			t.Errorf("Expected each domain to be expired, got %d expirations", n)
+		}
+	}
+}
+
+func TestManagerCloseErrors(t *testing.T) {
+	m, err := NewManager(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Generators whose mapped state has been closed under them fail to
+	// flush it when they are closed.
+	broken := func() *Generator {
+		ms, err := OpenMappedState(filepath.Join(t.TempDir(), "state"), 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gen := NewGenerator(WithMappedState(ms, time.Second))
+		ms.Close()
+		return gen
+	}
+	m.Register("a", broken())
+	m.Register("b", broken())
+	m.CreateDomain("c")
+	if ok, err := m.DeleteDomain("a"); !ok || err == nil {
+		t.Errorf("Expected DeleteDomain to report the close error, got %v and %v", ok, err)
+	}
+	if err := m.Close(); err == nil {
+		t.Error("Expected Close to report the close error")
+	}
+}
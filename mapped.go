@@ -0,0 +1,284 @@
+package serial
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// ErrMapped is returned by OpenMappedState when the file exists but wasn't
+// written by a MappedState with the same number of entries, or when asked
+// for fewer than one entry.
+var ErrMapped = errors.New("serial: invalid mapped state file")
+
+// mappedMagic identifies a mapped state file.
+const mappedMagic = "SERIALM2"
+
+// The layout of a mapped state file: a header holding the magic string, the
+// number of entry slots, the watermark, the index of the next slot to write
+// and the expiry limit of the last expiry, followed by the slots, each
+// holding a serial value, its deadline, whether it was flagged or unflagged,
+// and a check word. Slots are a power of two in size and start on a
+// multiple of their size, so none straddles a page.
+const (
+	mappedSlotsOff   = 8
+	mappedLastOff    = 16
+	mappedNextOff    = 24
+	mappedHorizonOff = 32
+	mappedHeader     = 64
+	mappedSlot       = 32
+)
+
+// The kinds of change a slot records.
+const (
+	mappedSet   = 1
+	mappedUnset = 2
+)
+
+// MappedState keeps a generator's watermark, the highest value it has
+// issued, and its most recently flagged seen entries in a fixed-size file
+// mapped into memory. Updates are plain stores to memory, with no system
+// call, so they cost almost nothing; the operating system writes them back
+// to the file in its own time. The state therefore survives the process
+// crashing or being killed, but changes made since the last call to Sync may
+// be lost if the machine itself crashes, which is why WithMappedState skips
+// ahead by a margin when it recovers.
+//
+// The seen entries are kept in a ring, along with the values unflagged by
+// UnsetSeen, so only the most recent changes survive, and values attached by
+// SetSeenWithValue aren't kept. Each slot carries a check word, written
+// last, so a slot only partly written when the machine crashed is skipped.
+// The file uses the machine's byte order, so can't be moved between machines
+// with different byte orders. Memory mapping is only available on Unix
+// systems.
+type MappedState struct {
+	file  *os.File
+	data  []byte
+	slots uint64
+}
+
+// OpenMappedState opens the mapped state file with the specified path,
+// creating it with room for the specified number of seen entries if it
+// doesn't exist. On systems without memory mapping, it returns an error
+// which matches errors.ErrUnsupported.
+func OpenMappedState(path string, entries int) (*MappedState, error) {
+	if entries < 1 {
+		return nil, ErrMapped
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	size := int64(mappedHeader + entries*mappedSlot)
+	fi, err := f.Stat()
+	if err == nil && fi.Size() == 0 {
+		err = f.Truncate(size)
+	} else if err == nil && fi.Size() != size {
+		err = ErrMapped
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	data, err := mapFile(f, int(size))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	m := &MappedState{file: f, data: data, slots: uint64(entries)}
+	switch string(data[:8]) {
+	case mappedMagic:
+		if *m.word(mappedSlotsOff) != int64(entries) {
+			m.Close()
+			return nil, ErrMapped
+		}
+	case "\x00\x00\x00\x00\x00\x00\x00\x00":
+		copy(data, mappedMagic)
+		*m.word(mappedSlotsOff) = int64(entries)
+	default:
+		m.Close()
+		return nil, ErrMapped
+	}
+	return m, nil
+}
+
+// word returns a pointer to the 64 bit word at the specified offset in the
+// mapped file. The mapping is page aligned, and every offset used is a
+// multiple of 8, so the words can be updated atomically.
+func (m *MappedState) word(off int) *int64 {
+	return (*int64)(unsafe.Pointer(&m.data[off]))
+}
+
+// Watermark returns the highest value recorded.
+func (m *MappedState) Watermark() Serial {
+	return Serial(atomic.LoadInt64(m.word(mappedLastOff)))
+}
+
+// raise records x as the watermark, if it is higher than the current one.
+func (m *MappedState) raise(x Serial) {
+	p := m.word(mappedLastOff)
+	for {
+		last := atomic.LoadInt64(p)
+		if int64(x) <= last || atomic.CompareAndSwapInt64(p, last, int64(x)) {
+			return
+		}
+	}
+}
+
+// record adds a seen entry to the ring, overwriting the oldest.
+func (m *MappedState) record(x Serial, e seenEntry) {
+	m.write(x, e.deadline, mappedSet)
+}
+
+// forget records in the ring that x has been unflagged.
+func (m *MappedState) forget(x Serial) {
+	m.write(x, 0, mappedUnset)
+}
+
+// expire records limit as the expiry limit, if it is higher than the current
+// one, so that entries which expire by age below it aren't recovered.
+func (m *MappedState) expire(limit Serial) {
+	p := m.word(mappedHorizonOff)
+	for {
+		h := atomic.LoadInt64(p)
+		if int64(limit) <= h || atomic.CompareAndSwapInt64(p, h, int64(limit)) {
+			return
+		}
+	}
+}
+
+// write fills the next slot in the ring. The check word is cleared first and
+// written last, and covers the slot's index as well as its contents, so a
+// slot which was only partly overwritten doesn't pass as either its old or
+// its new contents.
+func (m *MappedState) write(x Serial, deadline, kind int64) {
+	i := uint64(atomic.AddInt64(m.word(mappedNextOff), 1) - 1)
+	off := mappedHeader + int(i%m.slots)*mappedSlot
+	atomic.StoreInt64(m.word(off+24), 0)
+	atomic.StoreInt64(m.word(off), int64(x))
+	atomic.StoreInt64(m.word(off+8), deadline)
+	atomic.StoreInt64(m.word(off+16), kind)
+	atomic.StoreInt64(m.word(off+24), slotCheck(i, int64(x), deadline, kind))
+}
+
+// slotCheck returns the check word for a slot with the specified index and
+// contents. It is never zero.
+func slotCheck(i uint64, x, deadline, kind int64) int64 {
+	h := uint64(mappedSlot)
+	for _, w := range [...]uint64{i, uint64(x), uint64(deadline), uint64(kind)} {
+		h ^= w
+		h ^= h >> 33
+		h *= 0xff51afd7ed558ccd
+		h ^= h >> 33
+		h *= 0xc4ceb9fe1a85ec53
+		h ^= h >> 33
+	}
+	return int64(h | 1)
+}
+
+// entries calls f with each change recorded in the ring, oldest first,
+// skipping slots which weren't completely written, and entries which expire
+// by age but were below the expiry limit of the last expiry.
+func (m *MappedState) entries(f func(x Serial, e seenEntry, unset bool)) {
+	next := uint64(atomic.LoadInt64(m.word(mappedNextOff)))
+	horizon := Serial(atomic.LoadInt64(m.word(mappedHorizonOff)))
+	n := m.slots
+	if next < n {
+		n = next
+	}
+	for i := next - n; i < next; i++ {
+		off := mappedHeader + int(i%m.slots)*mappedSlot
+		x := atomic.LoadInt64(m.word(off))
+		deadline := atomic.LoadInt64(m.word(off + 8))
+		kind := atomic.LoadInt64(m.word(off + 16))
+		if atomic.LoadInt64(m.word(off+24)) != slotCheck(i, x, deadline, kind) {
+			continue
+		}
+		e := seenEntry{deadline: deadline}
+		switch {
+		case kind == mappedUnset:
+			f(Serial(x), e, true)
+		case kind == mappedSet && (deadline > 0 || e.key(Serial(x)) >= horizon):
+			f(Serial(x), e, false)
+		}
+	}
+}
+
+// Sync flushes the state to stable storage, so that it survives the machine
+// crashing.
+func (m *MappedState) Sync() error {
+	return syncMap(m.data)
+}
+
+// Close flushes the state to stable storage and closes the file. Generators
+// using the state must not be used after it is closed.
+func (m *MappedState) Close() error {
+	err := syncMap(m.data)
+	if uerr := unmapFile(m.data); err == nil {
+		err = uerr
+	}
+	if cerr := m.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// WithMappedState makes the generator record its watermark and the entries
+// it flags as seen in m, and recover from it when created. On recovery, the
+// generator starts after the watermark, and after the watermark's time plus
+// margin, which should cover the time between calls to Sync, in case the
+// machine crashed and the latest updates were lost. In counter mode, the
+// margin is ignored. The seen entries in the file which haven't outlived
+// their time to live, or been expired by age, are flagged as seen again, and
+// values unflagged since are unflagged again.
+//
+// Values leased from an Allocator aren't covered by the watermark, and
+// Generate128 values aren't recorded.
+func WithMappedState(m *MappedState, margin time.Duration) Option {
+	return func(g *Generator) error {
+		if m == nil || margin < 0 {
+			return ErrMapped
+		}
+		g.mapped = m
+		g.margin = margin
+		return nil
+	}
+}
+
+// loadMapped recovers the generator's state from its mapped state file, if
+// it has one.
+func (g *Generator) loadMapped() error {
+	m := g.mapped
+	if m == nil {
+		return nil
+	}
+	w := m.Watermark()
+	if err := g.checkStartup(w, 0); err != nil {
+		return err
+	}
+	if w > 0 {
+		g.raise(w)
+		if !g.counter {
+			g.raise(g.layout.pack(g.layout.ticks(g.layout.timeOf(w).Add(g.margin)), 0, 0))
+		}
+	}
+	now := g.clock.Now().UnixNano()
+	m.entries(func(x Serial, e seenEntry, unset bool) {
+		if unset {
+			g.store.remove(x, now)
+		} else if !e.dead(now) {
+			g.store.add(x, e, now)
+		}
+	})
+	return nil
+}
+
+// persist records x as the watermark in the generator's mapped state file,
+// if it has one.
+func (g *Generator) persist(x Serial) {
+	if g.mapped != nil {
+		g.mapped.raise(x)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !unix
+
+package serial
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile reports that memory mapping isn't supported.
+func mapFile(f *os.File, size int) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// unmapFile reports that memory mapping isn't supported.
+func unmapFile(b []byte) error {
+	return errors.ErrUnsupported
+}
+
+// syncMap reports that memory mapping isn't supported.
+func syncMap(b []byte) error {
+	return errors.ErrUnsupported
+}
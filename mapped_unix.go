@@ -0,0 +1,25 @@
+//go:build unix
+
+package serial
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mapFile maps the first size bytes of f into memory, shared with the file.
+func mapFile(f *os.File, size int) ([]byte, error) {
+	return unix.Mmap(int(f.Fd()), 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+}
+
+// unmapFile removes a mapping made by mapFile.
+func unmapFile(b []byte) error {
+	return unix.Munmap(b)
+}
+
+// syncMap writes a mapping back to its file and waits for it to reach
+// stable storage.
+func syncMap(b []byte) error {
+	return unix.Msync(b, unix.MS_SYNC)
+}
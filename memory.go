@@ -0,0 +1,56 @@
+package serial
+
+// Approximate bytes used by each entry in the in-memory histories, measured
+// on 64-bit platforms, including the maps' spare capacity and the expiry
+// index.
+const (
+	mapEntryBytes     = 92
+	boundedEntryBytes = 117
+)
+
+// MemoryEstimator is implemented by SeenStores which can estimate how much
+// memory the history they hold uses, for Generator.EstimatedMemory.
+type MemoryEstimator interface {
+	// EstimatedMemory returns the approximate number of bytes used.
+	EstimatedMemory() int64
+}
+
+// EstimatedMemory returns the approximate number of bytes used by the seen
+// history, for capacity planning. The estimate for the default history is
+// based on the number of values it holds, and doesn't include values attached
+// by SetSeenWithValue. For a history kept in Bloom filters, it is the size of
+// the filters. For a history kept in a SeenStore, it is the store's estimate
+// if it implements MemoryEstimator, or -1 if it doesn't.
+func (g *Generator) EstimatedMemory() int64 {
+	return g.store.memory()
+}
+
+// EstimatedMemory implements MemoryEstimator.
+func (ms MemoryStore) EstimatedMemory() int64 {
+	return ms.st.memory()
+}
+
+func (st *mapStore) memory() int64 {
+	return int64(st.len()) * mapEntryBytes
+}
+
+func (st *boundedStore) memory() int64 {
+	return int64(st.len()) * boundedEntryBytes
+}
+
+func (st *bloomStore) memory() int64 {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+	var n int64
+	for _, gen := range st.gens {
+		n += 8 * int64(len(gen.bits))
+	}
+	return n
+}
+
+func (st *externalStore) memory() int64 {
+	if me, ok := st.st.(MemoryEstimator); ok {
+		return me.EstimatedMemory()
+	}
+	return -1
+}
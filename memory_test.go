@@ -0,0 +1,37 @@
+package serial
+
+import "testing"
+
+// plainStore hides any methods of a SeenStore beyond the interface.
+type plainStore struct {
+	SeenStore
+}
+
+func TestEstimatedMemory(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		opts []Option
+		min  int64
+		max  int64
+	}{
+		{"map", nil, 1000 * 50, 1000 * 200},
+		{"bounded", []Option{WithMaxSeen(5000)}, 1000 * 50, 1000 * 200},
+		{"memory store", []Option{WithSeenStore(NewMemoryStore())}, 1000 * 50, 1000 * 200},
+		{"bloom", []Option{WithBloomFilter(1000, 0.01)}, 1000, 100000},
+	} {
+		g := NewGenerator(c.opts...)
+		if n := g.EstimatedMemory(); n != 0 && c.name != "bloom" {
+			t.Errorf("Expected empty %s history to use nothing, got %d", c.name, n)
+		}
+		for i := 0; i < 1000; i++ {
+			g.SetSeen(g.Generate())
+		}
+		if n := g.EstimatedMemory(); n < c.min || n > c.max {
+			t.Errorf("Expected %s estimate between %d and %d, got %d", c.name, c.min, c.max, n)
+		}
+	}
+	g := NewGenerator(WithSeenStore(plainStore{NewMemoryStore()}))
+	if n := g.EstimatedMemory(); n != -1 {
+		t.Errorf("Expected -1 for a store without an estimate, got %d", n)
+	}
+}
@@ -0,0 +1,127 @@
+package serial
+
+import (
+	"bytes"
+	"errors"
+	"hash/fnv"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNoNode is returned when none of the sources given to WithDerivedNode or
+// DeriveNode can supply a node ID.
+var ErrNoNode = errors.New("serial: no node ID available")
+
+// NodeSource derives a node ID no greater than max, for layouts with node
+// bits. It returns ErrNoNode if it has nothing to derive the ID from, so that
+// the next source can be tried.
+type NodeSource func(max int64) (int64, error)
+
+// NodeFromEnv returns a NodeSource which reads the node ID, in decimal, from
+// the environment variable with the specified name. An unset or empty
+// variable gives ErrNoNode, but a value which isn't a valid node ID for the
+// layout gives ErrNodeRange, so that a mistake in an explicit setting isn't
+// silently replaced by a derived ID.
+func NodeFromEnv(name string) NodeSource {
+	return func(max int64) (int64, error) {
+		s := strings.TrimSpace(os.Getenv(name))
+		if s == "" {
+			return 0, ErrNoNode
+		}
+		node, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || node < 0 || node > max {
+			return 0, ErrNodeRange
+		}
+		return node, nil
+	}
+}
+
+// NodeFromHostname returns a NodeSource which hashes the machine's host name
+// to a node ID. It suits containers and virtual machines whose host names are
+// unique, such as the pods of a Kubernetes StatefulSet.
+func NodeFromHostname() NodeSource {
+	return func(max int64) (int64, error) {
+		host, err := os.Hostname()
+		if err != nil || host == "" {
+			return 0, ErrNoNode
+		}
+		return hashNode([]byte(strings.ToLower(host)), max), nil
+	}
+}
+
+// NodeFromMAC returns a NodeSource which hashes the lowest hardware address
+// of the machine's network interfaces to a node ID, ignoring loopback
+// interfaces and all-zero addresses. Taking the lowest address means the ID
+// doesn't depend on the order in which interfaces are listed.
+func NodeFromMAC() NodeSource {
+	return func(max int64) (int64, error) {
+		ifs, err := net.Interfaces()
+		if err != nil {
+			return 0, ErrNoNode
+		}
+		var lowest net.HardwareAddr
+		for _, ifc := range ifs {
+			mac := ifc.HardwareAddr
+			if ifc.Flags&net.FlagLoopback != 0 || len(mac) == 0 || allZero(mac) {
+				continue
+			}
+			if lowest == nil || bytes.Compare(mac, lowest) < 0 {
+				lowest = mac
+			}
+		}
+		if lowest == nil {
+			return 0, ErrNoNode
+		}
+		return hashNode(lowest, max), nil
+	}
+}
+
+// DeriveNode tries each source in turn, and returns the first node ID found
+// which fits the layout. It returns ErrNoNode if no source has one, or the
+// error from a source which fails with any other error.
+//
+// Hashed IDs are stable across restarts, but two machines can hash to the
+// same ID, and the fewer node bits the layout has, the more likely that is.
+// An explicit setting, such as NodeFromEnv, should come first, so that
+// collisions can be resolved by hand.
+func DeriveNode(l Layout, sources ...NodeSource) (int64, error) {
+	for _, src := range sources {
+		node, err := src(l.maxNode())
+		if err == ErrNoNode {
+			continue
+		}
+		return node, err
+	}
+	return 0, ErrNoNode
+}
+
+// WithDerivedNode sets the node ID embedded in generated serial values to
+// one found by DeriveNode from the specified sources, using the generator's
+// final layout. New returns the error if no node ID can be derived. Like
+// other options, a later WithNode overrides it, and it overrides an earlier
+// WithNode.
+func WithDerivedNode(sources ...NodeSource) Option {
+	return func(g *Generator) error {
+		g.nodeSources = sources
+		return nil
+	}
+}
+
+// hashNode maps b to a node ID no greater than max.
+func hashNode(b []byte, max int64) int64 {
+	h := fnv.New64a()
+	h.Write(b)
+	return int64(h.Sum64() % (uint64(max) + 1))
+}
+
+// allZero reports whether every byte of b is zero.
+func allZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
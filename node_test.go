@@ -0,0 +1,54 @@
+package serial
+
+import "testing"
+
+func TestDeriveNode(t *testing.T) {
+	t.Setenv("SERIAL_TEST_NODE", "")
+	env := NodeFromEnv("SERIAL_TEST_NODE")
+	if _, err := DeriveNode(SnowflakeLayout, env); err != ErrNoNode {
+		t.Errorf("Expected ErrNoNode with no sources set, got %v", err)
+	}
+	for _, s := range []string{"x", "-1", "1024"} {
+		t.Setenv("SERIAL_TEST_NODE", s)
+		if _, err := DeriveNode(SnowflakeLayout, env, NodeFromHostname()); err != ErrNodeRange {
+			t.Errorf("Expected ErrNodeRange for %q got %v", s, err)
+		}
+	}
+	t.Setenv("SERIAL_TEST_NODE", " 1023 ")
+	if node, err := DeriveNode(SnowflakeLayout, env, NodeFromHostname()); err != nil || node != 1023 {
+		t.Errorf("Expected node 1023 got %d %v", node, err)
+	}
+	for _, src := range []NodeSource{NodeFromHostname(), NodeFromMAC()} {
+		a, err := DeriveNode(SnowflakeLayout, src)
+		if err == ErrNoNode {
+			continue
+		}
+		b, _ := DeriveNode(SnowflakeLayout, src)
+		if err != nil || a < 0 || a > 1023 || a != b {
+			t.Errorf("Expected a stable node ID in range, got %d, %d and %v", a, b, err)
+		}
+	}
+}
+
+func TestWithDerivedNode(t *testing.T) {
+	t.Setenv("SERIAL_TEST_NODE", "42")
+	env := NodeFromEnv("SERIAL_TEST_NODE")
+	g, err := New(WithDerivedNode(env), WithLayout(SnowflakeLayout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, node, _ := SnowflakeLayout.unpack(g.Generate()); node != 42 {
+		t.Errorf("Expected node 42 got %d", node)
+	}
+	g, err = New(WithLayout(SnowflakeLayout), WithDerivedNode(env), WithNode(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, node, _ := SnowflakeLayout.unpack(g.Generate()); node != 7 {
+		t.Errorf("Expected WithNode to override, got node %d", node)
+	}
+	t.Setenv("SERIAL_TEST_NODE", "")
+	if _, err := New(WithLayout(SnowflakeLayout), WithDerivedNode(env)); err != ErrNoNode {
+		t.Errorf("Expected ErrNoNode got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package nodelease
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// etcdKV is a KV backed by etcd.
+type etcdKV struct {
+	client *clientv3.Client
+}
+
+// Etcd returns a KV which uses the etcd client. Etcd counts times to live in
+// whole seconds, so they are rounded up.
+func Etcd(client *clientv3.Client) KV {
+	return etcdKV{client: client}
+}
+
+// Grant implements KV.
+func (kv etcdKV) Grant(ctx context.Context, ttl time.Duration) (LeaseID, error) {
+	secs := int64((ttl + time.Second - 1) / time.Second)
+	resp, err := kv.client.Grant(ctx, secs)
+	if err != nil {
+		return 0, err
+	}
+	return LeaseID(resp.ID), nil
+}
+
+// Create implements KV, using a transaction which only puts the key if it
+// has never been created, or has been deleted since.
+func (kv etcdKV) Create(ctx context.Context, key, value string, lease LeaseID) (bool, error) {
+	resp, err := kv.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, value, clientv3.WithLease(clientv3.LeaseID(lease)))).
+		Commit()
+	if err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+// KeepAlive implements KV.
+func (kv etcdKV) KeepAlive(ctx context.Context, lease LeaseID) error {
+	_, err := kv.client.KeepAliveOnce(ctx, clientv3.LeaseID(lease))
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return ErrLost
+	}
+	return err
+}
+
+// Revoke implements KV.
+func (kv etcdKV) Revoke(ctx context.Context, lease LeaseID) error {
+	_, err := kv.client.Revoke(ctx, clientv3.LeaseID(lease))
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return nil
+	}
+	return err
+}
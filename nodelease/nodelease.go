@@ -0,0 +1,192 @@
+// Package nodelease assigns node IDs to serial generators by leasing them
+// from a key-value store such as etcd, so that the replicas of an autoscaled
+// service can use a layout with node bits without configuring each one by
+// hand.
+//
+//	client, err := clientv3.New(clientv3.Config{Endpoints: []string{"localhost:2379"}})
+//	...
+//	lease, err := nodelease.Acquire(ctx, nodelease.Etcd(client), "/serial/orders/", serial.SnowflakeLayout, 30*time.Second, nil)
+//	...
+//	defer lease.Close()
+//	gen, err := serial.New(serial.WithLayout(serial.SnowflakeLayout), lease.Option())
+//	...
+//	defer gen.Close()
+//
+// Each node ID is a key under a prefix, attached to a lease with a time to
+// live. A replica claims the first free key, and keeps its lease alive in the
+// background until it is closed, when the lease is revoked and the node ID
+// freed. If the replica dies, the lease expires and the ID is freed after the
+// time to live.
+package nodelease
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lpar/serial"
+)
+
+var (
+	// ErrTTL is returned by Acquire when the time to live isn't positive.
+	ErrTTL = errors.New("nodelease: invalid time to live")
+	// ErrNoFreeNode is returned by Acquire when every node ID the layout
+	// allows is already leased.
+	ErrNoFreeNode = errors.New("nodelease: no free node ID")
+	// ErrLost is returned by KV.KeepAlive when the lease has already
+	// expired, and passed to the handler given to Acquire when a lease can't
+	// be renewed in time.
+	ErrLost = errors.New("nodelease: lease lost")
+)
+
+// LeaseID identifies a lease granted by a KV.
+type LeaseID int64
+
+// KV is a key-value store with leases: keys attached to a lease are deleted
+// when the lease is revoked, or expires because it hasn't been kept alive.
+// Etcd returns one backed by etcd.
+type KV interface {
+	// Grant creates a lease which expires after ttl unless kept alive.
+	Grant(ctx context.Context, ttl time.Duration) (LeaseID, error)
+	// Create sets key to value, attached to the lease, if the key doesn't
+	// exist, and reports whether it did so.
+	Create(ctx context.Context, key, value string, lease LeaseID) (bool, error)
+	// KeepAlive renews the lease once, for its full time to live. It returns
+	// ErrLost if the lease has expired.
+	KeepAlive(ctx context.Context, lease LeaseID) error
+	// Revoke ends the lease, deleting the keys attached to it.
+	Revoke(ctx context.Context, lease LeaseID) error
+}
+
+// Lease is a node ID leased from a KV.
+type Lease struct {
+	kv     KV
+	id     LeaseID
+	node   int64
+	ttl    time.Duration
+	onLost func(error)
+	lost   chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Acquire leases a node ID which fits the layout, as the key formed by
+// appending the ID in decimal to prefix, and starts a goroutine which renews
+// the lease at a third of the time to live until Close is called. Replicas
+// which share node IDs must use the same prefix. The key's value records the
+// host name and process ID of the holder, for operators.
+//
+// If the lease can't be renewed before it expires, another replica may take
+// the node ID, so the generator using it must stop issuing values: onLost is
+// called with the error, and the channel returned by Lost is closed. A
+// typical handler closes the generator. onLost may be nil.
+func Acquire(ctx context.Context, kv KV, prefix string, l serial.Layout, ttl time.Duration, onLost func(error)) (*Lease, error) {
+	if ttl <= 0 {
+		return nil, ErrTTL
+	}
+	id, err := kv.Grant(ctx, ttl)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	value := host + ":" + strconv.Itoa(os.Getpid())
+	n := int64(1) << l.NodeBits
+	// Starting at a random ID spreads out replicas starting together, so
+	// they don't all contend for the lowest free ID.
+	start := rand.Int64N(n)
+	for i := int64(0); i < n; i++ {
+		node := (start + i) % n
+		ok, err := kv.Create(ctx, prefix+strconv.FormatInt(node, 10), value, id)
+		if err != nil {
+			kv.Revoke(context.Background(), id)
+			return nil, err
+		}
+		if ok {
+			ls := &Lease{
+				kv:     kv,
+				id:     id,
+				node:   node,
+				ttl:    ttl,
+				onLost: onLost,
+				lost:   make(chan struct{}),
+				stop:   make(chan struct{}),
+				done:   make(chan struct{}),
+			}
+			go ls.renew()
+			return ls, nil
+		}
+	}
+	kv.Revoke(context.Background(), id)
+	return nil, ErrNoFreeNode
+}
+
+// Node returns the leased node ID.
+func (ls *Lease) Node() int64 {
+	return ls.node
+}
+
+// Option returns a serial.Option which sets the generator's node ID to the
+// leased one.
+func (ls *Lease) Option() serial.Option {
+	return serial.WithNode(ls.node)
+}
+
+// Lost returns a channel which is closed if the lease is lost.
+func (ls *Lease) Lost() <-chan struct{} {
+	return ls.lost
+}
+
+// renew keeps the lease alive until it is closed or lost. A failed renewal
+// is retried at the next interval, until the lease has gone a full time to
+// live without being renewed.
+func (ls *Lease) renew() {
+	defer close(ls.done)
+	interval := ls.ttl / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-ls.stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		err := ls.kv.KeepAlive(ctx, ls.id)
+		cancel()
+		if err == nil {
+			renewed = time.Now()
+			continue
+		}
+		if err == ErrLost || time.Since(renewed) >= ls.ttl {
+			if err != ErrLost {
+				err = errors.Join(ErrLost, err)
+			}
+			close(ls.lost)
+			if ls.onLost != nil {
+				ls.onLost(err)
+			}
+			return
+		}
+	}
+}
+
+// Close stops renewing the lease and revokes it, freeing the node ID. It
+// should be called after the generator using the ID has been closed, and is
+// safe to call more than once.
+func (ls *Lease) Close() error {
+	var err error
+	ls.once.Do(func() {
+		close(ls.stop)
+		<-ls.done
+		ctx, cancel := context.WithTimeout(context.Background(), ls.ttl)
+		defer cancel()
+		err = ls.kv.Revoke(ctx, ls.id)
+	})
+	return err
+}
@@ -0,0 +1,139 @@
+package nodelease
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lpar/serial"
+)
+
+// fakeKV is an in-memory KV whose leases never expire by themselves, but can
+// be made to fail renewal.
+type fakeKV struct {
+	mutex  sync.Mutex
+	next   LeaseID
+	keys   map[string]LeaseID
+	renews int
+	fail   error
+}
+
+func newFakeKV() *fakeKV {
+	return &fakeKV{keys: make(map[string]LeaseID)}
+}
+
+func (kv *fakeKV) Grant(ctx context.Context, ttl time.Duration) (LeaseID, error) {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+	kv.next++
+	return kv.next, nil
+}
+
+func (kv *fakeKV) Create(ctx context.Context, key, value string, lease LeaseID) (bool, error) {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+	if _, ok := kv.keys[key]; ok {
+		return false, nil
+	}
+	kv.keys[key] = lease
+	return true, nil
+}
+
+func (kv *fakeKV) KeepAlive(ctx context.Context, lease LeaseID) error {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+	kv.renews++
+	return kv.fail
+}
+
+func (kv *fakeKV) Revoke(ctx context.Context, lease LeaseID) error {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+	for k, id := range kv.keys {
+		if id == lease {
+			delete(kv.keys, k)
+		}
+	}
+	return nil
+}
+
+func TestAcquire(t *testing.T) {
+	kv := newFakeKV()
+	layout := serial.Layout{TimeBits: 61, NodeBits: 2}
+	ctx := context.Background()
+	if _, err := Acquire(ctx, kv, "/nodes/", layout, 0, nil); err != ErrTTL {
+		t.Errorf("Expected ErrTTL got %v", err)
+	}
+	nodes := make(map[int64]*Lease)
+	for i := 0; i < 4; i++ {
+		ls, err := Acquire(ctx, kv, "/nodes/", layout, time.Minute, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ls.Close()
+		if nodes[ls.Node()] != nil {
+			t.Fatalf("Node %d leased twice", ls.Node())
+		}
+		nodes[ls.Node()] = ls
+	}
+	if _, err := Acquire(ctx, kv, "/nodes/", layout, time.Minute, nil); err != ErrNoFreeNode {
+		t.Errorf("Expected ErrNoFreeNode got %v", err)
+	}
+	if err := nodes[2].Close(); err != nil {
+		t.Fatal(err)
+	}
+	ls, err := Acquire(ctx, kv, "/nodes/", layout, time.Minute, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ls.Close()
+	if ls.Node() != 2 {
+		t.Errorf("Expected freed node 2 got %d", ls.Node())
+	}
+	gen, err := serial.New(serial.WithLayout(serial.SnowflakeLayout), ls.Option())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node := gen.Decompose(gen.Generate()).Node; node != 2 {
+		t.Errorf("Expected node 2 in generated values, got %d", node)
+	}
+	for k := range kv.keys {
+		if !strings.HasPrefix(k, "/nodes/") {
+			t.Errorf("Unexpected key %q", k)
+		}
+	}
+}
+
+func TestRenew(t *testing.T) {
+	kv := newFakeKV()
+	lost := make(chan error, 1)
+	ls, err := Acquire(context.Background(), kv, "/nodes/", serial.SnowflakeLayout, 30*time.Millisecond, func(err error) { lost <- err })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ls.Close()
+	time.Sleep(100 * time.Millisecond)
+	kv.mutex.Lock()
+	renews := kv.renews
+	kv.fail = errors.New("etcd unavailable")
+	kv.mutex.Unlock()
+	if renews < 2 {
+		t.Errorf("Expected lease to be renewed, got %d renewals", renews)
+	}
+	select {
+	case err := <-lost:
+		if !errors.Is(err, ErrLost) {
+			t.Errorf("Expected ErrLost got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Lease wasn't reported lost")
+	}
+	select {
+	case <-ls.Lost():
+	default:
+		t.Error("Expected Lost channel to be closed")
+	}
+}
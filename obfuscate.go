@@ -0,0 +1,74 @@
+package serial
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrKey is returned when asked to create an Obfuscator or Signer with an
+// empty key.
+var ErrKey = errors.New("serial: empty key")
+
+// obfuscatorRounds is the number of Feistel rounds an Obfuscator uses.
+const obfuscatorRounds = 8
+
+// Obfuscator scrambles serial values with a keyed permutation of all 64 bit
+// values, so that serials shown outside the application don't reveal when
+// they were generated or how many have been issued, but can still be turned
+// back into the original values by anyone who has the key.
+//
+// The permutation is a Feistel network whose round keys are derived from the
+// key with SHA-256. It hides the structure of the values from casual
+// inspection, but isn't a cipher that has been analysed for security, so
+// it shouldn't be relied on to keep the values secret from a determined
+// attacker. An Obfuscator is safe for concurrent use.
+type Obfuscator struct {
+	keys [obfuscatorRounds]uint64
+}
+
+// NewObfuscator creates an Obfuscator using the specified secret key, which
+// should be at least 16 random bytes. It returns ErrKey if the key is empty.
+func NewObfuscator(key []byte) (*Obfuscator, error) {
+	if len(key) == 0 {
+		return nil, ErrKey
+	}
+	o := &Obfuscator{}
+	sum := sha256.Sum256(key)
+	for i := range o.keys {
+		if i%4 == 0 && i > 0 {
+			sum = sha256.Sum256(sum[:])
+		}
+		o.keys[i] = binary.BigEndian.Uint64(sum[i%4*8:])
+	}
+	return o, nil
+}
+
+// Obfuscate returns the scrambled form of x. Different values always give
+// different results.
+func (o *Obfuscator) Obfuscate(x Serial) Serial {
+	l, r := uint32(uint64(x)>>32), uint32(x)
+	for _, k := range o.keys {
+		l, r = r, l^round(r, k)
+	}
+	return Serial(uint64(l)<<32 | uint64(r))
+}
+
+// Reveal returns the serial value which Obfuscate scrambled to produce x.
+func (o *Obfuscator) Reveal(x Serial) Serial {
+	l, r := uint32(uint64(x)>>32), uint32(x)
+	for i := len(o.keys) - 1; i >= 0; i-- {
+		l, r = r^round(l, o.keys[i]), l
+	}
+	return Serial(uint64(l)<<32 | uint64(r))
+}
+
+// round is the Feistel round function, which mixes half a value with a round
+// key using the SplitMix64 finalizer.
+func round(r uint32, k uint64) uint32 {
+	z := uint64(r) ^ k
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	z ^= z >> 31
+	return uint32(z >> 32)
+}
@@ -0,0 +1,39 @@
+package serial
+
+import "testing"
+
+func TestObfuscator(t *testing.T) {
+	if _, err := NewObfuscator(nil); err != ErrKey {
+		t.Errorf("Expected ErrKey got %v", err)
+	}
+	o, err := NewObfuscator([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGenerator()
+	seen := make(map[Serial]bool)
+	prev := Serial(0)
+	ordered := 0
+	for i := 0; i < 1000; i++ {
+		x := g.Generate()
+		y := o.Obfuscate(x)
+		if seen[y] {
+			t.Fatalf("Obfuscated value %d repeated", y)
+		}
+		seen[y] = true
+		if y > prev {
+			ordered++
+		}
+		prev = y
+		if z := o.Reveal(y); z != x {
+			t.Fatalf("Expected %d got %d", x, z)
+		}
+	}
+	if ordered > 600 {
+		t.Errorf("Obfuscated values are still mostly ordered: %d of 1000", ordered)
+	}
+	other, _ := NewObfuscator([]byte("another key"))
+	if other.Obfuscate(12345) == o.Obfuscate(12345) {
+		t.Error("Different keys gave the same result")
+	}
+}
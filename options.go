@@ -0,0 +1,77 @@
+package serial
+
+import (
+	"time"
+)
+
+// Option configures a Generator created by New, NewGenerator or one of the
+// other constructors. Options are applied in order, so where two set the
+// same thing, the later one wins.
+type Option func(*Generator) error
+
+// WithLayout sets the layout of the serial values generated. The default is
+// NanosecondLayout. If the layout's Epoch is the zero time, any epoch set by
+// WithEpoch is kept.
+func WithLayout(l Layout) Option {
+	return func(g *Generator) error {
+		if err := l.check(); err != nil {
+			return err
+		}
+		if l.Epoch.IsZero() {
+			l.Epoch = g.layout.Epoch
+		}
+		g.layout = l
+		return nil
+	}
+}
+
+// WithEpoch sets the epoch from which the timestamps in generated serial
+// values are counted, in place of the Unix epoch. The epoch must be in the
+// past, according to the generator's clock, or New returns ErrEpoch.
+// Subtracting a recent epoch keeps serial values smaller and means they
+// don't directly reveal when they were generated.
+func WithEpoch(epoch time.Time) Option {
+	return func(g *Generator) error {
+		g.layout.Epoch = epoch
+		return nil
+	}
+}
+
+// WithNode sets the node ID embedded in generated serial values. It must fit
+// in the NodeBits of the generator's layout. Every generator running at the
+// same time with a layout which has node bits must have a different node ID.
+// WithDerivedNode can choose one automatically.
+func WithNode(node int64) Option {
+	return func(g *Generator) error {
+		g.node = node
+		g.nodeSources = nil
+		return nil
+	}
+}
+
+// WithResolution sets the resolution of the timestamps in generated serial
+// values, and reserves the specified number of bits below the timestamp for a
+// sequence number counting values generated within the same tick. The rest of
+// the layout set so far is kept, except that the timestamp takes up all the
+// remaining bits.
+//
+// A coarser resolution makes serial values smaller. For example, millisecond
+// resolution with 10 sequence bits allows over a million values per second,
+// and gives values which fit in the 53 bits JavaScript numbers can hold
+// exactly until the year 2248.
+func WithResolution(res time.Duration, seqBits uint) Option {
+	return func(g *Generator) error {
+		l := g.layout
+		l.Resolution = res
+		l.SequenceBits = seqBits
+		if seqBits+l.NodeBits >= 63 {
+			return ErrLayout
+		}
+		l.TimeBits = 63 - l.NodeBits - seqBits
+		if err := l.check(); err != nil {
+			return err
+		}
+		g.layout = l
+		return nil
+	}
+}
@@ -0,0 +1,63 @@
+package serial
+
+import "sort"
+
+// Before reports whether x comes before y. Each generator issues values in
+// strictly ascending order, so for two values from the same generator, x was
+// generated before y. Values from different generators with the same layout
+// and epoch are ordered by their embedded times, to within the generators'
+// resolution and however far their clocks disagree; within the same tick,
+// values with node IDs are ordered by node ID rather than time.
+func (x Serial) Before(y Serial) bool {
+	return x < y
+}
+
+// After reports whether x comes after y, in the order described for Before.
+func (x Serial) After(y Serial) bool {
+	return x > y
+}
+
+// Compare returns -1 if x comes before y, 0 if they are the same value, and
+// +1 if x comes after y, in the order described for Before. It suits
+// slices.SortFunc.
+func (x Serial) Compare(y Serial) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// Len implements sort.Interface.
+func (xs Serials) Len() int {
+	return len(xs)
+}
+
+// Less implements sort.Interface.
+func (xs Serials) Less(i, j int) bool {
+	return xs[i] < xs[j]
+}
+
+// Swap implements sort.Interface.
+func (xs Serials) Swap(i, j int) {
+	xs[i], xs[j] = xs[j], xs[i]
+}
+
+// Sort sorts the values into ascending order, which for values from a single
+// generator is the order in which they were generated.
+func (xs Serials) Sort() {
+	sort.Sort(xs)
+}
+
+// IsSorted reports whether the values are in ascending order.
+func (xs Serials) IsSorted() bool {
+	return sort.IsSorted(xs)
+}
+
+// Search returns the index of x in the sorted values, or the index at which
+// it would be inserted if it isn't present.
+func (xs Serials) Search(x Serial) int {
+	return sort.Search(len(xs), func(i int) bool { return xs[i] >= x })
+}
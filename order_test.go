@@ -0,0 +1,37 @@
+package serial
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	x, y := gen.Generate(), gen.Generate()
+	if !x.Before(y) || x.After(y) || y.Before(x) || !y.After(x) {
+		t.Errorf("Expected %d before %d", x, y)
+	}
+	if x.Compare(y) != -1 || y.Compare(x) != 1 || x.Compare(x) != 0 {
+		t.Errorf("Wrong comparison of %d and %d", x, y)
+	}
+}
+
+func TestSortSerials(t *testing.T) {
+	xs := Serials(gen.GenerateN(100))
+	want := append(Serials(nil), xs...)
+	if !xs.IsSorted() {
+		t.Fatal("Expected generated values to be sorted")
+	}
+	for i := range xs {
+		j := (i * 37) % len(xs)
+		xs[i], xs[j] = xs[j], xs[i]
+	}
+	xs.Sort()
+	for i := range xs {
+		if xs[i] != want[i] {
+			t.Fatalf("Expected %d at %d got %d", want[i], i, xs[i])
+		}
+	}
+	if i := xs.Search(want[40]); i != 40 {
+		t.Errorf("Expected 40 got %d", i)
+	}
+	if i := xs.Search(want[99] + 1); i != 100 {
+		t.Errorf("Expected 100 got %d", i)
+	}
+}
@@ -0,0 +1,61 @@
+package serial
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrOverflow is returned by TryGenerate when the next value would
+	// overflow the generator's layout, which would otherwise wrap around to
+	// negative or repeated values.
+	ErrOverflow = errors.New("serial: serial values exhausted")
+	// ErrSkew is returned by TryGenerate when the next value's embedded time
+	// would be further ahead of the clock than the generator allows.
+	ErrSkew = errors.New("serial: serial values too far ahead of the clock")
+)
+
+// WithMaxSkew limits how far the embedded times of generated values can run
+// ahead of the clock, as happens when values are generated faster than the
+// clock ticks, or after the clock moves backwards under the
+// RollbackIncrement policy. If f is nil, a value which would be more than max
+// ahead isn't issued: TryGenerate returns ErrSkew, and Generate, Fill and
+// ReserveBlock panic with it. Otherwise the value is issued, and f is called
+// with how far ahead it is, after the generator's locks have been released.
+//
+// Values leased with WithAllocator aren't checked.
+func WithMaxSkew(max time.Duration, f func(time.Duration)) Option {
+	return func(g *Generator) error {
+		g.maxSkew = max
+		g.onSkew = f
+		return nil
+	}
+}
+
+// check verifies that x, the last of a run of values generated at the time
+// now to follow last, hasn't overflowed the layout, and isn't too far ahead
+// of the clock. It returns how far ahead x is if that's too far.
+func (g *Generator) check(last, x Serial, now time.Time) (time.Duration, error) {
+	if x <= last || uint64(x)>>(g.layout.TimeBits+g.layout.NodeBits+g.layout.SequenceBits) != 0 {
+		return 0, ErrOverflow
+	}
+	if g.maxSkew <= 0 || g.counter {
+		return 0, nil
+	}
+	skew := g.layout.timeOf(x).Sub(now)
+	if skew <= g.maxSkew {
+		return 0, nil
+	}
+	if g.onSkew == nil {
+		return skew, ErrSkew
+	}
+	return skew, nil
+}
+
+// skewed calls the skew handler, if values have run too far ahead of the
+// clock.
+func (g *Generator) skewed(skew time.Duration) {
+	if skew > 0 && g.onSkew != nil {
+		g.onSkew(skew)
+	}
+}
@@ -0,0 +1,56 @@
+package serial
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestOverflow(t *testing.T) {
+	g := NewDeterministicGenerator(math.MaxInt64 - 1)
+	if x := g.Generate(); x != math.MaxInt64-1 {
+		t.Fatalf("Expected %d got %d", int64(math.MaxInt64-1), x)
+	}
+	if x := g.Generate(); x != math.MaxInt64 {
+		t.Fatalf("Expected %d got %d", int64(math.MaxInt64), x)
+	}
+	if x, err := g.TryGenerate(); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow got %d, %v", x, err)
+	}
+	l := Layout{TimeBits: 10, SequenceBits: 2, Resolution: time.Second}
+	clk := &fakeClock{now: time.Unix(1020, 0)}
+	g = NewGenerator(WithClock(clk), WithLayout(l))
+	if _, err := g.TryGenerate(); err != nil {
+		t.Fatal(err)
+	}
+	clk.Advance(4 * time.Second)
+	if x, err := g.TryGenerate(); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow past the layout's time bits, got %d, %v", x, err)
+	}
+	func() {
+		defer func() {
+			if recover() != ErrOverflow {
+				t.Error("Expected ReserveBlock to panic with ErrOverflow")
+			}
+		}()
+		NewDeterministicGenerator(math.MaxInt64 - 10).ReserveBlock(20)
+	}()
+}
+
+func TestMaxSkew(t *testing.T) {
+	start := time.Unix(1000, 0)
+	g := NewGenerator(WithClock(fixedClock(start)), WithMaxSkew(10*time.Nanosecond, nil))
+	g.GenerateN(10)
+	if _, err := g.TryGenerate(); err != nil {
+		t.Errorf("Unexpected error at the limit: %v", err)
+	}
+	if _, err := g.TryGenerate(); err != ErrSkew {
+		t.Errorf("Expected ErrSkew got %v", err)
+	}
+	var got time.Duration
+	g = NewGenerator(WithClock(fixedClock(start)), WithMaxSkew(10*time.Nanosecond, func(d time.Duration) { got = d }))
+	g.GenerateN(12)
+	if x := g.Generate(); x != Serial(start.UnixNano())+12 || got != 12 {
+		t.Errorf("Expected value 12ns ahead and handler called, got %d and %v", x, got)
+	}
+}
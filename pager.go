@@ -0,0 +1,90 @@
+package serial
+
+import (
+	"sort"
+	"sync"
+)
+
+// Pager hands out contiguous pages of serial values from a Generator, for
+// clients which claim a batch of IDs from a server and then allocate from
+// it locally without further calls (the classic hi/lo pattern).
+//
+// A Pager can optionally keep track of which pages are outstanding, so that
+// the server can audit them. Pages are then tracked until they are passed to
+// Release.
+type Pager struct {
+	gen         *Generator
+	track       bool
+	mutex       sync.Mutex
+	outstanding map[Serial]Range
+}
+
+// NewPager creates a Pager which reserves pages from the specified generator.
+// If track is true, the Pager records each page it hands out until it is
+// released.
+func NewPager(gen *Generator, track bool) *Pager {
+	p := &Pager{gen: gen, track: track}
+	if track {
+		p.outstanding = make(map[Serial]Range)
+	}
+	return p
+}
+
+// Acquire returns a fresh page of the specified size. It panics if size is
+// less than 1, or in the other circumstances in which the generator's
+// ReserveBlock panics, including with ErrClosed once it has been closed; use
+// Lease to handle errors.
+func (p *Pager) Acquire(size int) Range {
+	r, err := p.acquire(size)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// acquire reserves a page for Acquire and Lease.
+func (p *Pager) acquire(size int) (Range, error) {
+	r, err := p.gen.reserve(size)
+	if err != nil {
+		return Range{}, err
+	}
+	if p.track {
+		p.mutex.Lock()
+		p.outstanding[r.First] = r
+		p.mutex.Unlock()
+	}
+	return r, nil
+}
+
+// Release marks a page as no longer outstanding, and reports whether it was
+// being tracked. Released values are never handed out again.
+func (p *Pager) Release(r Range) bool {
+	if !p.track {
+		return false
+	}
+	p.mutex.Lock()
+	or, ok := p.outstanding[r.First]
+	ok = ok && or == r
+	if ok {
+		delete(p.outstanding, r.First)
+	}
+	p.mutex.Unlock()
+	return ok
+}
+
+// Outstanding returns the pages which have been acquired but not yet
+// released, in order. It always returns nil if the Pager isn't tracking
+// pages.
+func (p *Pager) Outstanding() []Range {
+	if !p.track {
+		return nil
+	}
+	p.mutex.Lock()
+	rs := make([]Range, 0, len(p.outstanding))
+	for _, r := range p.outstanding {
+		rs = append(rs, r)
+	}
+	p.mutex.Unlock()
+	sort.Slice(rs, func(i, j int) bool { return rs[i].First < rs[j].First })
+	return rs
+}
@@ -0,0 +1,35 @@
+package serial
+
+import (
+	"testing"
+)
+
+func TestPager(t *testing.T) {
+	g := NewGenerator()
+	p := NewPager(g, true)
+	r1 := p.Acquire(10)
+	if r1.Len() != 10 {
+		t.Errorf("Wrong page size, expected 10 got %d", r1.Len())
+	}
+	r2 := p.Acquire(5)
+	if r2.First <= r1.Last {
+		t.Errorf("Pages overlap: %v and %v", r1, r2)
+	}
+	if n := g.Generate(); n <= r2.Last {
+		t.Errorf("Generated %d from inside page %v", n, r2)
+	}
+	out := p.Outstanding()
+	if len(out) != 2 || out[0] != r1 || out[1] != r2 {
+		t.Errorf("Wrong outstanding pages, got %v", out)
+	}
+	if !p.Release(r1) {
+		t.Error("Failed to release outstanding page")
+	}
+	if p.Release(r1) {
+		t.Error("Released the same page twice")
+	}
+	out = p.Outstanding()
+	if len(out) != 1 || out[0] != r2 {
+		t.Errorf("Wrong outstanding pages after release, got %v", out)
+	}
+}
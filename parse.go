@@ -0,0 +1,127 @@
+package serial
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidHex is returned when parsing a string which isn't a valid
+// hexadecimal serial value.
+var ErrInvalidHex = errors.New("serial: invalid hexadecimal serial")
+
+// ErrUnknownFormat is returned by Parse when a string isn't in any of the
+// formats it accepts.
+var ErrUnknownFormat = errors.New("serial: unrecognized serial format")
+
+// ParseError records a failure to parse a serial value with Parse. Err is
+// one of the package's sentinel errors, such as ErrInvalidBase62 or
+// ErrChecksum, so callers can use errors.Is to find out what went wrong.
+type ParseError struct {
+	Input  string
+	Format string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return "serial: parsing " + strconv.Quote(e.Input) + " as " + e.Format + ": " +
+		strings.TrimPrefix(e.Err.Error(), "serial: ")
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Parse parses a serial value in any of the forms produced by String, Hex,
+// Base32, Base32Check, Base62 and Key, and the ULIDs and UUIDs produced by a
+// generator or ToUUID. The format is chosen as follows:
+//
+//   - with a 0x or 0X prefix, the rest is read as hexadecimal;
+//   - a string of only decimal digits is read as decimal;
+//   - 36 characters are read as a UUID, and 26 as a ULID;
+//   - 13 or 14 symbols, ignoring hyphens, are read as Crockford's Base32,
+//     with a check symbol in the 14 symbol case;
+//   - anything else of up to 11 characters is read as Base62.
+//
+// A Base32 value consisting only of digits will therefore be read as decimal;
+// use ParseBase32 when the format is known. Values with check digits from
+// Luhn or Damm, custom formats from Format, and prefixed or short codes have
+// their own parsers, and aren't recognized; in particular, a value with a
+// check digit is read as plain decimal. Errors are of type *ParseError.
+func Parse(s string) (Serial, error) {
+	var (
+		x      Serial
+		err    error
+		format string
+	)
+	switch {
+	case strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X"):
+		format = "hexadecimal"
+		x, err = parseHex(s[2:])
+	case isDecimal(s):
+		format = "decimal"
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 64)
+		x = Serial(n)
+		if err != nil {
+			err = ErrInvalidDecimal
+		}
+	case len(s) == 36:
+		format = "UUID"
+		var u UUID
+		if u, err = ParseUUID(s); err == nil {
+			x = u.Serial()
+		}
+	case len(s) == 26:
+		format = "ULID"
+		var u ULID
+		if u, err = ParseULID(s); err == nil {
+			x = u.Serial()
+		}
+	case len(strings.Replace(s, "-", "", -1)) == 13:
+		format = "base32"
+		x, err = ParseBase32(s)
+	case len(strings.Replace(s, "-", "", -1)) == 14:
+		format = "base32"
+		x, err = ParseBase32Check(s)
+	case len(s) <= 11:
+		format = "base62"
+		x, err = ParseBase62(s)
+	default:
+		format = "serial"
+		err = ErrUnknownFormat
+	}
+	if err != nil {
+		return 0, &ParseError{Input: s, Format: format, Err: err}
+	}
+	return x, nil
+}
+
+// Hex returns the serial value in hexadecimal with a 0x prefix, as accepted
+// by Parse.
+func (x Serial) Hex() string {
+	return "0x" + strconv.FormatUint(uint64(x), 16)
+}
+
+// parseHex parses hexadecimal digits as a serial value.
+func parseHex(s string) (Serial, error) {
+	n, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, ErrInvalidHex
+	}
+	return Serial(n), nil
+}
+
+// isDecimal reports whether s is a non-empty string of decimal digits.
+func isDecimal(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package serial
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	x := gen.Generate()
+	text, _ := x.MarshalText()
+	for _, s := range []string{
+		x.String(), string(text), x.Hex(), "0X" + x.Hex()[2:], x.Base32(), x.Base32Check(), x.Base62(), x.Key(),
+		gen.ULID(x).String(), gen.UUIDv7(x).String(), ToUUID(x).String(),
+	} {
+		y, err := Parse(s)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", s, err)
+		} else if y != x {
+			t.Errorf("Wrong value from %q, expected %d got %d", s, x, y)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	bad := Serial(123456789).Base32Check()
+	bad = bad[:13] + "Z"
+	for _, c := range []struct {
+		s   string
+		err error
+	}{
+		{"", ErrInvalidBase62},
+		{"0x", ErrInvalidHex},
+		{"0x12g", ErrInvalidHex},
+		{"99999999999999999999", ErrInvalidDecimal},
+		{bad, ErrChecksum},
+		{"abc$", ErrInvalidBase62},
+		{"abcdefghijkl", ErrUnknownFormat},
+		{"zzzzzzzzzzzzzzzzzzzzzzzzzz", ErrInvalidULID},
+		{"00000000-0000-4000-8000-000000000000", ErrInvalidUUID},
+	} {
+		_, err := Parse(c.s)
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("Expected a ParseError for %q, got %v", c.s, err)
+			continue
+		}
+		if perr.Input != c.s || !errors.Is(err, c.err) {
+			t.Errorf("Wrong error for %q, expected %v got %v", c.s, c.err, err)
+		}
+	}
+}
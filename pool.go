@@ -0,0 +1,102 @@
+package serial
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPool is returned when asked to create a generator with a pool size less
+// than 1.
+var ErrPool = errors.New("serial: invalid pool size")
+
+// poolRetry is how long the goroutine filling a pool waits after failing to
+// generate a value before trying again.
+const poolRetry = time.Millisecond
+
+// pool holds values generated ahead of time by a background goroutine.
+type pool struct {
+	ch     chan Serial
+	once   sync.Once
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// WithPool keeps a pool of up to size values generated ahead of time by a
+// background goroutine, so that during a burst of requests, Generate,
+// TryGenerate and GenerateCtx can usually return a value by taking it from
+// the pool, without reading the clock or contending with other callers. When
+// the pool is empty, values are generated as usual. The goroutine starts
+// when the first value is requested, and runs until Close is called; any
+// values left in the pool are then discarded, leaving a gap.
+//
+// The price is that values come from the pool in the order they were
+// generated, so a value taken from the pool may be lower than one the same
+// caller was just given directly, and the time embedded in it is the time it
+// was generated, which may be well before it is returned. Rate limits and
+// quotas are still applied as values are taken, but OnGenerate hooks and
+// events are triggered as values are added to the pool, by the background
+// goroutine. Anything which moves the generator on, such as Restore or
+// Observe, should be done before the first value is requested, since values
+// already in the pool aren't affected.
+func WithPool(size int) Option {
+	return func(g *Generator) error {
+		if size < 1 {
+			return ErrPool
+		}
+		g.pool = &pool{ch: make(chan Serial, size)}
+		return nil
+	}
+}
+
+// take returns a value from the pool, if there is one, starting the
+// goroutine which fills it if necessary.
+func (p *pool) take(g *Generator) (Serial, bool) {
+	p.once.Do(func() {
+		var ctx context.Context
+		ctx, p.cancel = context.WithCancel(context.Background())
+		p.done = make(chan struct{})
+		go p.fill(ctx, g)
+	})
+	select {
+	case x := <-p.ch:
+		return x, true
+	default:
+		return 0, false
+	}
+}
+
+// fill keeps the pool full until the context is cancelled. If a value can't
+// be generated, as when the clock has moved backwards under the
+// RollbackError policy, it tries again shortly, leaving callers to generate
+// values directly and receive the error meanwhile.
+func (p *pool) fill(ctx context.Context, g *Generator) {
+	defer close(p.done)
+	for {
+		x, err := g.generate(ctx)
+		if err != nil {
+			if sleep(ctx, poolRetry) != nil {
+				return
+			}
+			continue
+		}
+		select {
+		case p.ch <- x:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// stop tells the goroutine filling the pool to stop, if it was started, and
+// prevents it from starting. It returns a channel which is closed once the
+// goroutine has finished, or nil if it never started.
+func (p *pool) stop() <-chan struct{} {
+	p.once.Do(func() {})
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+	return p.done
+}
@@ -0,0 +1,77 @@
+package serial
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	if _, err := New(WithPool(0)); err != ErrPool {
+		t.Errorf("Expected ErrPool got %v", err)
+	}
+	g := NewGenerator(WithPool(100))
+	first := g.Generate()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(g.pool.ch) < cap(g.pool.ch) {
+		if time.Now().After(deadline) {
+			t.Fatal("Pool wasn't filled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	last := g.Last()
+	if x := g.Generate(); x <= first || x >= last {
+		t.Errorf("Expected a pooled value between %d and %d, got %d", first, last, x)
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	seen := make(map[Serial]bool)
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				x := g.Generate()
+				mutex.Lock()
+				if seen[x] {
+					t.Errorf("Value %d issued twice", x)
+				}
+				seen[x] = true
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.TryGenerate(); err != ErrClosed {
+		t.Errorf("Expected ErrClosed got %v", err)
+	}
+}
+
+func TestPoolQuota(t *testing.T) {
+	g := NewGenerator(WithPool(10), WithIssueQuota(5, time.Hour))
+	defer g.Close()
+	for i := 0; i < 5; i++ {
+		if _, err := g.TryGenerate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var qe *QuotaError
+	if _, err := g.TryGenerate(); !errors.As(err, &qe) {
+		t.Errorf("Expected *QuotaError from pooled generator, got %v", err)
+	}
+}
+
+func TestPoolNeverStarted(t *testing.T) {
+	g := NewGenerator(WithPool(10))
+	if err := g.Close(); err != nil {
+		t.Errorf("Close returned %v", err)
+	}
+	if g.pool.cancel != nil {
+		t.Error("Expected pool not to start after Close")
+	}
+}
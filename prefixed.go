@@ -0,0 +1,64 @@
+package serial
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrPrefix is returned when a prefix for a PrefixedGenerator is invalid, or
+// when parsing an identifier which doesn't have the expected prefix.
+var ErrPrefix = errors.New("serial: invalid prefix")
+
+// PrefixedGenerator generates identifiers which combine a resource prefix
+// with a Base62 encoded serial value, separated by an underscore, such as
+// "ord_1h5k3j9Qx2". Identifiers like these are self-describing when they
+// appear in logs or public APIs, and can't be mixed up between resource
+// types.
+type PrefixedGenerator struct {
+	gen    *Generator
+	prefix string
+}
+
+// NewPrefixedGenerator creates a PrefixedGenerator which generates serial
+// values from gen, and prefixes them with prefix. The prefix must start with
+// a lower case letter, and consist only of lower case letters and digits.
+func NewPrefixedGenerator(gen *Generator, prefix string) (*PrefixedGenerator, error) {
+	if prefix == "" || prefix[0] < 'a' || prefix[0] > 'z' {
+		return nil, ErrPrefix
+	}
+	for i := 1; i < len(prefix); i++ {
+		c := prefix[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return nil, ErrPrefix
+		}
+	}
+	return &PrefixedGenerator{gen: gen, prefix: prefix}, nil
+}
+
+// Prefix returns the generator's prefix.
+func (p *PrefixedGenerator) Prefix() string {
+	return p.prefix
+}
+
+// Generate generates a new serial value, and returns it as a prefixed
+// identifier. It panics in the same circumstances as Generator.Generate,
+// including once the generator has been closed; to handle errors, pass a
+// value from the generator's TryGenerate to Format.
+func (p *PrefixedGenerator) Generate() string {
+	return p.Format(p.gen.Generate())
+}
+
+// Format returns the prefixed identifier for a serial value.
+func (p *PrefixedGenerator) Format(x Serial) string {
+	return p.prefix + "_" + x.Base62()
+}
+
+// Parse recovers the serial value from a prefixed identifier. It returns
+// ErrPrefix if the identifier doesn't start with the generator's prefix, or
+// ErrInvalidBase62 if the rest of it isn't a valid encoded serial.
+func (p *PrefixedGenerator) Parse(id string) (Serial, error) {
+	if !strings.HasPrefix(id, p.prefix+"_") {
+		return 0, ErrPrefix
+	}
+	return ParseBase62(id[len(p.prefix)+1:])
+}
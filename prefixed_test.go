@@ -0,0 +1,39 @@
+package serial
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrefixedGenerator(t *testing.T) {
+	for _, prefix := range []string{"", "Ord", "1ord", "or_d"} {
+		if _, err := NewPrefixedGenerator(gen, prefix); err != ErrPrefix {
+			t.Errorf("Expected ErrPrefix for %q, got %v", prefix, err)
+		}
+	}
+	orders, err := NewPrefixedGenerator(gen, "ord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := NewPrefixedGenerator(gen, "user2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := orders.Generate()
+	if !strings.HasPrefix(id, "ord_") {
+		t.Errorf("Identifier %q is missing its prefix", id)
+	}
+	x, err := orders.Parse(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orders.Format(x) != id {
+		t.Errorf("Round trip failed, expected %q got %q", id, orders.Format(x))
+	}
+	if _, err := users.Parse(id); err != ErrPrefix {
+		t.Errorf("Expected ErrPrefix parsing %q as a user, got %v", id, err)
+	}
+	if _, err := orders.Parse("ord_!"); err != ErrInvalidBase62 {
+		t.Errorf("Expected ErrInvalidBase62, got %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package serial
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrQuota is returned when asked to create a generator with a quota less
+// than 1, or an issue quota with a window of less than a nanosecond.
+var ErrQuota = errors.New("serial: invalid quota")
+
+// ErrQuotaExceeded is matched by errors.Is for any *QuotaError.
+var ErrQuotaExceeded = errors.New("serial: quota exceeded")
+
+// QuotaError records that a generator's quota has been reached. Quota is
+// either "seen" or "issued", according to which quota it was.
+type QuotaError struct {
+	Quota string
+	Limit int
+}
+
+func (e *QuotaError) Error() string {
+	return "serial: " + e.Quota + " quota of " + strconv.Itoa(e.Limit) + " exceeded"
+}
+
+// Is reports whether target is ErrQuotaExceeded.
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// quota holds a generator's quotas and the state needed to enforce them.
+type quota struct {
+	mutex     sync.Mutex
+	maxSeen   int
+	maxIssued int
+	window    int64
+	start     int64
+	issued    int
+}
+
+// getQuota returns the generator's quota, creating it if necessary.
+func (g *Generator) getQuota() *quota {
+	if g.quota == nil {
+		g.quota = &quota{}
+	}
+	return g.quota
+}
+
+// WithSeenQuota limits the seen history to max values, so that a generator
+// can put a hard cap on the one-time values outstanding in a domain, such as
+// the invite codes for a campaign. Once the history holds max values,
+// flagging another one as seen fails with a *QuotaError until some are
+// removed by ExpireSeen or UnsetSeen: TrySetSeen returns the error, and the
+// other methods which flag values as seen panic with it. Values whose time
+// to live has run out count towards the quota until they are removed.
+//
+// Unlike WithMaxSeen, nothing is evicted to make room. Flagging values as
+// seen is serialized while a seen quota is set.
+func WithSeenQuota(max int) Option {
+	return func(g *Generator) error {
+		if max < 1 {
+			return ErrQuota
+		}
+		g.getQuota().maxSeen = max
+		return nil
+	}
+}
+
+// WithIssueQuota limits the generator to issuing max values in each window
+// of the specified length, with windows aligned as per time.Time.Truncate.
+// Once the quota for a window is used up, TryGenerate returns a *QuotaError
+// until the next window starts, and Generate, Fill and ReserveBlock panic
+// with it. Each value in a batch from Fill or a block from ReserveBlock
+// counts towards the quota.
+func WithIssueQuota(max int, window time.Duration) Option {
+	return func(g *Generator) error {
+		if max < 1 || window < 1 {
+			return ErrQuota
+		}
+		q := g.getQuota()
+		q.maxIssued = max
+		q.window = int64(window)
+		return nil
+	}
+}
+
+// issue charges n values against the issue quota at time now.
+func (q *quota) issue(n int, now int64) error {
+	if q.maxIssued == 0 {
+		return nil
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if start := now - now%q.window; start != q.start {
+		q.start = start
+		q.issued = 0
+	}
+	if q.issued+n > q.maxIssued {
+		return &QuotaError{Quota: "issued", Limit: q.maxIssued}
+	}
+	q.issued += n
+	return nil
+}
+
+// refund gives back n values charged by issue, unless the window they were
+// charged in has ended since.
+func (q *quota) refund(n int, now int64) {
+	if q.maxIssued == 0 {
+		return
+	}
+	q.mutex.Lock()
+	if now-now%q.window == q.start {
+		q.issued -= n
+		if q.issued < 0 {
+			q.issued = 0
+		}
+	}
+	q.mutex.Unlock()
+}
+
+// addSeen adds x to the seen history, enforcing the seen quota if there is
+// one, and reports whether it wasn't already there.
+func (g *Generator) addSeen(x Serial, e seenEntry, now int64) (bool, error) {
+	if g.counter && e.deadline == 0 {
+		e.deadline = -now
+	}
+	g.snapMutex.RLock()
+	defer g.snapMutex.RUnlock()
+	if g.quota == nil || g.quota.maxSeen == 0 {
+		return g.stored(x, e, now), nil
+	}
+	q := g.quota
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if old, ok := g.store.has(x); ok && !old.dead(now) {
+		return false, nil
+	}
+	if g.store.len() >= q.maxSeen {
+		return false, &QuotaError{Quota: "seen", Limit: q.maxSeen}
+	}
+	return g.stored(x, e, now), nil
+}
+
+// stored adds x to the history, and records it in the generator's mapped
+// state file if it was added.
+func (g *Generator) stored(x Serial, e seenEntry, now int64) bool {
+	added := g.store.add(x, e, now)
+	if added && g.mapped != nil {
+		g.mapped.record(x, e)
+	}
+	return added
+}
+
+// TrySetSeen flags the specified Serial value as having been seen, as for
+// SetSeen, but returns a *QuotaError instead of panicking if the generator's
+// seen quota has been reached.
+func (g *Generator) TrySetSeen(x Serial) error {
+	added, err := g.addSeen(x, seenEntry{}, g.clock.Now().UnixNano())
+	if added {
+		g.flagged(x, seenEntry{})
+	}
+	return err
+}
+
+// TrySeenOrSet is like SeenOrSet, but returns a *QuotaError instead of
+// panicking if the value hasn't been seen and the generator's seen quota has
+// been reached.
+func (g *Generator) TrySeenOrSet(x Serial) (bool, error) {
+	added, err := g.addSeen(x, seenEntry{}, g.clock.Now().UnixNano())
+	if err != nil {
+		return false, err
+	}
+	if added {
+		g.flagged(x, seenEntry{})
+	}
+	return !added, nil
+}
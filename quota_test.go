@@ -0,0 +1,76 @@
+package serial
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSeenQuota(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk), WithSeenQuota(2))
+	x, y, z := g.Generate(), g.Generate(), g.Generate()
+	if err := g.TrySetSeen(x); err != nil {
+		t.Fatal(err)
+	}
+	if seen, err := g.TrySeenOrSet(y); seen || err != nil {
+		t.Fatalf("Expected false, nil got %v, %v", seen, err)
+	}
+	if seen, err := g.TrySeenOrSet(y); !seen || err != nil {
+		t.Errorf("Expected an already seen value not to count, got %v, %v", seen, err)
+	}
+	err := g.TrySetSeen(z)
+	var qe *QuotaError
+	if !errors.As(err, &qe) || qe.Quota != "seen" || qe.Limit != 2 || !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Expected seen QuotaError got %v", err)
+	}
+	if g.Seen(z) {
+		t.Error("Value over quota was flagged as seen")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected SetSeen to panic over quota")
+			}
+		}()
+		g.SetSeen(z)
+	}()
+	g.UnsetSeen(x)
+	if err := g.TrySetSeen(z); err != nil {
+		t.Errorf("Expected room after UnsetSeen, got %v", err)
+	}
+}
+
+func TestIssueQuota(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk), WithIssueQuota(5, time.Minute))
+	g.GenerateN(4)
+	if _, err := g.TryGenerate(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := g.TryGenerate()
+	var qe *QuotaError
+	if !errors.As(err, &qe) || qe.Quota != "issued" || qe.Limit != 5 {
+		t.Fatalf("Expected issued QuotaError got %v", err)
+	}
+	clk.Advance(time.Minute)
+	if _, err := g.TryGenerate(); err != nil {
+		t.Errorf("Expected quota to reset in the next window, got %v", err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected ReserveBlock to panic over quota")
+			}
+		}()
+		g.ReserveBlock(5)
+	}()
+}
+
+func TestQuotaInvalid(t *testing.T) {
+	for _, opt := range []Option{WithSeenQuota(0), WithIssueQuota(0, time.Second), WithIssueQuota(1, 0)} {
+		if _, err := New(opt); err != ErrQuota {
+			t.Errorf("Expected ErrQuota got %v", err)
+		}
+	}
+}
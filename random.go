@@ -0,0 +1,67 @@
+package serial
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// ErrRandomBits is returned when asked to create a generator with a number
+// of random bits which is out of range for its layout, or with random bits
+// and an Allocator.
+var ErrRandomBits = errors.New("serial: invalid number of random bits")
+
+// WithRandomBits fills the n lowest bits of each serial value with random
+// bits from crypto/rand, or the source set by WithEntropy, from 1 to 32, so
+// that values can't be guessed from their neighbours. The remaining bits
+// still increase with each value, so values are unique and keep their order.
+//
+// With the default layout, the random bits replace the lowest bits of the
+// timestamp, so n bits of randomness cost 2^n nanoseconds of each value's
+// share of the clock: with 8 bits, generating more than about 4 million
+// values a second pushes them ahead of the clock. With a layout which has
+// node IDs, the random bits come from the sequence number, so n can't exceed
+// SequenceBits. Random bits can't be used with WithAllocator, and a
+// generator with random bits can't reserve blocks.
+//
+// The values are still not random as a whole: their high bits reveal the time
+// they were generated, as ever.
+func WithRandomBits(n uint) Option {
+	return func(g *Generator) error {
+		if n < 1 || n > 32 {
+			return ErrRandomBits
+		}
+		g.randomBits = n
+		return nil
+	}
+}
+
+// next returns the value to follow last at the time now, as for Layout.next,
+// but with r in the low bits if the generator has random bits. The bits
+// above them are then incremented from last, so the result is still greater.
+func (g *Generator) next(last Serial, now time.Time, r uint64) Serial {
+	if g.counter {
+		return last + 1
+	}
+	if g.randomBits == 0 {
+		return g.layout.next(last, g.node, now)
+	}
+	mask := Serial(1)<<g.randomBits - 1
+	return g.layout.next(last|mask, g.node, now)&^mask | Serial(r)&mask
+}
+
+// random fills rs with random numbers for the low bits of new values, if the
+// generator has random bits.
+func (g *Generator) random(rs []uint64) error {
+	if g.randomBits == 0 {
+		return nil
+	}
+	b := make([]byte, 8*len(rs))
+	if err := g.readRandom(b); err != nil {
+		return err
+	}
+	for i := range rs {
+		rs[i] = binary.LittleEndian.Uint64(b[8*i:])
+	}
+	return nil
+}
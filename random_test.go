@@ -0,0 +1,45 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRandomBits(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithRandomBits(0)},
+		{WithRandomBits(33)},
+		{WithLayout(SnowflakeLayout), WithRandomBits(12)},
+		{WithRandomBits(4), WithAllocator(NewPager(NewGenerator(), false), 10)},
+	} {
+		if _, err := New(opts...); err != ErrRandomBits {
+			t.Errorf("Expected ErrRandomBits got %v", err)
+		}
+	}
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk), WithRandomBits(8))
+	xs := append(g.GenerateN(500), g.Generate())
+	lows := make(map[Serial]bool)
+	for i, x := range xs {
+		if i > 0 && x <= xs[i-1] {
+			t.Fatalf("Values out of order: %d then %d", xs[i-1], x)
+		}
+		if i > 0 && x>>8 != xs[i-1]>>8+1 {
+			t.Fatalf("Expected high bits to increment, got %d then %d", xs[i-1], x)
+		}
+		lows[x&255] = true
+	}
+	if len(lows) < 100 {
+		t.Errorf("Expected random low bits, got %d distinct values", len(lows))
+	}
+	clk.Advance(time.Hour)
+	if x := g.Generate(); x>>8 != Serial(clk.now.UnixNano())>>8 {
+		t.Errorf("Expected high bits from the clock, got %d", x)
+	}
+	s := NewGenerator(WithLayout(SnowflakeLayout), WithNode(5), WithRandomBits(6))
+	for i := 0; i < 5000; i++ {
+		if _, node, _ := SnowflakeLayout.unpack(s.Generate()); node != 5 {
+			t.Fatalf("Expected node 5 got %d", node)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package serial
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRate is returned when asked to create a generator with a rate limit
+// less than 1.
+var ErrRate = errors.New("serial: invalid rate limit")
+
+// ErrRateLimited is returned by TryGenerate when the generator's rate limit
+// has been reached and it isn't set to wait.
+var ErrRateLimited = errors.New("serial: rate limit exceeded")
+
+// WithRateLimit caps the rate at which the generator issues values to
+// perSecond, on average, with bursts of up to perSecond values allowed after
+// a quiet period. When the limit is reached, if wait is true the generator
+// sleeps until it can carry on; otherwise TryGenerate returns ErrRateLimited,
+// and Generate, Fill and ReserveBlock panic with it. Each value in a batch
+// from Fill or a block from ReserveBlock counts towards the limit, so if wait
+// is false, a batch larger than perSecond always fails.
+func WithRateLimit(perSecond int, wait bool) Option {
+	return func(g *Generator) error {
+		if perSecond < 1 {
+			return ErrRate
+		}
+		g.limiter = &limiter{
+			rate:   float64(perSecond) / float64(time.Second),
+			burst:  float64(perSecond),
+			tokens: float64(perSecond),
+			wait:   wait,
+		}
+		return nil
+	}
+}
+
+// limiter is a token bucket which limits the rate of generation. It can go
+// into debt when waiting is allowed, so that callers queue up in order. The
+// bucket starts full, and last is only set by the first take, so that it
+// comes from the generator's final clock whatever the order of options.
+type limiter struct {
+	mutex   sync.Mutex
+	rate    float64
+	burst   float64
+	tokens  float64
+	last    int64
+	started bool
+	wait    bool
+}
+
+// take takes n tokens from the bucket at time now. It returns how long the
+// caller must wait before going ahead, and false if it mustn't go ahead at
+// all.
+func (l *limiter) take(n int, now int64) (time.Duration, bool) {
+	l.mutex.Lock()
+	if !l.started {
+		l.started = true
+		l.last = now
+	}
+	if now > l.last {
+		l.tokens += float64(now-l.last) * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+	}
+	need := float64(n)
+	if l.tokens >= need {
+		l.tokens -= need
+		l.mutex.Unlock()
+		return 0, true
+	}
+	if !l.wait {
+		l.mutex.Unlock()
+		return 0, false
+	}
+	l.tokens -= need
+	wait := time.Duration(-l.tokens / l.rate)
+	l.mutex.Unlock()
+	return wait, true
+}
+
+// refund returns n tokens to the bucket.
+func (l *limiter) refund(n int) {
+	l.mutex.Lock()
+	l.tokens += float64(n)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.mutex.Unlock()
+}
+
+// limit applies the generator's rate limit and issue quota, if it has them,
+// to the issue of n values. The quota is only charged once the rate limit
+// lets the values through, and if the quota refuses them, or the context is
+// done while waiting for the rate limit, the values are given back.
+func (g *Generator) limit(ctx context.Context, n int) error {
+	if g.quota == nil && g.limiter == nil {
+		return nil
+	}
+	if g.limiter != nil {
+		wait, ok := g.limiter.take(n, g.clock.Now().UnixNano())
+		if !ok {
+			return ErrRateLimited
+		}
+		if wait > 0 {
+			if err := sleep(ctx, wait); err != nil {
+				g.limiter.refund(n)
+				return err
+			}
+		}
+	}
+	if g.quota != nil {
+		if err := g.quota.issue(n, g.clock.Now().UnixNano()); err != nil {
+			if g.limiter != nil {
+				g.limiter.refund(n)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// unlimit gives back n values charged by limit which couldn't be issued
+// after all.
+func (g *Generator) unlimit(n int) {
+	if g.limiter != nil {
+		g.limiter.refund(n)
+	}
+	if g.quota != nil {
+		g.quota.refund(n, g.clock.Now().UnixNano())
+	}
+}
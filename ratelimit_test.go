@@ -0,0 +1,101 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk), WithRateLimit(10, false))
+	for i := 0; i < 10; i++ {
+		if _, err := g.TryGenerate(); err != nil {
+			t.Fatalf("Unexpected error in burst: %v", err)
+		}
+	}
+	if _, err := g.TryGenerate(); err != ErrRateLimited {
+		t.Errorf("Expected ErrRateLimited got %v", err)
+	}
+	clk.Advance(100 * time.Millisecond)
+	if _, err := g.TryGenerate(); err != nil {
+		t.Errorf("Expected a token after 100ms, got %v", err)
+	}
+	if _, err := g.TryGenerate(); err != ErrRateLimited {
+		t.Errorf("Expected ErrRateLimited got %v", err)
+	}
+	// The bucket never holds more than a second's worth.
+	clk.Advance(time.Hour)
+	n := 0
+	for ; n < 100; n++ {
+		if _, err := g.TryGenerate(); err != nil {
+			break
+		}
+	}
+	if n != 10 {
+		t.Errorf("Expected burst of 10 got %d", n)
+	}
+}
+
+func TestRateLimitClockOrder(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithRateLimit(1, false), WithClock(clk))
+	if _, err := g.TryGenerate(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := g.TryGenerate(); err != ErrRateLimited {
+		t.Errorf("Expected ErrRateLimited got %v", err)
+	}
+	clk.Advance(time.Hour)
+	if _, err := g.TryGenerate(); err != nil {
+		t.Errorf("Expected a token after an hour, got %v", err)
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	g := NewGenerator(WithRateLimit(100, true))
+	start := time.Now()
+	g.GenerateN(100)
+	for i := 0; i < 10; i++ {
+		g.Generate()
+	}
+	if d := time.Since(start); d < 80*time.Millisecond {
+		t.Errorf("Expected to wait about 100ms, took %v", d)
+	}
+}
+
+func TestWithRateLimitInvalid(t *testing.T) {
+	if _, err := New(WithRateLimit(0, true)); err != ErrRate {
+		t.Errorf("Expected ErrRate got %v", err)
+	}
+}
+
+func TestRateLimitQuota(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk), WithRateLimit(1, false), WithIssueQuota(3, time.Hour))
+	if _, err := g.TryGenerate(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Calls refused by the rate limit don't use up the quota.
+	for i := 0; i < 10; i++ {
+		if _, err := g.TryGenerate(); err != ErrRateLimited {
+			t.Fatalf("Expected ErrRateLimited got %v", err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		clk.Advance(time.Second)
+		if _, err := g.TryGenerate(); err != nil {
+			t.Fatalf("Expected a value after 1s, got %v", err)
+		}
+	}
+	// Calls refused by the quota don't use up the rate limit.
+	clk.Advance(time.Second)
+	if _, err := g.TryGenerate(); err == nil {
+		t.Fatal("Expected quota to be exceeded")
+	} else if _, ok := err.(*QuotaError); !ok {
+		t.Fatalf("Expected *QuotaError got %v", err)
+	}
+	clk.Advance(time.Hour)
+	if _, err := g.TryGenerate(); err != nil {
+		t.Errorf("Expected a value in the next window, got %v", err)
+	}
+}
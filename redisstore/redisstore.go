@@ -0,0 +1,265 @@
+// Package redisstore keeps the seen history of a serial.Generator in Redis,
+// so that several replicas of an application share one history and a
+// one-time value can only be used once, whichever replica receives it.
+//
+//	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+//	gen := serial.NewGenerator(serial.WithSeenStore(redisstore.New(client, "tokens", nil)))
+//
+// Values flagged with SetSeenFor are stored with a Redis TTL, so Redis removes
+// them itself. Other values are also listed in a sorted set, so that they can
+// be found and removed by age. All of a store's keys share a hash tag, so that
+// they are kept together in a Redis Cluster.
+package redisstore
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lpar/serial"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrCorrupt is reported when an entry in Redis can't be decoded.
+var ErrCorrupt = errors.New("redisstore: corrupt entry")
+
+// scanBatch is the number of keys requested from Redis at a time when
+// scanning or expiring the history.
+const scanBatch = 1000
+
+// setScript records a value which expires by age, and lists it in the index,
+// atomically.
+var setScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX') then
+	redis.call('ZADD', KEYS[2], 0, ARGV[2])
+	return 1
+end
+return 0`)
+
+// deleteScript removes a value and its index entry atomically.
+var deleteScript = redis.NewScript(`
+redis.call('ZREM', KEYS[2], ARGV[1])
+return redis.call('DEL', KEYS[1])`)
+
+// Store is a serial.SeenStore backed by Redis.
+//
+// The SeenStore interface has no way to report errors, so a Store fails
+// closed: if Redis can't be reached, values are reported as seen, and if a
+// value can't be recorded, it is reported as already seen, so that a one-time
+// value is never accepted twice. Errors are passed to the handler given when
+// the Store was created.
+type Store struct {
+	client  redis.UniversalClient
+	prefix  string
+	index   string
+	onError func(error)
+}
+
+// New returns a Store which keeps the history in Redis under keys starting
+// with the specified name in braces, such as {tokens}:. Stores with the same
+// name share a history. The handler is called with any errors from Redis; it
+// may be nil.
+func New(client redis.UniversalClient, name string, onError func(error)) *Store {
+	prefix := "{" + name + "}:"
+	return &Store{client: client, prefix: prefix, index: prefix + "index", onError: onError}
+}
+
+// fail reports an error to the handler.
+func (st *Store) fail(err error) {
+	if st.onError != nil {
+		st.onError(err)
+	}
+}
+
+// entry is the form in which entries are stored in Redis.
+type entry struct {
+	Expires int64       `json:"e,omitempty"`
+	Value   interface{} `json:"v,omitempty"`
+}
+
+// Set implements serial.SeenStore.
+func (st *Store) Set(x serial.Serial, e serial.SeenEntry, now time.Time) bool {
+	ctx := context.Background()
+	ent := entry{Value: e.Value}
+	if !e.Expires.IsZero() {
+		ent.Expires = e.Expires.UnixNano()
+	}
+	v, err := json.Marshal(ent)
+	if err != nil {
+		st.fail(err)
+		return false
+	}
+	m := member(x)
+	if e.Expires.IsZero() {
+		n, err := setScript.Run(ctx, st.client, []string{st.prefix + m, st.index}, v, m).Int()
+		if err != nil {
+			st.fail(err)
+			return false
+		}
+		return n == 1
+	}
+	ttl := e.Expires.Sub(now)
+	if ttl <= 0 {
+		// Already expired, so there's nothing to store.
+		n, err := st.client.Exists(ctx, st.prefix+m).Result()
+		if err != nil {
+			st.fail(err)
+			return false
+		}
+		return n == 0
+	}
+	ok, err := st.client.SetNX(ctx, st.prefix+m, v, ttl).Result()
+	if err != nil {
+		st.fail(err)
+		return false
+	}
+	return ok
+}
+
+// Has implements serial.SeenStore.
+func (st *Store) Has(x serial.Serial) (serial.SeenEntry, bool) {
+	v, err := st.client.Get(context.Background(), st.prefix+member(x)).Bytes()
+	if err == redis.Nil {
+		return serial.SeenEntry{}, false
+	}
+	if err != nil {
+		st.fail(err)
+		return serial.SeenEntry{}, true
+	}
+	e, err := decode(v)
+	if err != nil {
+		st.fail(err)
+	}
+	return e, true
+}
+
+// Delete implements serial.SeenStore.
+func (st *Store) Delete(x serial.Serial) bool {
+	m := member(x)
+	n, err := deleteScript.Run(context.Background(), st.client, []string{st.prefix + m, st.index}, m).Int()
+	if err != nil {
+		st.fail(err)
+		return false
+	}
+	return n == 1
+}
+
+// ExpireBefore implements serial.SeenStore. Values with a time to live are
+// removed by Redis when they expire, so they aren't included in the count.
+func (st *Store) ExpireBefore(limit serial.Serial, now time.Time) int {
+	ctx := context.Background()
+	removed := 0
+	for {
+		ms, err := st.client.ZRangeByLex(ctx, st.index, &redis.ZRangeBy{
+			Min: "-", Max: "(" + member(limit), Count: scanBatch,
+		}).Result()
+		if err != nil {
+			st.fail(err)
+			return removed
+		}
+		if len(ms) == 0 {
+			return removed
+		}
+		keys := make([]string, len(ms))
+		zm := make([]interface{}, len(ms))
+		for i, m := range ms {
+			keys[i] = st.prefix + m
+			zm[i] = m
+		}
+		pipe := st.client.TxPipeline()
+		del := pipe.Del(ctx, keys...)
+		pipe.ZRem(ctx, st.index, zm...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			st.fail(err)
+			return removed
+		}
+		removed += int(del.Val())
+	}
+}
+
+// Range implements serial.SeenStore. It scans the keys in Redis, so values
+// added or removed during the call may or may not be visited.
+func (st *Store) Range(f func(x serial.Serial, e serial.SeenEntry) bool) {
+	ctx := context.Background()
+	var cursor uint64
+	for {
+		keys, next, err := st.client.Scan(ctx, cursor, st.prefix+"*", scanBatch).Result()
+		if err != nil {
+			st.fail(err)
+			return
+		}
+		var vals []interface{}
+		if len(keys) > 0 {
+			if vals, err = st.client.MGet(ctx, keys...).Result(); err != nil {
+				st.fail(err)
+				return
+			}
+		}
+		for i, k := range keys {
+			x, ok := unmember(k[len(st.prefix):])
+			s, isString := vals[i].(string)
+			if !ok || !isString {
+				// The index, or a value which has since expired.
+				continue
+			}
+			e, err := decode([]byte(s))
+			if err != nil {
+				st.fail(err)
+				continue
+			}
+			if !f(x, e) {
+				return
+			}
+		}
+		if next == 0 {
+			return
+		}
+		cursor = next
+	}
+}
+
+// Len implements serial.SeenStore. It has to scan the keys in Redis, so it
+// is slow for a large history.
+func (st *Store) Len() int {
+	n := 0
+	st.Range(func(serial.Serial, serial.SeenEntry) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// member returns the key suffix and index member for x, as 16 hex digits
+// with the sign bit flipped, so that members sort in the same order as the
+// values.
+func member(x serial.Serial) string {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(x)^1<<63)
+	return hex.EncodeToString(b[:])
+}
+
+// unmember returns the value for a key suffix, and whether it is valid.
+func unmember(m string) (serial.Serial, bool) {
+	b, err := hex.DecodeString(m)
+	if err != nil || len(b) != 8 {
+		return 0, false
+	}
+	return serial.Serial(binary.BigEndian.Uint64(b) ^ 1<<63), true
+}
+
+// decode decodes an entry stored by Set. Attached values are decoded as for
+// json.Unmarshal into an interface{}.
+func decode(v []byte) (serial.SeenEntry, error) {
+	var ent entry
+	if err := json.Unmarshal(v, &ent); err != nil {
+		return serial.SeenEntry{}, ErrCorrupt
+	}
+	e := serial.SeenEntry{Value: ent.Value}
+	if ent.Expires != 0 {
+		e.Expires = time.Unix(0, ent.Expires)
+	}
+	return e, nil
+}
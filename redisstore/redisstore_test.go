@@ -0,0 +1,85 @@
+package redisstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/lpar/serial"
+	"github.com/redis/go-redis/v9"
+)
+
+func newStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return New(client, "tokens", func(err error) { t.Errorf("Unexpected store error: %v", err) }), mr
+}
+
+func TestStore(t *testing.T) {
+	st, mr := newStore(t)
+	// Two replicas sharing one history.
+	g1 := serial.NewGenerator(serial.WithSeenStore(st))
+	g2 := serial.NewGenerator(serial.WithSeenStore(New(st.client, "tokens", nil)))
+	x := g1.Generate()
+	y := g1.Generate()
+	z := g1.Generate()
+	g1.SetSeenWithValue(x, "alice")
+	g1.SetSeenFor(y, time.Minute)
+	if g1.SeenOrSet(z) || !g2.SeenOrSet(z) {
+		t.Errorf("Expected SeenOrSet(%d) to succeed exactly once across replicas", z)
+	}
+	if v, ok := g2.SeenValue(x); !ok || v != "alice" {
+		t.Errorf("Expected alice and 'seen' got %v and %v", v, ok)
+	}
+	if e, ok := st.Has(y); !ok || e.Expires.IsZero() {
+		t.Errorf("Expected %d seen with expiry, got %v and %v", y, e, ok)
+	}
+	if n := g2.SeenCount(); n != 3 {
+		t.Errorf("Expected 3 seen values got %d", n)
+	}
+	if !g2.UnsetSeen(z) || g1.Seen(z) {
+		t.Errorf("Expected %d to be unset", z)
+	}
+
+	mr.FastForward(2 * time.Minute)
+	if g1.Seen(y) {
+		t.Errorf("Expected %d to have expired, got 'seen'", y)
+	}
+	if n := st.ExpireBefore(z, time.Now()); n != 1 {
+		t.Errorf("Expected 1 value expired got %d", n)
+	}
+	if n := st.Len(); n != 0 {
+		t.Errorf("Expected empty store got %d", n)
+	}
+}
+
+func TestFailClosed(t *testing.T) {
+	st, mr := newStore(t)
+	var errs int
+	st.onError = func(error) { errs++ }
+	g := serial.NewGenerator(serial.WithSeenStore(st))
+	x := g.Generate()
+	mr.Close()
+	if !g.Seen(x) {
+		t.Errorf("Expected %d reported as seen when Redis is down", x)
+	}
+	if !g.SeenOrSet(x) {
+		t.Errorf("Expected SeenOrSet(%d) to fail closed when Redis is down", x)
+	}
+	if errs != 2 {
+		t.Errorf("Expected 2 errors reported got %d", errs)
+	}
+}
+
+func TestMemberOrder(t *testing.T) {
+	xs := []serial.Serial{-1 << 63, -1, 0, 1, 1<<63 - 1}
+	for i := 1; i < len(xs); i++ {
+		if member(xs[i-1]) >= member(xs[i]) {
+			t.Errorf("Expected member for %d before member for %d", xs[i-1], xs[i])
+		}
+		if x, ok := unmember(member(xs[i])); !ok || x != xs[i] {
+			t.Errorf("Expected %d got %d", xs[i], x)
+		}
+	}
+}
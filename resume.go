@@ -0,0 +1,57 @@
+package serial
+
+// WithStartAfter makes the generator issue only values greater than x, as
+// if it had already issued x, so that a generator rebuilt from persisted
+// state can't issue any value up to the highest it is known to have issued
+// before. If the clock is behind x, values run ahead of it, as after the
+// clock moves backwards.
+func WithStartAfter(x Serial) Option {
+	return func(g *Generator) error {
+		if x > g.startAfter {
+			g.startAfter = x
+		}
+		return nil
+	}
+}
+
+// WithResumeFromSeen makes the generator start after the highest value in
+// its seen history when it is created, as for WithStartAfter, so that it can
+// never issue a value below anything already recorded. It is meant for a
+// history which outlives the process, such as a SeenStore backed by a
+// database, and reads the whole history once, which may take some time for
+// a large store. A history kept in Bloom filters can't be read, and starts
+// empty anyway.
+//
+// ImportSeen and Restore already move the generator on past the values they
+// restore, so don't need this option.
+func WithResumeFromSeen() Option {
+	return func(g *Generator) error {
+		g.resume = true
+		return nil
+	}
+}
+
+// NewGeneratorFrom creates a generator which only issues values greater than
+// seed, as for WithStartAfter, configured by the other options. Like
+// NewGenerator, it panics if the options are invalid.
+func NewGeneratorFrom(seed Serial, opts ...Option) *Generator {
+	return NewGenerator(append([]Option{WithStartAfter(seed)}, opts...)...)
+}
+
+// resumeFrom moves the generator past the value set by WithStartAfter, and
+// the highest value in its seen history if it was created with
+// WithResumeFromSeen.
+func (g *Generator) resumeFrom() {
+	g.raise(g.startAfter)
+	if !g.resume {
+		return
+	}
+	var max Serial
+	g.store.each(func(x Serial, e seenEntry) bool {
+		if x > max {
+			max = x
+		}
+		return true
+	})
+	g.raise(max)
+}
@@ -0,0 +1,34 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResume(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	seed := Serial(time.Unix(2000, 0).UnixNano())
+	g := NewGeneratorFrom(seed, WithClock(clk))
+	if x := g.Generate(); x != seed+1 {
+		t.Errorf("Expected %d got %d", seed+1, x)
+	}
+
+	st := NewMemoryStore()
+	g = NewGenerator(WithClock(clk), WithSeenStore(st))
+	g.SetSeen(seed + 100)
+	g.SetSeenFor(seed+50, time.Hour)
+	fresh := NewGenerator(WithClock(clk), WithSeenStore(st))
+	if x := fresh.Generate(); x > seed {
+		t.Errorf("Expected a fresh generator to follow the clock, got %d", x)
+	}
+	resumed := NewGenerator(WithClock(clk), WithSeenStore(st), WithResumeFromSeen())
+	if x := resumed.Generate(); x != seed+101 {
+		t.Errorf("Expected %d got %d", seed+101, x)
+	}
+	// A later clock wins over the history.
+	clk.now = time.Unix(3000, 0)
+	resumed = NewGenerator(WithClock(clk), WithSeenStore(st), WithResumeFromSeen(), WithStartAfter(seed))
+	if x := resumed.Generate(); x != Serial(clk.now.UnixNano()) {
+		t.Errorf("Expected %d got %d", clk.now.UnixNano(), x)
+	}
+}
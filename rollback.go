@@ -0,0 +1,146 @@
+package serial
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RollbackPolicy determines what a Generator does when it finds that the
+// clock has moved backwards since it last generated a value, as can happen
+// after an NTP correction or a VM migration.
+type RollbackPolicy int
+
+const (
+	// RollbackIncrement carries on incrementing from the last value
+	// generated, so values stay unique but their embedded timestamps run
+	// ahead of the clock until it catches up. This is the default.
+	RollbackIncrement RollbackPolicy = iota
+	// RollbackWait sleeps until the clock has caught up with the time the
+	// last value was generated, so embedded timestamps stay truthful.
+	RollbackWait
+	// RollbackError makes TryGenerate return ErrClockRollback until the clock
+	// has caught up, and Generate panic.
+	RollbackError
+)
+
+// ErrClockRollback is returned by TryGenerate when the clock has moved
+// backwards and the generator's policy is RollbackError.
+var ErrClockRollback = errors.New("serial: clock moved backwards")
+
+// WithRollbackPolicy sets what the generator does when the clock moves
+// backwards. The default is RollbackIncrement.
+func WithRollbackPolicy(p RollbackPolicy) Option {
+	return func(g *Generator) error {
+		g.rollback = p
+		return nil
+	}
+}
+
+// WithRollbackHandler sets a function to be called whenever the generator
+// finds that the clock has moved backwards, with the distance it moved, so
+// that it can be logged. The function is called regardless of the rollback
+// policy, and after the generator's locks have been released.
+func WithRollbackHandler(f func(time.Duration)) Option {
+	return func(g *Generator) error {
+		g.onRollback = f
+		return nil
+	}
+}
+
+// WithWaitForClock makes the generator wait for the clock, rather than run
+// ahead of it, so that the time embedded in a value is never later than the
+// time it was returned. When values are requested faster than the clock
+// ticks, each value is still claimed at once, but Generate doesn't return it
+// until the clock has reached its embedded time; Fill and Reserve wait for
+// the last value of the batch or block. It also sets the RollbackWait policy.
+// The option has no effect on generators which lease values from an
+// Allocator or count with WithCounter, since their values aren't tied to the
+// clock.
+func WithWaitForClock() Option {
+	return func(g *Generator) error {
+		g.waitClock = true
+		g.rollback = RollbackWait
+		return nil
+	}
+}
+
+// waitFor sleeps until the clock has reached the time embedded in x, if the
+// generator waits for the clock, unless the context is done first.
+func (g *Generator) waitFor(ctx context.Context, x Serial) error {
+	if !g.waitClock || g.counter || g.leaser != nil {
+		return nil
+	}
+	t := g.layout.timeOf(x)
+	for {
+		d := t.Sub(g.clock.Now())
+		if d <= 0 {
+			return nil
+		}
+		if err := sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// advance reads the clock for generating the values which follow lastSerial,
+// applying the rollback policy. It also returns how far the clock had moved
+// backwards, if it had.
+func (g *Generator) advance(ctx context.Context) (time.Time, time.Duration, error) {
+	// lastClock must be loaded before the clock is read. Any value stored
+	// by another goroutine was then read from the clock before this one,
+	// so a concurrent call can't be mistaken for the clock moving
+	// backwards.
+	last := g.lastClock.Load()
+	now := g.clock.Now()
+	n := now.UnixNano()
+	var back time.Duration
+	if n < last {
+		back = time.Duration(last - n)
+		switch g.rollback {
+		case RollbackError:
+			return now, back, ErrClockRollback
+		case RollbackWait:
+			for n < last {
+				if err := sleep(ctx, time.Duration(last-n)); err != nil {
+					return now, back, err
+				}
+				now = g.clock.Now()
+				n = now.UnixNano()
+			}
+		}
+	}
+	for last < n && !g.lastClock.CompareAndSwap(last, n) {
+		last = g.lastClock.Load()
+	}
+	return now, back, nil
+}
+
+// rolledBack counts a rollback and calls the rollback handler, if the clock
+// has moved backwards.
+func (g *Generator) rolledBack(back time.Duration) {
+	if back <= 0 {
+		return
+	}
+	g.counters.rollbacks.Add(1)
+	if g.onRollback != nil {
+		g.onRollback(back)
+	}
+}
+
+// sleep pauses for the specified duration, or until the context is done, in
+// which case it returns the context's error.
+func sleep(ctx context.Context, d time.Duration) error {
+	if ctx.Done() == nil {
+		time.Sleep(d)
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,100 @@
+package serial
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// tickingClock is a Clock which moves forward a little every time it's read.
+type tickingClock struct {
+	fakeClock
+	tick time.Duration
+}
+
+func (c *tickingClock) Now() time.Time {
+	c.Advance(c.tick)
+	return c.now
+}
+
+func TestRollbackIncrement(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var back time.Duration
+	g := NewGenerator(WithClock(clock), WithRollbackHandler(func(d time.Duration) { back = d }))
+	x1 := g.Generate()
+	clock.Advance(-time.Minute)
+	x2 := g.Generate()
+	if x2 != x1+1 {
+		t.Errorf("Expected to increment after rollback, got %d then %d", x1, x2)
+	}
+	if back != time.Minute {
+		t.Errorf("Wrong rollback reported, expected 1m got %v", back)
+	}
+}
+
+func TestRollbackError(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	g := NewGenerator(WithClock(clock), WithRollbackPolicy(RollbackError))
+	g.Generate()
+	clock.Advance(-time.Second)
+	if _, err := g.TryGenerate(); err != ErrClockRollback {
+		t.Errorf("Expected ErrClockRollback, got %v", err)
+	}
+	clock.Advance(time.Second)
+	if _, err := g.TryGenerate(); err != nil {
+		t.Errorf("Expected success once clock caught up, got %v", err)
+	}
+	clock.Advance(-time.Second)
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Generate to panic")
+		}
+	}()
+	g.Generate()
+}
+
+func TestRollbackWait(t *testing.T) {
+	clock := &tickingClock{fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}, time.Millisecond}
+	g := NewGenerator(WithClock(clock), WithRollbackPolicy(RollbackWait))
+	x1 := g.Generate()
+	clock.Advance(-10 * time.Millisecond)
+	x2 := g.Generate()
+	if x2 <= x1 || clock.now.Before(x1.Time()) {
+		t.Errorf("Expected to wait for clock, got %v then %v with clock at %v", x1.Time(), x2.Time(), clock.now)
+	}
+}
+
+func TestWaitForClock(t *testing.T) {
+	g := NewGenerator(WithLayout(Layout{TimeBits: 63, Resolution: time.Millisecond}), WithWaitForClock())
+	start := time.Now()
+	var last Serial
+	for i := 0; i < 5; i++ {
+		x := g.Generate()
+		if x <= last {
+			t.Fatalf("Expected %d after %d", x, last)
+		}
+		last = x
+		if g.Time(x).After(time.Now()) {
+			t.Errorf("Value %d returned before its time %v", x, g.Time(x))
+		}
+	}
+	xs := g.GenerateN(3)
+	if g.Time(xs[2]).After(time.Now()) {
+		t.Errorf("Batch returned before its time %v", g.Time(xs[2]))
+	}
+	r := g.ReserveBlock(3)
+	if g.Time(r.Last).After(time.Now()) {
+		t.Errorf("Block returned before its time %v", g.Time(r.Last))
+	}
+	if d := time.Since(start); d < 8*time.Millisecond {
+		t.Errorf("Expected 11 values to take at least 8ms, took %v", d)
+	}
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	g = NewGenerator(WithClock(clock), WithWaitForClock())
+	g.Generate()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := g.GenerateCtx(ctx); err != context.Canceled {
+		t.Errorf("Expected context.Canceled waiting for a stopped clock, got %v", err)
+	}
+}
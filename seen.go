@@ -0,0 +1,597 @@
+package serial
+
+import (
+	"math"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// seenStore holds the history of seen values for a Generator. Times are in
+// Unix nanoseconds.
+type seenStore interface {
+	// add records x as seen, and reports whether it wasn't already. If e has
+	// a deadline, x expires at that time rather than by age.
+	add(x Serial, e seenEntry, now int64) bool
+	// has reports whether x has been seen, and returns its entry.
+	has(x Serial) (seenEntry, bool)
+	// expire removes entries whose deadline is no later than now, and
+	// entries without a deadline whose values are less than limit. It
+	// returns how many were removed, and if removed isn't nil, appends the
+	// values removed to it where they can be recovered.
+	expire(limit Serial, now int64, removed *[]Serial) int
+	// remove removes x, and reports whether it was present.
+	remove(x Serial, now int64) bool
+	// each calls f for every entry, in no particular order, until f returns
+	// false. No locks are held while f runs, so it may modify the history.
+	each(f func(x Serial, e seenEntry) bool)
+	// lag returns the net growth of the history over the lag window.
+	lag(now int64) int64
+	// len returns the number of values in the history.
+	len() int
+	// memory returns the approximate number of bytes the history uses.
+	memory() int64
+}
+
+// seenShards is the number of independently locked shards the default seen
+// history is split across, so that concurrent calls to Seen and SetSeen
+// rarely contend for the same lock. It must be a power of two.
+const (
+	seenShardBits = 6
+	seenShards    = 1 << seenShardBits
+)
+
+// seenEntry holds the details recorded for a seen value.
+type seenEntry struct {
+	// deadline is the time at which the entry expires, if it is positive.
+	// If it is zero, the entry expires by the age of its value; if it is
+	// negative, as for a generator in counter mode, the entry expires by age
+	// counted from -deadline, the time at which it was flagged.
+	deadline int64
+	// value is the value attached by SetSeenWithValue, if any.
+	value interface{}
+}
+
+// expired reports whether the entry for x has expired, given the limit and
+// time passed to seenStore.expire.
+func (e seenEntry) expired(x, limit Serial, now int64) bool {
+	if e.deadline > 0 {
+		return e.deadline <= now
+	}
+	return e.key(x) < limit
+}
+
+// dead reports whether the entry's time to live has run out as of now.
+func (e seenEntry) dead(now int64) bool {
+	return e.deadline > 0 && e.deadline <= now
+}
+
+// key returns what the entry for x is compared with the expiry limit by:
+// the value itself, or the time at which it was flagged for an entry which
+// expires by age from then.
+func (e seenEntry) key(x Serial) Serial {
+	if e.deadline < 0 {
+		return Serial(-e.deadline)
+	}
+	return x
+}
+
+// lagSlots and lagSlotWidth define the recent window over which
+// ExpirationLag reports net growth of the seen history: six ten second
+// slots, or roughly the last minute.
+const (
+	lagSlots     = 6
+	lagSlotWidth = int64(10 * time.Second)
+)
+
+// lagSlot accumulates the net change in size of the seen history during
+// one slot of the lag window.
+type lagSlot struct {
+	slot int64
+	net  int64
+}
+
+// lagWindow accumulates net changes in size of the seen history over the lag
+// window. It needs to be protected by its owner's lock.
+type lagWindow [lagSlots]lagSlot
+
+// record adds delta to the slot for the time now.
+func (w *lagWindow) record(now int64, delta int64) {
+	n := now / lagSlotWidth
+	l := &w[n%lagSlots]
+	if l.slot != n {
+		l.slot = n
+		l.net = 0
+	}
+	l.net += delta
+}
+
+// sum returns the total of the slots within the window as of time now.
+func (w *lagWindow) sum(now int64) (net int64) {
+	n := now / lagSlotWidth
+	for _, l := range w {
+		if n-l.slot < lagSlots {
+			net += l.net
+		}
+	}
+	return net
+}
+
+// mapStore is the default seenStore, which keeps exact history in maps.
+type mapStore struct {
+	shards [seenShards]seenShard
+}
+
+// seenShard holds the part of the seen history whose values hash to it,
+// along with its share of the lag accounting. Its values are indexed by the
+// key they expire by, or by their deadline if they have one.
+type seenShard struct {
+	mutex      sync.RWMutex
+	m          map[Serial]seenEntry
+	byKey      expiryIndex
+	byDeadline expiryIndex
+	lag        lagWindow
+}
+
+// index adds x to the appropriate expiry index for its entry.
+func (s *seenShard) index(x Serial, e seenEntry) {
+	if e.deadline > 0 {
+		s.byDeadline.add(e.deadline, x)
+	} else {
+		s.byKey.add(int64(e.key(x)), x)
+	}
+}
+
+func newMapStore() *mapStore {
+	st := &mapStore{}
+	for i := range st.shards {
+		st.shards[i].m = make(map[Serial]seenEntry)
+	}
+	return st
+}
+
+// shard returns the shard which holds x. Serial values are hashed with
+// Fibonacci hashing, so that the mostly sequential values from a generator
+// are spread evenly.
+func (st *mapStore) shard(x Serial) *seenShard {
+	return &st.shards[uint64(x)*0x9e3779b97f4a7c15>>(64-seenShardBits)]
+}
+
+func (st *mapStore) add(x Serial, e seenEntry, now int64) bool {
+	s := st.shard(x)
+	s.mutex.Lock()
+	old, ok := s.m[x]
+	if ok && old.dead(now) {
+		// Expired but not yet removed, so it can be replaced.
+		ok = false
+		s.lag.record(now, -1)
+	}
+	if !ok {
+		s.m[x] = e
+		s.index(x, e)
+		s.lag.record(now, 1)
+	}
+	s.mutex.Unlock()
+	return !ok
+}
+
+func (st *mapStore) has(x Serial) (seenEntry, bool) {
+	s := st.shard(x)
+	s.mutex.RLock()
+	e, ok := s.m[x]
+	s.mutex.RUnlock()
+	return e, ok
+}
+
+func (st *mapStore) remove(x Serial, now int64) bool {
+	s := st.shard(x)
+	s.mutex.Lock()
+	_, ok := s.m[x]
+	if ok {
+		delete(s.m, x)
+		s.lag.record(now, -1)
+	}
+	s.mutex.Unlock()
+	return ok
+}
+
+func (st *mapStore) each(f func(x Serial, e seenEntry) bool) {
+	type item struct {
+		x Serial
+		e seenEntry
+	}
+	var items []item
+	for i := range st.shards {
+		s := &st.shards[i]
+		items = items[:0]
+		s.mutex.RLock()
+		for x, e := range s.m {
+			items = append(items, item{x, e})
+		}
+		s.mutex.RUnlock()
+		for _, it := range items {
+			if !f(it.x, it.e) {
+				return
+			}
+		}
+	}
+}
+
+// expire visits only the buckets of the expiry indexes which are old enough
+// to hold expired entries.
+func (st *mapStore) expire(limit Serial, now int64, removed *[]Serial) int {
+	total := 0
+	for i := range st.shards {
+		n, _ := st.expireShard(i, limit, now, removed, 0, nil)
+		total += n
+	}
+	return total
+}
+
+// expireShard expires the entries in shard i, as for expire, and returns how
+// many were removed. If budget is positive, it visits at most that many
+// entries in each of the shard's indexes, carrying on from where the cursors
+// say the last call stopped, and reports whether there are more to visit.
+func (st *mapStore) expireShard(i int, limit Serial, now int64, removed *[]Serial, budget int, cs *[2]expiryCursor) (int, bool) {
+	s := &st.shards[i]
+	s.mutex.Lock()
+	var n int64
+	// keep expires x if it is due, and reports whether it must stay in the
+	// index which holds entries with or without deadlines.
+	keep := func(x Serial, deadline bool) bool {
+		e, ok := s.m[x]
+		if !ok || (e.deadline > 0) != deadline {
+			return false
+		}
+		if !e.expired(x, limit, now) {
+			return true
+		}
+		delete(s.m, x)
+		n++
+		if removed != nil {
+			*removed = append(*removed, x)
+		}
+		return false
+	}
+	var kc, dc *expiryCursor
+	if cs != nil {
+		kc, dc = &cs[0], &cs[1]
+	}
+	more := s.byKey.expire(int64(limit)-1, budget, kc, func(x Serial) bool { return keep(x, false) })
+	if s.byDeadline.expire(now, budget, dc, func(x Serial) bool { return keep(x, true) }) {
+		more = true
+	}
+	s.lag.record(now, -n)
+	s.mutex.Unlock()
+	return int(n), more
+}
+
+// extract removes the entries which expire by age, have no attached value and
+// are less than limit, calling move with each before it is removed, and
+// returns how many were removed. Like expire, it only visits the buckets of
+// the expiry indexes which are old enough to hold them.
+func (st *mapStore) extract(limit Serial, now int64, move func(x Serial)) int {
+	total := 0
+	for i := range st.shards {
+		s := &st.shards[i]
+		s.mutex.Lock()
+		var n int64
+		s.byKey.expire(int64(limit)-1, 0, nil, func(x Serial) bool {
+			e, ok := s.m[x]
+			if !ok || e.deadline > 0 {
+				return false
+			}
+			if e.key(x) >= limit || e.value != nil {
+				return true
+			}
+			move(x)
+			delete(s.m, x)
+			n++
+			return false
+		})
+		s.lag.record(now, -n)
+		s.mutex.Unlock()
+		total += int(n)
+	}
+	return total
+}
+
+func (st *mapStore) lag(now int64) (net int64) {
+	for i := range st.shards {
+		s := &st.shards[i]
+		s.mutex.RLock()
+		net += s.lag.sum(now)
+		s.mutex.RUnlock()
+	}
+	return net
+}
+
+func (st *mapStore) len() int {
+	n := 0
+	for i := range st.shards {
+		s := &st.shards[i]
+		s.mutex.RLock()
+		n += len(s.m)
+		s.mutex.RUnlock()
+	}
+	return n
+}
+
+// Seen returns a boolean to indicate whether the specified Serial value has
+// been seen. Serial values are unseen until SetSeen is called. Once they have
+// been set as seen, they remain seen until history is expired, or until their
+// time to live runs out if they were flagged with SetSeenFor.
+func (g *Generator) Seen(x Serial) bool {
+	_, ok := g.lookup(x)
+	return ok
+}
+
+// lookup returns the entry for x if it has been seen and hasn't outlived its
+// time to live.
+func (g *Generator) lookup(x Serial) (seenEntry, bool) {
+	e, ok := g.store.has(x)
+	if ok && e.dead(g.clock.Now().UnixNano()) {
+		return seenEntry{}, false
+	}
+	return e, ok
+}
+
+// SetSeen flags the specified Serial value as having been seen. This can
+// then be interrogated using the Seen() method. It panics if the generator's
+// seen quota has been reached; use TrySetSeen to handle that case.
+func (g *Generator) SetSeen(x Serial) {
+	if err := g.TrySetSeen(x); err != nil {
+		panic(err)
+	}
+}
+
+// SetSeenWithValue flags the specified Serial value as having been seen, as
+// for SetSeen, and attaches a value to it, such as a record of who consumed
+// it and why. The value can be retrieved with SeenValue, and is discarded
+// along with the rest of the entry when history is expired. If the serial is
+// already flagged as seen, its value is unchanged.
+//
+// A history kept in Bloom filters has no room for values, so they are
+// discarded.
+func (g *Generator) SetSeenWithValue(x Serial, v interface{}) {
+	e := seenEntry{value: v}
+	added, err := g.addSeen(x, e, g.clock.Now().UnixNano())
+	if err != nil {
+		panic(err)
+	}
+	if added {
+		g.flagged(x, e)
+	}
+}
+
+// SeenValue returns the value attached to the specified Serial value by
+// SetSeenWithValue, and whether the serial has been seen. A serial flagged by
+// one of the other methods is seen with a nil value.
+func (g *Generator) SeenValue(x Serial) (interface{}, bool) {
+	e, ok := g.lookup(x)
+	return e.value, ok
+}
+
+// SeenOrSet flags the specified Serial value as having been seen, and reports
+// whether it had already been seen. The check and the update are atomic, so
+// when several goroutines race to redeem the same one-time value, exactly one
+// of them gets false.
+func (g *Generator) SeenOrSet(x Serial) bool {
+	seen, err := g.TrySeenOrSet(x)
+	if err != nil {
+		panic(err)
+	}
+	return seen
+}
+
+// UnsetSeen removes the specified Serial value from the seen history, so that
+// it is unseen again, and reports whether it was previously seen. This allows
+// a one-time value to be returned to circulation, for example when the
+// transaction which consumed it is rolled back.
+//
+// Values can't be removed from a history kept in Bloom filters, so UnsetSeen
+// always returns false for a generator created with WithBloomFilter.
+func (g *Generator) UnsetSeen(x Serial) bool {
+	g.snapMutex.RLock()
+	ok := g.store.remove(x, g.clock.Now().UnixNano())
+	g.snapMutex.RUnlock()
+	if ok {
+		if g.mapped != nil {
+			g.mapped.forget(x)
+		}
+		g.emit(EventUnset, x, 0)
+		return true
+	}
+	return false
+}
+
+// RangeSeen calls yield for each Serial value in the seen history, in no
+// particular order, until yield returns false. It allows the history to be
+// inspected, exported or selectively pruned, since yield may call the
+// generator's other methods, including UnsetSeen. Values flagged as seen or
+// unset during the call may or may not be visited. Values whose time to live
+// has run out are skipped.
+//
+// RangeSeen has the form of an iterator function, so from Go 1.23 it can be
+// used directly in a for loop:
+//
+//	for x := range gen.RangeSeen {
+//		...
+//	}
+//
+// The values in a history kept in Bloom filters can't be recovered, so for a
+// generator created with WithBloomFilter it visits nothing.
+func (g *Generator) RangeSeen(yield func(Serial) bool) {
+	now := g.clock.Now().UnixNano()
+	g.store.each(func(x Serial, e seenEntry) bool {
+		if e.dead(now) {
+			return true
+		}
+		return yield(x)
+	})
+}
+
+// SeenBetween returns the values in the seen history whose embedded times,
+// as returned by Time, fall within the window from from up to but not
+// including to, in ascending order. It allows the values consumed during a
+// period, such as an incident, to be audited. Like RangeSeen, it skips values
+// whose time to live has run out, and finds nothing in a history kept in
+// Bloom filters.
+func (g *Generator) SeenBetween(from, to time.Time) []Serial {
+	var xs Serials
+	g.RangeSeen(func(x Serial) bool {
+		if t := g.Time(x); !t.Before(from) && t.Before(to) {
+			xs = append(xs, x)
+		}
+		return true
+	})
+	xs.Sort()
+	return xs
+}
+
+// SetSeenFor flags the specified Serial value as having been seen for the
+// specified time to live. Once the time is up, Seen reports the value as
+// unseen, and the next call to ExpireSeen removes it from the history,
+// regardless of the age limit passed. This allows values with different
+// lifetimes to share a generator. If the value is already flagged as seen,
+// its lifetime is unchanged.
+//
+// A history kept in Bloom filters can't track individual lifetimes, so values
+// stay seen until their whole filter expires.
+func (g *Generator) SetSeenFor(x Serial, ttl time.Duration) {
+	now := g.clock.Now().UnixNano()
+	e := seenEntry{deadline: now + int64(ttl)}
+	added, err := g.addSeen(x, e, now)
+	if err != nil {
+		panic(err)
+	}
+	if added {
+		g.flagged(x, e)
+	}
+}
+
+// ExpireSeen clears the history of seen Serial values, using an age limit
+// provided as a time.Duration. All history data older than the specified
+// duration is deleted, along with values flagged by SetSeenFor whose time to
+// live has run out. It returns how many values were removed, and how many
+// remain, as for SeenCount.
+//
+// This function should be called periodically if you are using the Seen flag
+// feature, or else eventually your memory will fill up.
+func (g *Generator) ExpireSeen(agelimit time.Duration) (removed, remaining int) {
+	now := g.clock.Now()
+	removed = g.expireBefore(now.Add(-agelimit), now, 0)
+	return removed, g.store.len()
+}
+
+// expireChunk is the number of entries ExpireSeenAsync visits in each index
+// of a shard of the history before releasing its locks.
+const expireChunk = 1024
+
+// ExpireResult reports the outcome of ExpireSeenAsync.
+type ExpireResult struct {
+	// Removed is the number of values removed.
+	Removed int
+	// Remaining is the number of values left in the history.
+	Remaining int
+}
+
+// ExpireSeenAsync expires the history as for ExpireSeen, but in a new
+// goroutine, and in small chunks, releasing the history's locks between
+// them, so that a large expiry doesn't stall other goroutines' calls to Seen
+// and SetSeen. The result is sent on the returned channel, which is then
+// closed. Values flagged while the expiry is in progress may or may not be
+// expired by it. Only the default history is expired in chunks; other
+// histories are expired all at once, in the background.
+func (g *Generator) ExpireSeenAsync(agelimit time.Duration) <-chan ExpireResult {
+	ch := make(chan ExpireResult, 1)
+	now := g.clock.Now()
+	go func() {
+		removed := g.expireBefore(now.Add(-agelimit), now, expireChunk)
+		ch <- ExpireResult{Removed: removed, Remaining: g.store.len()}
+		close(ch)
+	}()
+	return ch
+}
+
+// ExpireSeenBefore clears the history of seen Serial values generated before
+// the specified time, along with values flagged by SetSeenFor whose time to
+// live has run out, and returns how many were removed. Unlike ExpireSeen, the
+// cutoff doesn't depend on the local clock, so replicas can expire their
+// histories consistently.
+func (g *Generator) ExpireSeenBefore(t time.Time) int {
+	return g.expireBefore(t, g.clock.Now(), 0)
+}
+
+// expireBefore removes values generated before the cutoff, and values whose
+// time to live has run out as of now, and returns how many were removed. If
+// chunk is positive, a history in a mapStore is expired in chunks of that
+// many entries at a time.
+func (g *Generator) expireBefore(cutoff, now time.Time, chunk int) int {
+	limit := g.layout.pack(g.layout.ticks(cutoff), 0, 0)
+	if g.counter {
+		limit = Serial(cutoff.UnixNano())
+	}
+	for {
+		h := g.horizon.Load()
+		if h >= int64(limit) || g.horizon.CompareAndSwap(h, int64(limit)) {
+			break
+		}
+	}
+	if g.mapped != nil {
+		g.mapped.expire(limit)
+	}
+	hooks := g.expireHooks()
+	var xs *[]Serial
+	if len(hooks) > 0 {
+		xs = new([]Serial)
+	}
+	var removed int
+	if ms, ok := g.store.(*mapStore); ok && chunk > 0 {
+		for i := range ms.shards {
+			cs := [2]expiryCursor{{id: math.MinInt64}, {id: math.MinInt64}}
+			for more := true; more; {
+				var n int
+				g.snapMutex.RLock()
+				n, more = ms.expireShard(i, limit, now.UnixNano(), xs, chunk, &cs)
+				g.snapMutex.RUnlock()
+				removed += n
+				runtime.Gosched()
+			}
+		}
+	} else {
+		g.snapMutex.RLock()
+		removed = g.store.expire(limit, now.UnixNano(), xs)
+		g.snapMutex.RUnlock()
+	}
+	g.counters.expirations.Add(1)
+	g.counters.expired.Add(uint64(removed))
+	g.emit(EventExpire, 0, removed)
+	if xs != nil {
+		for _, x := range *xs {
+			for _, f := range hooks {
+				f(x)
+			}
+		}
+	}
+	return removed
+}
+
+// ExpirationLag returns the net growth of the seen history over roughly the
+// last minute, i.e. the number of values flagged with SetSeen minus the number
+// removed by ExpireSeen. A value which stays positive and keeps growing means
+// ExpireSeen isn't being called often enough to keep up with insertions, and
+// memory use will grow without bound.
+func (g *Generator) ExpirationLag() (net int64) {
+	return g.store.lag(g.clock.Now().UnixNano())
+}
+
+// SeenCount returns the number of values in the seen history, so that
+// applications can monitor its size and the memory it is likely to use. It
+// includes values whose time to live has run out but which haven't yet been
+// removed by ExpireSeen. For a history kept in Bloom filters, it counts the
+// values added to the filters which haven't yet expired, though their memory
+// use is fixed.
+func (g *Generator) SeenCount() int {
+	return g.store.len()
+}
@@ -0,0 +1,297 @@
+package serial
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardSpread(t *testing.T) {
+	g := NewGenerator()
+	for i := 0; i < seenShards*100; i++ {
+		g.SetSeen(g.Generate())
+	}
+	st := g.store.(*mapStore)
+	for i := range st.shards {
+		if n := len(st.shards[i].m); n < 50 || n > 150 {
+			t.Errorf("Shard %d has %d values, expected around 100", i, n)
+		}
+	}
+}
+
+func TestConcurrentSeen(t *testing.T) {
+	g := NewGenerator()
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				x := g.Generate()
+				g.SetSeen(x)
+				if !g.Seen(x) {
+					t.Errorf("Flagged value %d as seen, got 'not seen'", x)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := g.SeenCount(); n != 8000 {
+		t.Errorf("History wrong length, expected 8000 got %d", n)
+	}
+}
+
+func BenchmarkSeenParallel(b *testing.B) {
+	g := NewGenerator()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			x := g.Generate()
+			g.SetSeen(x)
+			g.Seen(x)
+		}
+	})
+}
+
+func TestSetSeenFor(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk))
+	short := g.Generate()
+	long := g.Generate()
+	plain := g.Generate()
+	g.SetSeenFor(short, time.Second)
+	g.SetSeenFor(long, time.Hour)
+	g.SetSeen(plain)
+	clk.Advance(2 * time.Second)
+	if g.Seen(short) {
+		t.Errorf("Expected %d unseen after its TTL, got 'seen'", short)
+	}
+	if !g.Seen(long) {
+		t.Errorf("Expected %d still seen, got 'not seen'", long)
+	}
+	g.ExpireSeen(24 * time.Hour)
+	if n := g.SeenCount(); n != 2 {
+		t.Errorf("History wrong length, expected 2 got %d", n)
+	}
+	// A TTL overrides the age limit in both directions.
+	g.ExpireSeen(time.Second)
+	if !g.Seen(long) || g.Seen(plain) {
+		t.Errorf("Expected only %d to remain seen", long)
+	}
+	g.SetSeenFor(short, time.Second)
+	if !g.Seen(short) {
+		t.Errorf("Expected %d seen again, got 'not seen'", short)
+	}
+}
+
+func TestSetSeenForBounded(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk), WithMaxSeen(10))
+	x := g.Generate()
+	g.SetSeenFor(x, time.Second)
+	clk.Advance(2 * time.Second)
+	if g.Seen(x) {
+		t.Errorf("Expected %d unseen after its TTL, got 'seen'", x)
+	}
+	g.SetSeenFor(x, time.Second)
+	if !g.Seen(x) {
+		t.Errorf("Expected %d seen again, got 'not seen'", x)
+	}
+	if n := g.SeenCount(); n != 1 {
+		t.Errorf("History wrong length, expected 1 got %d", n)
+	}
+}
+
+func TestSeenOrSet(t *testing.T) {
+	g := NewGenerator()
+	x := g.Generate()
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	wins := 0
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !g.SeenOrSet(x) {
+				mutex.Lock()
+				wins++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if wins != 1 {
+		t.Errorf("Expected 1 successful redemption got %d", wins)
+	}
+	if !g.Seen(x) {
+		t.Errorf("Expected %d seen, got 'not seen'", x)
+	}
+}
+
+func TestSeenValue(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk))
+	x := g.Generate()
+	y := g.Generate()
+	if v, ok := g.SeenValue(x); ok || v != nil {
+		t.Errorf("Expected nil and 'not seen' got %v and %v", v, ok)
+	}
+	g.SetSeenWithValue(x, "redeemed by alice")
+	g.SetSeenWithValue(x, "redeemed by bob")
+	g.SetSeen(y)
+	if v, ok := g.SeenValue(x); !ok || v != "redeemed by alice" {
+		t.Errorf("Expected first value and 'seen' got %v and %v", v, ok)
+	}
+	if v, ok := g.SeenValue(y); !ok || v != nil {
+		t.Errorf("Expected nil and 'seen' got %v and %v", v, ok)
+	}
+	clk.Advance(time.Second)
+	g.ExpireSeen(0)
+	if _, ok := g.SeenValue(x); ok {
+		t.Errorf("Expected %d expired, got 'seen'", x)
+	}
+}
+
+func TestUnsetSeen(t *testing.T) {
+	for _, g := range []*Generator{NewGenerator(), NewGenerator(WithMaxSeen(100))} {
+		x := g.Generate()
+		y := g.Generate()
+		g.SetSeen(x)
+		g.SetSeen(y)
+		if !g.UnsetSeen(x) {
+			t.Errorf("Expected UnsetSeen(%d) to return true", x)
+		}
+		if g.UnsetSeen(x) {
+			t.Errorf("Expected second UnsetSeen(%d) to return false", x)
+		}
+		if g.Seen(x) || !g.Seen(y) {
+			t.Errorf("Expected only %d to remain seen", y)
+		}
+		if n := g.SeenCount(); n != 1 {
+			t.Errorf("History wrong length, expected 1 got %d", n)
+		}
+	}
+}
+
+func TestRangeSeen(t *testing.T) {
+	for _, g := range []*Generator{NewGenerator(), NewGenerator(WithMaxSeen(1000))} {
+		want := make(map[Serial]bool)
+		for i := 0; i < 500; i++ {
+			x := g.Generate()
+			g.SetSeen(x)
+			want[x] = true
+		}
+		got := 0
+		g.RangeSeen(func(x Serial) bool {
+			if !want[x] {
+				t.Errorf("Unexpected value %d", x)
+			}
+			got++
+			// Prune odd values as we go.
+			if x%2 == 1 {
+				g.UnsetSeen(x)
+			}
+			return true
+		})
+		if got != 500 {
+			t.Errorf("Expected 500 values got %d", got)
+		}
+		g.RangeSeen(func(x Serial) bool {
+			if x%2 == 1 {
+				t.Errorf("Expected %d to have been pruned", x)
+			}
+			return true
+		})
+		got = 0
+		g.RangeSeen(func(x Serial) bool {
+			got++
+			return got < 10
+		})
+		if got != 10 {
+			t.Errorf("Expected iteration to stop after 10 values, got %d", got)
+		}
+	}
+}
+
+func TestSeenBetween(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk))
+	var xs []Serial
+	for i := 0; i < 5; i++ {
+		x := g.Generate()
+		xs = append(xs, x)
+		g.SetSeen(x)
+		clk.Advance(time.Minute)
+	}
+	g.Generate()
+	got := g.SeenBetween(time.Unix(1060, 0), time.Unix(1180, 0))
+	if len(got) != 2 || got[0] != xs[1] || got[1] != xs[2] {
+		t.Errorf("Expected %v got %v", xs[1:3], got)
+	}
+	if got := g.SeenBetween(time.Unix(2000, 0), time.Unix(3000, 0)); len(got) != 0 {
+		t.Errorf("Expected nothing got %v", got)
+	}
+}
+
+func TestExpireSeenBefore(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk))
+	for i := 0; i < 3; i++ {
+		g.SetSeen(g.Generate())
+		clk.Advance(time.Minute)
+	}
+	x := g.Generate()
+	g.SetSeenFor(x, time.Second)
+	clk.Advance(time.Second)
+	if n := g.ExpireSeenBefore(time.Unix(1100, 0)); n != 3 {
+		t.Errorf("Expected 3 removed got %d", n)
+	}
+	if n := g.SeenCount(); n != 1 {
+		t.Errorf("Expected 1 left got %d", n)
+	}
+	if n := g.ExpireSeenBefore(time.Unix(0, 0)); n != 0 {
+		t.Errorf("Expected nothing removed got %d", n)
+	}
+}
+
+func TestExpireSeenCounts(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	g := NewGenerator(WithClock(clock))
+	for i := 0; i < 10; i++ {
+		g.SetSeen(g.Generate())
+	}
+	clock.Advance(time.Hour)
+	for i := 0; i < 5; i++ {
+		g.SetSeen(g.Generate())
+	}
+	if removed, remaining := g.ExpireSeen(time.Minute); removed != 10 || remaining != 5 {
+		t.Errorf("Expected 10 removed and 5 remaining got %d and %d", removed, remaining)
+	}
+}
+
+func TestExpireSeenAsync(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	g := NewGenerator(WithClock(clock))
+	var expired int
+	g.OnExpire(func(Serial) { expired++ })
+	// Spread the values over several buckets, and enough per shard for
+	// several chunks.
+	for i := 0; i < 200000; i++ {
+		g.SetSeen(g.Generate())
+		if i%50000 == 0 {
+			clock.Advance(10 * time.Second)
+		}
+	}
+	g.SetSeenFor(g.Generate(), time.Minute)
+	clock.Advance(time.Hour)
+	keep := g.Generate()
+	g.SetSeen(keep)
+	g.SetSeenFor(g.Generate(), 2*time.Hour)
+	res := <-g.ExpireSeenAsync(time.Minute)
+	if res.Removed != 200001 || res.Remaining != 2 || expired != 200001 {
+		t.Errorf("Expected 200001 removed and 2 remaining got %+v, with %d hook calls", res, expired)
+	}
+	if !g.Seen(keep) {
+		t.Errorf("Expected %d to remain", keep)
+	}
+}
@@ -0,0 +1,178 @@
+package serial
+
+import (
+	"sync"
+	"time"
+)
+
+// SeenEntry holds the details recorded for a value in the seen history.
+type SeenEntry struct {
+	// Expires is the time at which the entry expires, if it was flagged with
+	// SetSeenFor, or the zero time if it expires by age.
+	Expires time.Time
+	// Value is the value attached by SetSeenWithValue, if any.
+	Value interface{}
+}
+
+// SeenStore is implemented by backends which hold the seen history for a
+// Generator, such as persistent or distributed stores. The default history
+// is kept in memory. A SeenStore must be safe for concurrent use.
+//
+// A SeenStore has no way to report errors to the generator. If a backend
+// can fail, it should fail closed, so that Has reports values as seen when
+// it can't be sure, and let the application know some other way.
+type SeenStore interface {
+	// Set records x as seen with the details in e, and reports whether it
+	// wasn't already. An entry which has expired as of now, but hasn't yet
+	// been removed, counts as not seen and should be replaced.
+	Set(x Serial, e SeenEntry, now time.Time) bool
+	// Has reports whether x has been seen, and returns its entry. It may
+	// return entries which have expired but haven't yet been removed.
+	Has(x Serial) (SeenEntry, bool)
+	// Delete removes x, and reports whether it was present.
+	Delete(x Serial) bool
+	// ExpireBefore removes entries which expire no later than now, and
+	// entries without an expiry time whose values are less than limit. It
+	// returns how many were removed.
+	ExpireBefore(limit Serial, now time.Time) int
+	// Range calls f for every entry, in no particular order, until f
+	// returns false. It must not hold locks while f runs, so that f can
+	// modify the history.
+	Range(f func(x Serial, e SeenEntry) bool)
+	// Len returns the number of entries.
+	Len() int
+}
+
+// WithSeenStore keeps the generator's seen history in the specified store
+// rather than in memory.
+func WithSeenStore(st SeenStore) Option {
+	return func(g *Generator) error {
+		if ms, ok := st.(MemoryStore); ok {
+			g.store = ms.st
+		} else {
+			g.store = &externalStore{st: st}
+		}
+		return nil
+	}
+}
+
+// MemoryStore is the SeenStore which holds a generator's seen history by
+// default. It is exported so that other stores can build on it, for example
+// to cache a slower backend.
+type MemoryStore struct {
+	st *mapStore
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() MemoryStore {
+	return MemoryStore{st: newMapStore()}
+}
+
+// Set implements SeenStore.
+func (ms MemoryStore) Set(x Serial, e SeenEntry, now time.Time) bool {
+	return ms.st.add(x, importEntry(e), now.UnixNano())
+}
+
+// Has implements SeenStore.
+func (ms MemoryStore) Has(x Serial) (SeenEntry, bool) {
+	e, ok := ms.st.has(x)
+	return exportEntry(e), ok
+}
+
+// Delete implements SeenStore.
+func (ms MemoryStore) Delete(x Serial) bool {
+	return ms.st.remove(x, time.Now().UnixNano())
+}
+
+// ExpireBefore implements SeenStore.
+func (ms MemoryStore) ExpireBefore(limit Serial, now time.Time) int {
+	return ms.st.expire(limit, now.UnixNano(), nil)
+}
+
+// Range implements SeenStore.
+func (ms MemoryStore) Range(f func(x Serial, e SeenEntry) bool) {
+	ms.st.each(func(x Serial, e seenEntry) bool {
+		return f(x, exportEntry(e))
+	})
+}
+
+// Len implements SeenStore.
+func (ms MemoryStore) Len() int {
+	return ms.st.len()
+}
+
+// externalStore adapts a SeenStore to the seenStore used internally, and
+// keeps track of its lag.
+type externalStore struct {
+	st    SeenStore
+	mutex sync.Mutex
+	lagw  lagWindow
+}
+
+func (st *externalStore) record(now int64, delta int64) {
+	st.mutex.Lock()
+	st.lagw.record(now, delta)
+	st.mutex.Unlock()
+}
+
+func (st *externalStore) add(x Serial, e seenEntry, now int64) bool {
+	if !st.st.Set(x, exportEntry(e), time.Unix(0, now)) {
+		return false
+	}
+	st.record(now, 1)
+	return true
+}
+
+func (st *externalStore) has(x Serial) (seenEntry, bool) {
+	e, ok := st.st.Has(x)
+	return importEntry(e), ok
+}
+
+func (st *externalStore) remove(x Serial, now int64) bool {
+	if !st.st.Delete(x) {
+		return false
+	}
+	st.record(now, -1)
+	return true
+}
+
+func (st *externalStore) each(f func(x Serial, e seenEntry) bool) {
+	st.st.Range(func(x Serial, e SeenEntry) bool {
+		return f(x, importEntry(e))
+	})
+}
+
+// expire never reports the values removed, since SeenStore doesn't.
+func (st *externalStore) expire(limit Serial, now int64, _ *[]Serial) int {
+	removed := st.st.ExpireBefore(limit, time.Unix(0, now))
+	st.record(now, -int64(removed))
+	return removed
+}
+
+func (st *externalStore) lag(now int64) int64 {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	return st.lagw.sum(now)
+}
+
+func (st *externalStore) len() int {
+	return st.st.Len()
+}
+
+// exportEntry converts an internal entry to a SeenEntry.
+func exportEntry(e seenEntry) SeenEntry {
+	se := SeenEntry{Value: e.value}
+	if e.deadline > 0 {
+		se.Expires = time.Unix(0, e.deadline)
+	}
+	return se
+}
+
+// importEntry converts a SeenEntry to an internal entry.
+func importEntry(se SeenEntry) seenEntry {
+	e := seenEntry{value: se.Value}
+	if !se.Expires.IsZero() {
+		e.deadline = se.Expires.UnixNano()
+	}
+	return e
+}
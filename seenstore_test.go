@@ -0,0 +1,132 @@
+package serial
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// testStore is a minimal SeenStore, as a third party might write one.
+type testStore struct {
+	mutex sync.Mutex
+	m     map[Serial]SeenEntry
+}
+
+func (st *testStore) Set(x Serial, e SeenEntry, now time.Time) bool {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	if old, ok := st.m[x]; ok && (old.Expires.IsZero() || old.Expires.After(now)) {
+		return false
+	}
+	st.m[x] = e
+	return true
+}
+
+func (st *testStore) Has(x Serial) (SeenEntry, bool) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	e, ok := st.m[x]
+	return e, ok
+}
+
+func (st *testStore) Delete(x Serial) bool {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	_, ok := st.m[x]
+	delete(st.m, x)
+	return ok
+}
+
+func (st *testStore) ExpireBefore(limit Serial, now time.Time) int {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	n := 0
+	for x, e := range st.m {
+		if (!e.Expires.IsZero() && !e.Expires.After(now)) || (e.Expires.IsZero() && x < limit) {
+			delete(st.m, x)
+			n++
+		}
+	}
+	return n
+}
+
+func (st *testStore) Range(f func(x Serial, e SeenEntry) bool) {
+	st.mutex.Lock()
+	m := make(map[Serial]SeenEntry, len(st.m))
+	for x, e := range st.m {
+		m[x] = e
+	}
+	st.mutex.Unlock()
+	for x, e := range m {
+		if !f(x, e) {
+			return
+		}
+	}
+}
+
+func (st *testStore) Len() int {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	return len(st.m)
+}
+
+func TestWithSeenStore(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	st := &testStore{m: make(map[Serial]SeenEntry)}
+	g := NewGenerator(WithClock(clk), WithSeenStore(st))
+	x := g.Generate()
+	y := g.Generate()
+	z := g.Generate()
+	g.SetSeenWithValue(x, "alice")
+	g.SetSeenFor(y, time.Second)
+	if g.SeenOrSet(z) {
+		t.Errorf("Expected %d not seen before SeenOrSet", z)
+	}
+	if st.Len() != 3 {
+		t.Errorf("Expected 3 values in store got %d", st.Len())
+	}
+	if v, ok := g.SeenValue(x); !ok || v != "alice" {
+		t.Errorf("Expected alice and 'seen' got %v and %v", v, ok)
+	}
+	if n := g.ExpirationLag(); n != 3 {
+		t.Errorf("Expected lag 3 got %d", n)
+	}
+	clk.Advance(2 * time.Second)
+	if g.Seen(y) {
+		t.Errorf("Expected %d unseen after its TTL, got 'seen'", y)
+	}
+	g.ExpireSeen(time.Hour)
+	if !g.UnsetSeen(z) {
+		t.Errorf("Expected UnsetSeen(%d) to return true", z)
+	}
+	if n := g.SeenCount(); n != 1 {
+		t.Errorf("History wrong length, expected 1 got %d", n)
+	}
+	if e, ok := st.Has(x); !ok || e.Value != "alice" || !e.Expires.IsZero() {
+		t.Errorf("Unexpected entry in store: %v %v", e, ok)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ms := NewMemoryStore()
+	g := NewGenerator(WithSeenStore(ms))
+	x := g.Generate()
+	g.SetSeenWithValue(x, 42)
+	if e, ok := ms.Has(x); !ok || e.Value != 42 {
+		t.Errorf("Expected 42 and 'seen' got %v and %v", e.Value, ok)
+	}
+	now := time.Now()
+	y := g.Generate()
+	if !ms.Set(y, SeenEntry{Expires: now.Add(time.Second)}, now) {
+		t.Errorf("Expected Set(%d) to return true", y)
+	}
+	if !g.Seen(y) {
+		t.Errorf("Expected %d seen, got 'not seen'", y)
+	}
+	if n := ms.ExpireBefore(y+1, now.Add(2*time.Second)); n != 2 {
+		t.Errorf("Expected 2 values expired got %d", n)
+	}
+	if ms.Len() != 0 {
+		t.Errorf("Expected empty store got %d values", ms.Len())
+	}
+}
@@ -5,77 +5,610 @@
 package serial
 
 import (
+	"context"
+	"errors"
+	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Serial is a unique serial number.
 type Serial int64
 
+// Time returns the time embedded in the serial value. Since serials are
+// generated from the Unix time in nanoseconds, this is the approximate time
+// at which the value was generated. It assumes the default NanosecondLayout;
+// for values from a generator with a different layout or epoch, use the
+// generator's Time method instead.
+//
+// When values are generated faster than the clock ticks, the generator pushes
+// the timestamp forward to keep them unique, so the embedded time can be
+// slightly later than the actual time of generation.
+func (x Serial) Time() time.Time {
+	return time.Unix(0, int64(x))
+}
+
+// Age returns how long ago the serial value was generated, according to its
+// embedded time as returned by Time. Since the embedded time can be slightly
+// ahead of the actual time of generation, a very recent value can have a
+// negative age.
+func (x Serial) Age() time.Duration {
+	return time.Since(x.Time())
+}
+
+// PartitionByTime groups a batch of serial values into buckets of the
+// specified duration, according to their embedded times. The map is keyed by
+// the start time of each bucket, in UTC, and each bucket's values are in the
+// same order as they appeared in xs. Buckets are aligned as per
+// time.Time.Truncate, so a bucket of 24 hours runs from midnight to midnight
+// UTC.
+func PartitionByTime(xs []Serial, bucket time.Duration) map[time.Time][]Serial {
+	parts := make(map[time.Time][]Serial)
+	for _, x := range xs {
+		k := x.Time().UTC().Truncate(bucket)
+		parts[k] = append(parts[k], x)
+	}
+	return parts
+}
+
+// Range is a contiguous, inclusive range of serial values. A range whose
+// First is greater than its Last is empty.
+type Range struct {
+	First Serial
+	Last  Serial
+}
+
+// Len returns the number of serial values in the range.
+func (r Range) Len() int64 {
+	return int64(r.Last-r.First) + 1
+}
+
+// Contains reports whether x is in the range.
+func (r Range) Contains(x Serial) bool {
+	return r.First <= x && x <= r.Last
+}
+
+// Overlaps reports whether the two ranges have any values in common.
+func (r Range) Overlaps(o Range) bool {
+	return r.First <= r.Last && o.First <= o.Last && r.First <= o.Last && o.First <= r.Last
+}
+
+// Each calls f with each value in the range, in ascending order, until f
+// returns false.
+func (r Range) Each(f func(x Serial) bool) {
+	for x := r.First; x <= r.Last; x++ {
+		if !f(x) || x == r.Last {
+			// Stopping at Last avoids overflow when it is the largest
+			// possible value.
+			return
+		}
+	}
+}
+
+// Serials is a slice of serial values.
+type Serials []Serial
+
+// Gaps returns the ranges of values missing from a sorted slice of serials,
+// for auditing allocation schemes where every value should be accounted for.
+// Values are expected to be step apart, so a gap is reported between any two
+// consecutive values more than step apart, running from the first to the last
+// expected value which is missing, counting in steps from the value before
+// the gap. If the value after the gap isn't a whole number of steps on, the
+// gap still ends on a step, so First is never after Last.
+// Duplicate values are ignored.
+//
+// Serials from a Generator are based on the clock, so large gaps between them
+// are normal and Gaps will report almost every interval; it is only useful for
+// values allocated sequentially, such as those handed out from a reserved
+// block. It panics if step is less than 1.
+func (xs Serials) Gaps(step int64) []Range {
+	if step < 1 {
+		panic("serial: step must be positive")
+	}
+	var gaps []Range
+	for i := 1; i < len(xs); i++ {
+		a, b := xs[i-1], xs[i]
+		if d := int64(b - a); d > step {
+			gaps = append(gaps, Range{First: a + Serial(step), Last: a + Serial((d-1)/step*step)})
+		}
+	}
+	return gaps
+}
+
 // Generator defines a generator of unique serial numbers. You can run any
 // number of independent generators for different serial number problem
 // domains, each with its own mutexes for thread safety.
 type Generator struct {
-	lastmutex  sync.RWMutex
-	lastSerial Serial
-	seenmutex  sync.RWMutex
-	seen       map[Serial]struct{}
-}
-
-// NewGenerator creates and initializes a new serial number generator.
-func NewGenerator() *Generator {
-	gen := &Generator{}
-	gen.seenmutex.Lock()
-	gen.seen = make(map[Serial]struct{})
-	gen.seenmutex.Unlock()
-	return gen
+	opts        []Option
+	layout      Layout
+	node        int64
+	nodeSources []NodeSource
+	randomBits  uint
+	entropy     io.Reader
+	counter     bool
+	clock       Clock
+	lastSerial  atomic.Int64
+	lastClock   atomic.Int64
+	lastmutex   sync.RWMutex
+	last128     Serial128
+	rollback    RollbackPolicy
+	onRollback  func(time.Duration)
+	waitClock   bool
+	maxSkew     time.Duration
+	startAfter  Serial
+	resume      bool
+	onSkew      func(time.Duration)
+	startup     *startupCheck
+	store       seenStore
+	snapMutex   sync.RWMutex
+
+	checkpoint Checkpointer
+	lead       time.Duration
+	saved      atomic.Int64
+	cpmutex    sync.Mutex
+	journal    *Journal
+	mapped     *MappedState
+	margin     time.Duration
+	leaser     *leaser
+	limiter    *limiter
+	quota      *quota
+
+	autoInterval time.Duration
+	autoAge      time.Duration
+	compactAfter time.Duration
+	drift        *driftMonitor
+	pool         *pool
+	stop         chan struct{}
+	done         chan struct{}
+	closeOnce    sync.Once
+	closed       atomic.Bool
+
+	counters counters
+	horizon  atomic.Int64
+
+	audit      *auditor
+	hookMutex  sync.Mutex
+	onExpire   []func(Serial)
+	onGenerate atomic.Value
+	onSeen     atomic.Value
+
+	eventsOnce sync.Once
+	events     atomic.Value
+	dropped    atomic.Uint64
 }
 
-// Seen returns a boolean to indicate whether the specified Serial value has
-// been seen. Serial values are unseen until SetSeen is called. Once they have
-// been set as seen, they remain seen until history is expired.
-func (g *Generator) Seen(x Serial) bool {
-	g.seenmutex.RLock()
-	_, ok := g.seen[x]
-	g.seenmutex.RUnlock()
-	return ok
+// ErrNodeRange is returned when asked to create a generator with a node ID
+// which doesn't fit in the generated serial values.
+var ErrNodeRange = errors.New("serial: node ID out of range")
+
+var (
+	// ErrBlockSize is returned by Reserve when asked for a block of fewer
+	// than one value.
+	ErrBlockSize = errors.New("serial: block size must be positive")
+	// ErrBlockLayout is returned by Reserve when the generator embeds node
+	// IDs or random bits in its values, so they can't be reserved in
+	// contiguous blocks.
+	ErrBlockLayout = errors.New("serial: cannot reserve a block with node IDs or random bits")
+)
+
+// New creates and initializes a new serial number generator, configured by
+// the options provided. It returns an error if the options are invalid.
+func New(opts ...Option) (*Generator, error) {
+	gen := &Generator{opts: append([]Option(nil), opts...), layout: NanosecondLayout, clock: systemClock{}}
+	for _, opt := range opts {
+		if err := opt(gen); err != nil {
+			return nil, err
+		}
+	}
+	if gen.nodeSources != nil {
+		node, err := DeriveNode(gen.layout, gen.nodeSources...)
+		if err != nil {
+			return nil, err
+		}
+		gen.node = node
+	}
+	if gen.node < 0 || gen.node > gen.layout.maxNode() {
+		return nil, ErrNodeRange
+	}
+	if gen.layout.Epoch.After(gen.clock.Now()) {
+		return nil, ErrEpoch
+	}
+	if gen.randomBits > 0 && (gen.leaser != nil || gen.layout.NodeBits > 0 && gen.randomBits > gen.layout.SequenceBits) {
+		return nil, ErrRandomBits
+	}
+	if gen.compactAfter > 0 {
+		if gen.store != nil || gen.counter {
+			return nil, ErrCompaction
+		}
+		gen.store = newTieredStore(gen.layout, gen.compactAfter)
+	}
+	if gen.store == nil {
+		gen.store = newMapStore()
+	}
+	if gen.counter {
+		if _, ok := gen.store.(*externalStore); ok || gen.leaser != nil || gen.randomBits > 0 || gen.layout.NodeBits > 0 {
+			return nil, ErrCounter
+		}
+	}
+	if err := gen.loadCheckpoint(); err != nil {
+		return nil, err
+	}
+	if err := gen.loadMapped(); err != nil {
+		return nil, err
+	}
+	gen.resumeFrom()
+	if gen.autoInterval > 0 || gen.drift != nil {
+		gen.stop = make(chan struct{})
+		gen.done = make(chan struct{})
+		go gen.background()
+	}
+	return gen, nil
 }
 
-// SetSeen flags the specified Serial value as having been seen. This can
-// then be interrogated using the Seen() method.
-func (g *Generator) SetSeen(x Serial) {
-	g.seenmutex.Lock()
-	g.seen[x] = struct{}{}
-	g.seenmutex.Unlock()
+// NewGenerator creates and initializes a new serial number generator. It is
+// like New, but panics if the options are invalid, for use when they are
+// fixed in the program. With no options, it generates serials based on Unix
+// time in nanoseconds.
+func NewGenerator(opts ...Option) *Generator {
+	gen, err := New(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return gen
 }
 
-// ExpireSeen clears the history of seen Serial values, using an age limit
-// provided as a time.Duration. All history data older than the specified
-// duration is deleted.
-//
-// This function should be called periodically if you are using the Seen flag
-// feature, or else eventually your memory will fill up.
-func (g *Generator) ExpireSeen(agelimit time.Duration) {
-	g.seenmutex.Lock()
-	limit := time.Now().Add(-agelimit).UnixNano()
-	for tok := range g.seen {
-		if int64(tok) < limit {
-			delete(g.seen, tok)
-		}
+// NewDeterministicGenerator creates a generator which returns a reproducible
+// sequence of serial values, start, start+1, start+2 and so on, so that tests
+// of code which consumes serials can produce stable output. It works by
+// stopping the generator's clock at the time embedded in start, according to
+// the layout the options leave it with, so seen history only expires
+// relative to that time. For a layout with node bits, start should carry the
+// generator's node ID. KSUIDs from the generator still have a random
+// component. The options shouldn't include WithClock. Like NewGenerator, it
+// panics if the options are invalid.
+func NewDeterministicGenerator(start Serial, opts ...Option) *Generator {
+	stop := func(g *Generator) error {
+		g.clock = startClock{g: g, start: start}
+		return nil
 	}
-	g.seenmutex.Unlock()
+	return NewGenerator(append([]Option{stop, WithStartAfter(start - 1)}, opts...)...)
+}
+
+// NewSnowflakeGenerator creates a generator whose serial values are composed
+// like Twitter's Snowflake IDs, so that independent generators on different
+// machines can issue serials without coordination. It is equivalent to
+// calling New with SnowflakeLayout and the specified node ID, which must be
+// from 0 to 1023, followed by any other options.
+func NewSnowflakeGenerator(node int64, opts ...Option) (*Generator, error) {
+	return New(append([]Option{WithLayout(SnowflakeLayout), WithNode(node)}, opts...)...)
 }
 
 // Generate generates a serial value based on Unix time in nanoseconds.
 // You are guaranteed to get a different value each time you call the function.
 // The value will be no earlier than the current Unix epoch time in nanoseconds.
+// (For a generator with a different Layout, the embedded timestamp will be
+// no earlier than the current time at the layout's resolution.)
+//
+// Generate panics if the generator's RollbackPolicy is RollbackError and the
+// clock has moved backwards, or with ErrClosed if the generator has been
+// closed. Code which may still be running while the generator is closed,
+// such as a request handler during a graceful shutdown, should use
+// TryGenerate instead, and fail the one request.
 func (g *Generator) Generate() Serial {
-	g.lastmutex.Lock()
-	id := Serial(time.Now().UnixNano())
-	if id <= g.lastSerial {
-		id = g.lastSerial + 1
+	id, err := g.TryGenerate()
+	if err != nil {
+		panic(err)
 	}
-	g.lastSerial = id
-	g.lastmutex.Unlock()
 	return id
 }
+
+// TryGenerate is like Generate, but returns an error rather than panicking
+// if a value can't be generated, including ErrClosed once the generator has
+// been closed.
+func (g *Generator) TryGenerate() (Serial, error) {
+	return g.GenerateCtx(context.Background())
+}
+
+// GenerateCtx is like TryGenerate, but gives up if the context is done while
+// the generator is waiting: for the clock to catch up under the RollbackWait
+// policy or WithWaitForClock, for its rate limit, or before leasing a new
+// block of values. It then returns the context's error.
+func (g *Generator) GenerateCtx(ctx context.Context) (Serial, error) {
+	if err := g.checkOpen(); err != nil {
+		return 0, err
+	}
+	if err := g.limit(ctx, 1); err != nil {
+		return 0, err
+	}
+	if g.pool != nil {
+		if x, ok := g.pool.take(g); ok {
+			return x, nil
+		}
+	}
+	x, err := g.generate(ctx)
+	if err != nil {
+		g.unlimit(1)
+	}
+	return x, err
+}
+
+// generate issues a value for GenerateCtx, without checking limits.
+func (g *Generator) generate(ctx context.Context) (Serial, error) {
+	if g.leaser != nil {
+		var xs [1]Serial
+		err := g.fillLeased(ctx, xs[:])
+		return xs[0], err
+	}
+	var r [1]uint64
+	if err := g.random(r[:]); err != nil {
+		return 0, err
+	}
+	now, back, err := g.advance(ctx)
+	g.rolledBack(back)
+	if err != nil {
+		return 0, err
+	}
+	g.lockJournal()
+	var (
+		id   Serial
+		skew time.Duration
+	)
+	for {
+		last := g.lastSerial.Load()
+		id = g.next(Serial(last), now, r[0])
+		if skew, err = g.check(Serial(last), id, now); err != nil {
+			g.unlockJournal(now)
+			return 0, err
+		}
+		if g.lastSerial.CompareAndSwap(last, int64(id)) {
+			break
+		}
+	}
+	if err := g.unlockJournal(now, Range{First: id, Last: id}); err != nil {
+		return 0, err
+	}
+	g.skewed(skew)
+	g.persist(id)
+	if err := g.checkpointed(id); err != nil {
+		return 0, err
+	}
+	g.countRange(now, Range{First: id, Last: id})
+	g.generated([]Serial{id})
+	if err := g.waitFor(ctx, id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// GenerateN generates n serial values, in ascending order. It is equivalent
+// to calling Generate n times, but only contends with other callers once, so
+// is faster for allocating large batches of IDs. It panics in the same
+// circumstances as Fill; use TryFill to handle errors.
+func (g *Generator) GenerateN(n int) []Serial {
+	xs := make([]Serial, n)
+	g.Fill(xs)
+	return xs
+}
+
+// Fill fills the slice with newly generated serial values, in ascending
+// order, as for GenerateN. Like Generate, it panics if the clock has moved
+// backwards under the RollbackError policy, or with ErrClosed if the
+// generator has been closed; use TryFill to handle those cases.
+func (g *Generator) Fill(xs []Serial) {
+	if err := g.TryFill(xs); err != nil {
+		panic(err)
+	}
+}
+
+// TryFill is like Fill, but returns an error rather than panicking if the
+// values can't be generated, including ErrClosed once the generator has been
+// closed. The contents of the slice are then unspecified.
+func (g *Generator) TryFill(xs []Serial) error {
+	if len(xs) == 0 {
+		return nil
+	}
+	if err := g.checkOpen(); err != nil {
+		return err
+	}
+	if err := g.limit(context.Background(), len(xs)); err != nil {
+		return err
+	}
+	if err := g.fill(xs); err != nil {
+		g.unlimit(len(xs))
+		return err
+	}
+	return nil
+}
+
+// fill issues the values for TryFill, without checking limits.
+func (g *Generator) fill(xs []Serial) error {
+	if g.leaser != nil {
+		return g.fillLeased(context.Background(), xs)
+	}
+	var rnd []uint64
+	if g.randomBits > 0 {
+		rnd = make([]uint64, len(xs))
+		if err := g.random(rnd); err != nil {
+			return err
+		}
+	}
+	now, back, err := g.advance(context.Background())
+	g.rolledBack(back)
+	if err != nil {
+		return err
+	}
+	g.lockJournal()
+	var skew time.Duration
+	for {
+		first := g.lastSerial.Load()
+		last := Serial(first)
+		for i := range xs {
+			var r uint64
+			if rnd != nil {
+				r = rnd[i]
+			}
+			last = g.next(last, now, r)
+			xs[i] = last
+		}
+		if skew, err = g.check(Serial(first), last, now); err != nil {
+			g.unlockJournal(now)
+			return err
+		}
+		if g.lastSerial.CompareAndSwap(first, int64(last)) {
+			break
+		}
+	}
+	var rs []Range
+	if g.journal != nil {
+		rs = runs(xs)
+	}
+	if err := g.unlockJournal(now, rs...); err != nil {
+		return err
+	}
+	g.skewed(skew)
+	g.persist(xs[len(xs)-1])
+	if err := g.checkpointed(xs[len(xs)-1]); err != nil {
+		return err
+	}
+	g.countSerials(now, xs)
+	g.generated(xs)
+	return g.waitFor(context.Background(), xs[len(xs)-1])
+}
+
+// ReserveBlock claims a contiguous block of n serial values, none of which
+// will ever be returned by Generate. The block starts no earlier than the
+// current Unix epoch time in nanoseconds. It panics if n is less than 1, or
+// if the generator embeds a node ID or random bits in its values, since a
+// contiguous block would then overlap other nodes' serials. Like Generate, it
+// also panics if the clock has moved backwards under the RollbackError
+// policy, or with ErrClosed if the generator has been closed; use Reserve to
+// handle errors.
+func (g *Generator) ReserveBlock(n int) Range {
+	r, err := g.reserve(n)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Reserve is like ReserveBlock, but returns the first value of the block,
+// and an error rather than panicking if the block can't be claimed, including
+// ErrClosed once the generator has been closed. The
+// block runs from first to first+n-1, and the generator is moved on past it,
+// so its values are never issued by the generator again, which allows ranges
+// to be assigned in advance to clients which will use them offline.
+func (g *Generator) Reserve(n int) (first Serial, err error) {
+	r, err := g.reserve(n)
+	return r.First, err
+}
+
+// reserve claims a block of n values for ReserveBlock and Reserve.
+func (g *Generator) reserve(n int) (Range, error) {
+	if n < 1 {
+		return Range{}, ErrBlockSize
+	}
+	if err := g.checkOpen(); err != nil {
+		return Range{}, err
+	}
+	if g.leaser == nil && (g.layout.NodeBits > 0 || g.randomBits > 0) {
+		return Range{}, ErrBlockLayout
+	}
+	if err := g.limit(context.Background(), n); err != nil {
+		return Range{}, err
+	}
+	r, err := g.claim(n)
+	if err != nil {
+		g.unlimit(n)
+	}
+	return r, err
+}
+
+// claim claims the block for reserve, without checking limits.
+func (g *Generator) claim(n int) (Range, error) {
+	if g.leaser != nil {
+		r, err := g.reserveLeased(n)
+		if err != nil {
+			return Range{}, err
+		}
+		g.countRange(g.clock.Now(), r)
+		return r, nil
+	}
+	now, back, err := g.advance(context.Background())
+	g.rolledBack(back)
+	if err != nil {
+		return Range{}, err
+	}
+	g.lockJournal()
+	var (
+		r    Range
+		skew time.Duration
+	)
+	for {
+		last := g.lastSerial.Load()
+		first := g.next(Serial(last), now, 0)
+		r = Range{First: first, Last: first + Serial(n-1)}
+		if skew, err = g.check(Serial(last), r.Last, now); err != nil {
+			g.unlockJournal(now)
+			return Range{}, err
+		}
+		if g.lastSerial.CompareAndSwap(last, int64(r.Last)) {
+			break
+		}
+	}
+	if err := g.unlockJournal(now, r); err != nil {
+		return Range{}, err
+	}
+	g.skewed(skew)
+	g.persist(r.Last)
+	if err := g.checkpointed(r.Last); err != nil {
+		return Range{}, err
+	}
+	g.countRange(now, r)
+	// As for TryFill, a failed wait leaves the block unused, since the
+	// generator has already moved past it.
+	if err := g.waitFor(context.Background(), r.Last); err != nil {
+		return Range{}, err
+	}
+	return r, nil
+}
+
+// Last returns the generator's high-water mark: the most recently issued
+// value, without generating a new one. Every value the generator issues from
+// now on is greater. The mark is also moved on by anything which moves the
+// generator past a value without issuing it, such as loading a checkpoint,
+// Restore, ImportSeen or Observe. It is zero if the generator hasn't issued
+// or been moved past anything.
+func (g *Generator) Last() Serial {
+	return Serial(g.lastSerial.Load())
+}
+
+// LastTime returns the time embedded in the value returned by Last, as for
+// Time, or the zero time if Last returns zero.
+func (g *Generator) LastTime() time.Time {
+	x := g.Last()
+	if x == 0 {
+		return time.Time{}
+	}
+	return g.Time(x)
+}
+
+// Between returns the range of values whose embedded times, as returned by
+// Time, fall within the window from from up to but not including to, as for
+// Layout.Between with the generator's layout.
+func (g *Generator) Between(from, to time.Time) Range {
+	return g.layout.Between(from, to)
+}
+
+// Time returns the time embedded in a serial value from the generator, taking
+// account of its layout and epoch. As for Serial.Time, the embedded time can
+// be slightly later than the actual time of generation.
+func (g *Generator) Time(x Serial) time.Time {
+	return g.layout.timeOf(x)
+}
+
+// Age returns how long ago a serial value from the generator was generated,
+// according to its embedded time as returned by Time.
+func (g *Generator) Age(x Serial) time.Duration {
+	return g.clock.Now().Sub(g.Time(x))
+}
@@ -0,0 +1,24 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSerial128(t *testing.T) {
+	g := NewGenerator()
+	prev := g.Generate128()
+	for i := 0; i < 10000; i++ {
+		x := g.Generate128()
+		if !prev.Less(x) {
+			t.Fatalf("Values out of order: %v then %v", prev, x)
+		}
+		if x.String() <= prev.String() {
+			t.Fatalf("Strings out of order: %v then %v", prev, x)
+		}
+		prev = x
+	}
+	if d := time.Since(prev.Time()); d < 0 || d > time.Second {
+		t.Errorf("Embedded timestamp is %v away from now", d)
+	}
+}
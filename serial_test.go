@@ -1,6 +1,7 @@
 package serial
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -45,14 +46,14 @@ func TestGC(t *testing.T) {
 		gen.SetSeen(v)
 		time.Sleep(time.Second / 10)
 	}
-	before := len(gen.seen)
+	before := gen.SeenCount()
 	if before != 100 {
 		t.Errorf("History wrong length, expected 100 got %d", before)
 	}
 	// 5050 = 5 seconds plus a little slop to make sure we don't occasionally
 	// fail for no good reason
 	gen.ExpireSeen(time.Millisecond * 5050)
-	after := len(gen.seen)
+	after := gen.SeenCount()
 	if after != 50 {
 		t.Errorf("History wrong length after expire, expected 50 got %d", after)
 	}
@@ -62,7 +63,280 @@ func TestGC(t *testing.T) {
 			count++
 		}
 	}
-	if count != len(gen.seen) {
+	if count != gen.SeenCount() {
 		t.Errorf("History had wrong number of values expected %d got %d", count, after)
 	}
 }
+
+func TestExpirationLag(t *testing.T) {
+	g := NewGenerator()
+	for i := 0; i < 10; i++ {
+		g.SetSeen(g.Generate())
+	}
+	if lag := g.ExpirationLag(); lag != 10 {
+		t.Errorf("Wrong lag after insertions, expected 10 got %d", lag)
+	}
+	g.ExpireSeen(time.Duration(0))
+	if lag := g.ExpirationLag(); lag != 0 {
+		t.Errorf("Wrong lag after expiring everything, expected 0 got %d", lag)
+	}
+}
+
+func TestPartitionByTime(t *testing.T) {
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	xs := []Serial{
+		Serial(day.Add(time.Minute).UnixNano()),
+		Serial(day.Add(time.Hour + time.Second).UnixNano()),
+		Serial(day.Add(2 * time.Minute).UnixNano()),
+	}
+	parts := PartitionByTime(xs, time.Hour)
+	if len(parts) != 2 {
+		t.Fatalf("Wrong number of buckets, expected 2 got %d", len(parts))
+	}
+	first := parts[day]
+	if len(first) != 2 || first[0] != xs[0] || first[1] != xs[2] {
+		t.Errorf("Wrong first bucket, got %v", first)
+	}
+	second := parts[day.Add(time.Hour)]
+	if len(second) != 1 || second[0] != xs[1] {
+		t.Errorf("Wrong second bucket, got %v", second)
+	}
+}
+
+func TestGaps(t *testing.T) {
+	xs := Serials{1, 2, 3, 6, 7, 7, 10}
+	gaps := xs.Gaps(1)
+	if len(gaps) != 2 || gaps[0] != (Range{4, 5}) || gaps[1] != (Range{8, 9}) {
+		t.Errorf("Wrong gaps with step 1, got %v", gaps)
+	}
+	xs = Serials{10, 20, 50}
+	gaps = xs.Gaps(10)
+	if len(gaps) != 1 || gaps[0] != (Range{30, 40}) {
+		t.Errorf("Wrong gaps with step 10, got %v", gaps)
+	}
+	if gaps = (Serials{1, 2, 3}).Gaps(1); gaps != nil {
+		t.Errorf("Found gaps in contiguous values: %v", gaps)
+	}
+	// Values which aren't a whole number of steps apart.
+	gaps = (Serials{0, 11, 15, 40}).Gaps(10)
+	if len(gaps) != 2 || gaps[0] != (Range{10, 10}) || gaps[1] != (Range{25, 35}) {
+		t.Errorf("Wrong gaps for misaligned values, got %v", gaps)
+	}
+	for _, r := range gaps {
+		if r.First > r.Last || !r.Contains(r.First) || !r.Contains(r.Last) {
+			t.Errorf("Inconsistent gap %v", r)
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	x := gen.Generate()
+	if d := x.Age(); d < -time.Second || d > time.Second {
+		t.Errorf("Age of new value is %v", d)
+	}
+	epoch := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := NewGenerator(WithLayout(SnowflakeLayout), WithEpoch(epoch))
+	x = g.Generate()
+	if d := g.Age(x); d < -time.Second || d > time.Second {
+		t.Errorf("Age of new value with epoch is %v", d)
+	}
+	if tm := g.Time(x); tm.Nanosecond()%int(time.Millisecond) != 0 {
+		t.Errorf("Time %v isn't a whole number of milliseconds", tm)
+	}
+}
+
+func TestGenerateN(t *testing.T) {
+	g := NewGenerator(WithLayout(SnowflakeLayout))
+	xs := g.GenerateN(10000)
+	for i := 1; i < len(xs); i++ {
+		if xs[i] <= xs[i-1] {
+			t.Fatalf("Values out of order: %d then %d", xs[i-1], xs[i])
+		}
+	}
+	if x := g.Generate(); x <= xs[len(xs)-1] {
+		t.Errorf("Generated %d after batch ending %d", x, xs[len(xs)-1])
+	}
+	if xs := g.GenerateN(0); len(xs) != 0 {
+		t.Errorf("Expected empty batch, got %v", xs)
+	}
+}
+
+func TestConcurrentGenerate(t *testing.T) {
+	g := NewGenerator()
+	const workers, each = 8, 10000
+	results := make(chan []Serial)
+	for w := 0; w < workers; w++ {
+		go func() {
+			xs := make([]Serial, each)
+			for i := range xs {
+				xs[i] = g.Generate()
+			}
+			results <- xs
+		}()
+	}
+	seen := make(map[Serial]bool)
+	for w := 0; w < workers; w++ {
+		for _, x := range <-results {
+			if seen[x] {
+				t.Fatalf("Got the same value twice: %d", x)
+			}
+			seen[x] = true
+		}
+	}
+}
+
+func BenchmarkGenerateParallel(b *testing.B) {
+	g := NewGenerator()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			g.Generate()
+		}
+	})
+}
+
+func TestGenerateCtx(t *testing.T) {
+	g := NewGenerator(WithRateLimit(1, true))
+	if _, err := g.GenerateCtx(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := g.GenerateCtx(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded waiting for rate limit, got %v", err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("Expected to give up at the deadline, took %v", d)
+	}
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g = NewGenerator(WithClock(clk), WithRollbackPolicy(RollbackWait))
+	g.Generate()
+	clk.Advance(-time.Hour)
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := g.GenerateCtx(ctx); err != context.Canceled {
+		t.Errorf("Expected Canceled waiting for the clock, got %v", err)
+	}
+	g = NewGenerator(WithAllocator(NewPager(NewGenerator(), false), 10))
+	if _, err := g.GenerateCtx(ctx); err != context.Canceled {
+		t.Errorf("Expected Canceled before leasing, got %v", err)
+	}
+}
+
+func TestReserve(t *testing.T) {
+	g := NewGenerator()
+	first, err := g.Reserve(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(first.Time()); d < -time.Second || d > time.Second {
+		t.Errorf("Block starts %v away from now", d)
+	}
+	if x := g.Generate(); x <= first+999 {
+		t.Errorf("Expected %d to follow the block ending %d", x, first+999)
+	}
+	if _, err := g.Reserve(0); err != ErrBlockSize {
+		t.Errorf("Expected ErrBlockSize got %v", err)
+	}
+	s, _ := NewSnowflakeGenerator(1)
+	if _, err := s.Reserve(10); err != ErrBlockLayout {
+		t.Errorf("Expected ErrBlockLayout got %v", err)
+	}
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	r := NewGenerator(WithClock(clk), WithRollbackPolicy(RollbackError))
+	r.Generate()
+	clk.Advance(-time.Second)
+	if _, err := r.Reserve(10); err != ErrClockRollback {
+		t.Errorf("Expected ErrClockRollback got %v", err)
+	}
+}
+
+func TestLast(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk))
+	if x := g.Last(); x != 0 || !g.LastTime().IsZero() {
+		t.Errorf("Expected zero before generating, got %d and %v", x, g.LastTime())
+	}
+	x := g.Generate()
+	if y := g.Last(); y != x {
+		t.Errorf("Expected %d got %d", x, y)
+	}
+	if !g.LastTime().Equal(clk.now) {
+		t.Errorf("Expected %v got %v", clk.now, g.LastTime())
+	}
+	r := g.ReserveBlock(10)
+	if y := g.Last(); y != r.Last {
+		t.Errorf("Expected end of block %d got %d", r.Last, y)
+	}
+	if y := g.Generate(); y != r.Last+1 {
+		t.Errorf("Expected Last not to issue a value, got %d after %d", y, r.Last)
+	}
+}
+
+func TestRange(t *testing.T) {
+	r := Range{First: 10, Last: 12}
+	for x, want := range map[Serial]bool{9: false, 10: true, 12: true, 13: false} {
+		if r.Contains(x) != want {
+			t.Errorf("Expected Contains(%d) to be %v", x, want)
+		}
+	}
+	for _, c := range []struct {
+		o    Range
+		want bool
+	}{
+		{Range{First: 12, Last: 20}, true},
+		{Range{First: 13, Last: 20}, false},
+		{Range{First: 0, Last: 10}, true},
+		{Range{First: 0, Last: 9}, false},
+		{Range{First: 11, Last: 11}, true},
+		{Range{First: 11, Last: 10}, false},
+	} {
+		if r.Overlaps(c.o) != c.want || c.o.Overlaps(r) != c.want {
+			t.Errorf("Expected Overlaps(%v) to be %v", c.o, c.want)
+		}
+	}
+	var xs []Serial
+	r.Each(func(x Serial) bool {
+		xs = append(xs, x)
+		return true
+	})
+	if len(xs) != 3 || xs[0] != 10 || xs[2] != 12 {
+		t.Errorf("Expected 10 to 12 got %v", xs)
+	}
+	n := 0
+	Range{First: 1<<63 - 2, Last: 1<<63 - 1}.Each(func(Serial) bool {
+		n++
+		return true
+	})
+	if n != 2 {
+		t.Errorf("Expected 2 values at the top of the range, got %d", n)
+	}
+	n = 0
+	r.Each(func(Serial) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("Expected Each to stop, got %d calls", n)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk), WithLayout(SnowflakeLayout), WithNode(5))
+	before := g.Generate()
+	clk.Advance(time.Millisecond)
+	from := clk.now.Add(-time.Microsecond)
+	x := g.Generate()
+	y := g.Generate()
+	clk.Advance(time.Millisecond)
+	to := clk.now
+	after := g.Generate()
+	r := g.Between(from, to)
+	if r.Contains(before) || !r.Contains(x) || !r.Contains(y) || r.Contains(after) {
+		t.Errorf("Expected %v to contain only %d and %d", r, x, y)
+	}
+	if r := g.Between(to, from); r.Len() > 0 {
+		t.Errorf("Expected an empty range, got %v", r)
+	}
+}
@@ -0,0 +1,209 @@
+// Package serialhttp exposes a serial.Generator over HTTP, and provides
+// middleware which assigns a serial value to each request.
+package serialhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lpar/serial"
+)
+
+// MaxCount is the largest number of values a single request to /generate
+// can ask for.
+const MaxCount = 10000
+
+// NewHandler returns an http.Handler which serves a lightweight ID service
+// from gen, with the following endpoints:
+//
+//	GET or POST /generate[?count=n]  generate one or n values
+//	GET /seen/{id}                    report whether a value has been seen
+//	PUT /seen/{id}                    flag a value as seen
+//	DELETE /seen/{id}                 unflag a value
+//	POST /expire?age=duration         expire seen history older than age
+//
+// Values in paths may be in any format accepted by serial.Parse. Responses
+// are JSON, unless the request's Accept header prefers text/plain, in which
+// case they are plain text with one value per line. To serve the endpoints
+// under a prefix, wrap the handler in http.StripPrefix.
+func NewHandler(gen *serial.Generator) http.Handler {
+	h := &handler{gen: gen}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /generate", h.generate)
+	mux.HandleFunc("POST /generate", h.generate)
+	mux.HandleFunc("GET /seen/{id}", h.seen)
+	mux.HandleFunc("PUT /seen/{id}", h.seen)
+	mux.HandleFunc("DELETE /seen/{id}", h.seen)
+	mux.HandleFunc("POST /expire", h.expire)
+	return mux
+}
+
+type handler struct {
+	gen *serial.Generator
+}
+
+// generateResponse is the JSON response from /generate.
+type generateResponse struct {
+	Serial  *serial.Serial  `json:"serial,omitempty"`
+	Serials []serial.Serial `json:"serials,omitempty"`
+}
+
+func (h *handler) generate(w http.ResponseWriter, r *http.Request) {
+	count := 1
+	if s := r.URL.Query().Get("count"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > MaxCount {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("count must be from 1 to %d", MaxCount))
+			return
+		}
+		count = n
+	}
+	xs := make([]serial.Serial, count)
+	var err error
+	if count == 1 {
+		xs[0], err = h.gen.GenerateCtx(r.Context())
+	} else {
+		// A batch is claimed in one go, so it is issued whole or not at all.
+		err = h.gen.TryFill(xs)
+	}
+	if err != nil {
+		writeError(w, r, errorStatus(err), err.Error())
+		return
+	}
+	if wantsText(r) {
+		var sb strings.Builder
+		for _, x := range xs {
+			sb.WriteString(x.String())
+			sb.WriteByte('\n')
+		}
+		writeText(w, http.StatusOK, sb.String())
+		return
+	}
+	if r.URL.Query().Has("count") {
+		writeJSON(w, http.StatusOK, generateResponse{Serials: xs})
+	} else {
+		writeJSON(w, http.StatusOK, generateResponse{Serial: &xs[0]})
+	}
+}
+
+// seenResponse is the JSON response from /seen.
+type seenResponse struct {
+	Serial serial.Serial `json:"serial"`
+	Seen   bool          `json:"seen"`
+	// WasSeen reports whether the value had been seen before a PUT or
+	// DELETE request.
+	WasSeen *bool `json:"was_seen,omitempty"`
+}
+
+func (h *handler) seen(w http.ResponseWriter, r *http.Request) {
+	x, err := serial.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	resp := seenResponse{Serial: x}
+	switch r.Method {
+	case http.MethodPut:
+		was, err := h.gen.TrySeenOrSet(x)
+		if err != nil {
+			writeError(w, r, errorStatus(err), err.Error())
+			return
+		}
+		resp.Seen, resp.WasSeen = true, &was
+	case http.MethodDelete:
+		was := h.gen.UnsetSeen(x)
+		resp.Seen, resp.WasSeen = false, &was
+	default:
+		resp.Seen = h.gen.Seen(x)
+	}
+	if wantsText(r) {
+		writeText(w, http.StatusOK, strconv.FormatBool(resp.Seen)+"\n")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// expireResponse is the JSON response from /expire.
+type expireResponse struct {
+	Removed   int `json:"removed"`
+	Remaining int `json:"remaining"`
+}
+
+func (h *handler) expire(w http.ResponseWriter, r *http.Request) {
+	age, err := time.ParseDuration(r.URL.Query().Get("age"))
+	if err != nil || age < 0 {
+		writeError(w, r, http.StatusBadRequest, "age must be a non-negative duration, such as 24h")
+		return
+	}
+	removed, n := h.gen.ExpireSeen(age)
+	if wantsText(r) {
+		writeText(w, http.StatusOK, strconv.Itoa(n)+"\n")
+		return
+	}
+	writeJSON(w, http.StatusOK, expireResponse{Removed: removed, Remaining: n})
+}
+
+// wantsText reports whether the request's Accept header prefers text/plain
+// to JSON.
+func wantsText(r *http.Request) bool {
+	var qjson, qtext float64 = -1, -1
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+		switch mt {
+		case "text/plain":
+			qtext = max(qtext, q)
+		case "application/json":
+			qjson = max(qjson, q)
+		}
+	}
+	return qtext > 0 && qtext > qjson
+}
+
+// errorStatus returns the HTTP status for an error from the generator. A
+// request which exceeds a quota or rate limit gets 429 Too Many Requests.
+func errorStatus(err error) int {
+	if errors.Is(err, serial.ErrQuotaExceeded) || errors.Is(err, serial.ErrRateLimited) {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusServiceUnavailable
+}
+
+// errorResponse is the JSON response for an error.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	if wantsText(r) {
+		writeText(w, status, msg+"\n")
+		return
+	}
+	writeJSON(w, status, errorResponse{Error: msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeText(w http.ResponseWriter, status int, s string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(s))
+}
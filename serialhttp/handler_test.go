@@ -0,0 +1,132 @@
+package serialhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lpar/serial"
+)
+
+func do(t *testing.T, h http.Handler, method, target, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGenerate(t *testing.T) {
+	h := NewHandler(serial.NewGenerator())
+	rec := do(t, h, "GET", "/generate", "")
+	var one generateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &one); err != nil || one.Serial == nil {
+		t.Fatalf("Expected a serial, got %q", rec.Body.String())
+	}
+	rec = do(t, h, "POST", "/generate?count=3", "application/json")
+	var many generateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &many); err != nil || len(many.Serials) != 3 {
+		t.Fatalf("Expected 3 serials, got %q", rec.Body.String())
+	}
+	rec = do(t, h, "GET", "/generate?count=3", "text/plain, application/json;q=0.5")
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Expected text/plain got %s", ct)
+	}
+	if lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n"); len(lines) != 3 {
+		t.Errorf("Expected 3 lines got %q", rec.Body.String())
+	}
+	rec = do(t, h, "GET", "/generate?count=0", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 got %d", rec.Code)
+	}
+}
+
+func TestSeen(t *testing.T) {
+	gen := serial.NewGenerator()
+	h := NewHandler(gen)
+	x := gen.Generate()
+	path := "/seen/" + x.Base62()
+	var resp seenResponse
+	rec := do(t, h, "GET", path, "")
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Serial != x || resp.Seen {
+		t.Errorf("Expected %d unseen got %q", x, rec.Body.String())
+	}
+	rec = do(t, h, "PUT", path, "")
+	resp = seenResponse{}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if !resp.Seen || resp.WasSeen == nil || *resp.WasSeen {
+		t.Errorf("Expected newly seen got %q", rec.Body.String())
+	}
+	if !gen.Seen(x) {
+		t.Errorf("Expected %d seen", x)
+	}
+	rec = do(t, h, "GET", path, "text/plain")
+	if rec.Body.String() != "true\n" {
+		t.Errorf("Expected true got %q", rec.Body.String())
+	}
+	rec = do(t, h, "DELETE", path, "")
+	if gen.Seen(x) {
+		t.Errorf("Expected %d unseen after DELETE", x)
+	}
+	rec = do(t, h, "GET", "/seen/!!!", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 got %d", rec.Code)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	gen := serial.NewGenerator()
+	h := NewHandler(gen)
+	gen.SetSeen(gen.Generate())
+	rec := do(t, h, "POST", "/expire?age=1h", "")
+	var resp expireResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Remaining != 1 || resp.Removed != 0 {
+		t.Errorf("Expected 1 remaining got %q", rec.Body.String())
+	}
+	rec = do(t, h, "POST", "/expire?age=0s", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Remaining != 0 || resp.Removed != 1 {
+		t.Errorf("Expected 1 removed got %q", rec.Body.String())
+	}
+	rec = do(t, h, "POST", "/expire?age=bogus", "text/plain")
+	if rec.Code != http.StatusBadRequest || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Expected plain text 400 got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	rec = do(t, h, "GET", "/expire?age=1h", "")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 got %d", rec.Code)
+	}
+}
+
+func TestQuotaStatus(t *testing.T) {
+	h := NewHandler(serial.NewGenerator(serial.WithIssueQuota(1, time.Hour), serial.WithSeenQuota(1)))
+	if rec := do(t, h, "GET", "/generate", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 got %d", rec.Code)
+	}
+	if rec := do(t, h, "GET", "/generate", ""); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 got %d", rec.Code)
+	}
+	do(t, h, "PUT", "/seen/1", "")
+	if rec := do(t, h, "PUT", "/seen/2", ""); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 got %d", rec.Code)
+	}
+}
+
+func TestGenerateBatchWhole(t *testing.T) {
+	gen := serial.NewGenerator(serial.WithIssueQuota(5, time.Hour))
+	h := NewHandler(gen)
+	if rec := do(t, h, "GET", "/generate?count=6", ""); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 got %d", rec.Code)
+	}
+	if n := gen.Stats().Generated; n != 0 {
+		t.Errorf("Expected a failed batch to issue nothing, got %d", n)
+	}
+	if rec := do(t, h, "GET", "/generate?count=5", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 got %d", rec.Code)
+	}
+}
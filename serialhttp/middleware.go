@@ -0,0 +1,41 @@
+package serialhttp
+
+import (
+	"net/http"
+
+	"github.com/lpar/serial"
+)
+
+// RequestIDHeader is the header which carries a request's serial value.
+const RequestIDHeader = "X-Request-ID"
+
+// Middleware returns middleware which assigns a serial value to each
+// request, generated by gen. If the request already has an X-Request-ID
+// header which serial.Parse accepts, its value is used instead, so that an
+// ID assigned upstream is carried through. The value is stored in the
+// request's context, where serial.FromContext can find it, and set as the
+// X-Request-ID header of both the request and the response. An incoming
+// header is passed on exactly as received, so that it still matches the
+// upstream service's logs; a generated value is written in decimal. If
+// a value can't be generated, for example because gen has been closed during
+// a graceful shutdown, the request fails with an error response, as from
+// NewHandler.
+func Middleware(gen *serial.Generator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			x, err := serial.Parse(id)
+			if err != nil {
+				if x, err = gen.GenerateCtx(r.Context()); err != nil {
+					writeError(w, r, errorStatus(err), err.Error())
+					return
+				}
+				id = x.String()
+			}
+			r = r.WithContext(serial.NewContext(r.Context(), x))
+			r.Header.Set(RequestIDHeader, id)
+			w.Header().Set(RequestIDHeader, id)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
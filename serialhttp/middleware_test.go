@@ -0,0 +1,74 @@
+package serialhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lpar/serial"
+)
+
+func TestMiddleware(t *testing.T) {
+	var (
+		got    serial.Serial
+		header string
+	)
+	h := Middleware(serial.NewGenerator())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ok bool
+		if got, ok = serial.FromContext(r.Context()); !ok {
+			t.Error("Expected serial in request context")
+		}
+		header = r.Header.Get(RequestIDHeader)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if got == 0 || rec.Header().Get(RequestIDHeader) != got.String() || header != got.String() {
+		t.Errorf("Expected headers %d got %s and %s", got, header, rec.Header().Get(RequestIDHeader))
+	}
+
+	// An incoming ID is honoured, in any format Parse accepts, and passed on
+	// unchanged.
+	for _, c := range []struct {
+		id   string
+		want serial.Serial
+	}{
+		{"0x499602d2", 1234567890},
+		{"abc123", 0},
+		{"1234567890", 1234567890},
+	} {
+		if c.want == 0 {
+			c.want, _ = serial.Parse(c.id)
+		}
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(RequestIDHeader, c.id)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if got != c.want {
+			t.Errorf("Expected incoming ID %d got %d", c.want, got)
+		}
+		if header != c.id || rec.Header().Get(RequestIDHeader) != c.id {
+			t.Errorf("Expected headers %s got %s and %s", c.id, header, rec.Header().Get(RequestIDHeader))
+		}
+	}
+
+	// An unparseable one is replaced.
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "not a serial!")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if got == 1234567890 || got == 0 {
+		t.Errorf("Expected new ID got %d", got)
+	}
+}
+
+func TestMiddlewareClosed(t *testing.T) {
+	gen := serial.NewGenerator()
+	gen.Close()
+	called := false
+	h := Middleware(gen)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if called || rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without calling the handler, got %d and %v", rec.Code, called)
+	}
+}
@@ -0,0 +1,105 @@
+// Package serialotel integrates serial values with OpenTelemetry, for
+// applications which use them as correlation IDs. A Generator records each
+// value it issues on the current span, and carries it onwards as a baggage
+// member, so that it is propagated to downstream services along with the
+// trace context.
+package serialotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/lpar/serial"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultKey is the baggage key used when none is specified.
+const DefaultKey = "serial"
+
+// EventName is the name of the span event recorded for each value
+// generated.
+const EventName = "serial.generate"
+
+// Attribute keys for the span event.
+const (
+	ValueKey   = attribute.Key("serial.value")
+	LatencyKey = attribute.Key("serial.latency")
+)
+
+// Generator wraps a serial.Generator, recording each value it generates on
+// the span in the context, and adding it to the context's baggage.
+type Generator struct {
+	gen *serial.Generator
+	key string
+}
+
+// NewGenerator returns a Generator which issues values from gen, and
+// propagates them under the specified baggage key, or DefaultKey if key is
+// empty.
+func NewGenerator(gen *serial.Generator, key string) *Generator {
+	if key == "" {
+		key = DefaultKey
+	}
+	return &Generator{gen: gen, key: key}
+}
+
+// TryGenerate generates a serial value, and records a span event with the
+// value and how long it took to generate on the span in ctx, if there is
+// one. It returns a copy of ctx whose baggage holds the value. If a value
+// can't be generated, the error is recorded on the span and returned along
+// with ctx unchanged.
+func (g *Generator) TryGenerate(ctx context.Context) (context.Context, serial.Serial, error) {
+	span := trace.SpanFromContext(ctx)
+	start := time.Now()
+	x, err := g.gen.GenerateCtx(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return ctx, 0, err
+	}
+	span.AddEvent(EventName, trace.WithAttributes(
+		ValueKey.String(x.String()),
+		LatencyKey.Int64(int64(time.Since(start))),
+	))
+	m, err := baggage.NewMember(g.key, x.String())
+	if err != nil {
+		return ctx, x, err
+	}
+	b, err := baggage.FromContext(ctx).SetMember(m)
+	if err != nil {
+		return ctx, x, err
+	}
+	return baggage.ContextWithBaggage(ctx, b), x, nil
+}
+
+// Generate is like TryGenerate, but panics if a value can't be generated, as
+// serial.Generator.Generate does.
+func (g *Generator) Generate(ctx context.Context) (context.Context, serial.Serial) {
+	ctx, x, err := g.TryGenerate(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return ctx, x
+}
+
+// FromContext returns the serial value in the baggage of ctx under the
+// generator's key, and whether there was a valid one.
+func (g *Generator) FromContext(ctx context.Context) (serial.Serial, bool) {
+	return FromBaggage(ctx, g.key)
+}
+
+// FromBaggage returns the serial value in the baggage of ctx under the
+// specified key, and whether there was a valid one. It accepts values in any
+// format understood by serial.Parse, so that other services can propagate
+// them however they print them.
+func FromBaggage(ctx context.Context, key string) (serial.Serial, bool) {
+	v := baggage.FromContext(ctx).Member(key).Value()
+	if v == "" {
+		return 0, false
+	}
+	x, err := serial.Parse(v)
+	return x, err == nil
+}
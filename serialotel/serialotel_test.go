@@ -0,0 +1,61 @@
+package serialotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lpar/serial"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestGenerate(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	g := NewGenerator(serial.NewGenerator(), "")
+	ctx, x := g.Generate(ctx)
+	span.End()
+
+	if y, ok := g.FromContext(ctx); !ok || y != x {
+		t.Errorf("Expected %d in baggage got %d", x, y)
+	}
+	if v := baggage.FromContext(ctx).Member(DefaultKey).Value(); v != x.String() {
+		t.Errorf("Expected baggage value %s got %s", x, v)
+	}
+	spans := rec.Ended()
+	if len(spans) != 1 || len(spans[0].Events()) != 1 {
+		t.Fatalf("Expected one span with one event")
+	}
+	ev := spans[0].Events()[0]
+	if ev.Name != EventName {
+		t.Errorf("Expected event %s got %s", EventName, ev.Name)
+	}
+	found := false
+	for _, kv := range ev.Attributes {
+		if kv.Key == ValueKey && kv.Value.AsString() == x.String() {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected %s attribute with value %d", ValueKey, x)
+	}
+}
+
+func TestFromBaggage(t *testing.T) {
+	m, _ := baggage.NewMember("request", "0x499602d2")
+	b, _ := baggage.New(m)
+	ctx := baggage.ContextWithBaggage(context.Background(), b)
+	if x, ok := FromBaggage(ctx, "request"); !ok || x != 1234567890 {
+		t.Errorf("Expected 1234567890 got %d", x)
+	}
+	if _, ok := FromBaggage(ctx, "missing"); ok {
+		t.Error("Expected no value for missing key")
+	}
+	// Works without a span in the context.
+	ctx, x := NewGenerator(serial.NewGenerator(), "id").Generate(context.Background())
+	if y, ok := FromBaggage(ctx, "id"); !ok || y != x {
+		t.Errorf("Expected %d got %d", x, y)
+	}
+}
@@ -0,0 +1,53 @@
+// Package serialpb defines Protocol Buffers messages for serial values,
+// batches and ranges, so that services exchanging serials over gRPC have a
+// canonical wire representation, and provides conversions to and from the
+// serial package's types. The messages are defined in serialpb.proto, which
+// other .proto files can import.
+//
+//	msg := serialpb.New(gen.Generate())
+//	...
+//	x := msg.AsSerial()
+package serialpb
+
+//go:generate buf generate
+
+import "github.com/lpar/serial"
+
+// New returns a message holding x.
+func New(x serial.Serial) *Serial {
+	return &Serial{Value: int64(x)}
+}
+
+// AsSerial returns the serial value in the message, or 0 if it is nil.
+func (x *Serial) AsSerial() serial.Serial {
+	return serial.Serial(x.GetValue())
+}
+
+// NewBatch returns a message holding the values in xs.
+func NewBatch(xs []serial.Serial) *SerialBatch {
+	vs := make([]int64, len(xs))
+	for i, x := range xs {
+		vs[i] = int64(x)
+	}
+	return &SerialBatch{Values: vs}
+}
+
+// AsSerials returns the serial values in the message.
+func (x *SerialBatch) AsSerials() serial.Serials {
+	vs := x.GetValues()
+	xs := make(serial.Serials, len(vs))
+	for i, v := range vs {
+		xs[i] = serial.Serial(v)
+	}
+	return xs
+}
+
+// NewRange returns a message holding r.
+func NewRange(r serial.Range) *Range {
+	return &Range{First: int64(r.First), Last: int64(r.Last)}
+}
+
+// AsRange returns the range in the message, or the zero Range if it is nil.
+func (x *Range) AsRange() serial.Range {
+	return serial.Range{First: serial.Serial(x.GetFirst()), Last: serial.Serial(x.GetLast())}
+}
@@ -0,0 +1,51 @@
+package serialpb
+
+import (
+	"testing"
+
+	"github.com/lpar/serial"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConvert(t *testing.T) {
+	gen := serial.NewGenerator()
+	x := gen.Generate()
+	b, err := proto.Marshal(New(x))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 9 {
+		t.Errorf("Expected 9 bytes on the wire, got %d", len(b))
+	}
+	var msg Serial
+	if err := proto.Unmarshal(b, &msg); err != nil || msg.AsSerial() != x {
+		t.Errorf("Round trip failed, expected %d got %d %v", x, msg.AsSerial(), err)
+	}
+	if (*Serial)(nil).AsSerial() != 0 || (*Range)(nil).AsRange() != (serial.Range{}) {
+		t.Error("Expected zero values from nil messages")
+	}
+
+	xs := gen.GenerateN(3)
+	b, err = proto.Marshal(NewBatch(xs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var batch SerialBatch
+	if err := proto.Unmarshal(b, &batch); err != nil {
+		t.Fatal(err)
+	}
+	ys := batch.AsSerials()
+	if len(ys) != 3 || ys[0] != xs[0] || ys[2] != xs[2] {
+		t.Errorf("Batch round trip failed, expected %v got %v", xs, ys)
+	}
+
+	r := gen.ReserveBlock(10)
+	b, err = proto.Marshal(NewRange(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rmsg Range
+	if err := proto.Unmarshal(b, &rmsg); err != nil || rmsg.AsRange() != r {
+		t.Errorf("Range round trip failed, expected %v got %v %v", r, rmsg.AsRange(), err)
+	}
+}
@@ -0,0 +1,72 @@
+// Package serialprom exports metrics about serial.Generator instances to
+// Prometheus.
+//
+//	prometheus.MustRegister(serialprom.NewCollector(gen, "tokens"))
+package serialprom
+
+import (
+	"github.com/lpar/serial"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector which reports the figures from a
+// generator's Stats, labelled with the generator's name. The figures are
+// read when Prometheus scrapes them, so the generator does no extra work
+// between scrapes.
+type Collector struct {
+	gen         *serial.Generator
+	generated   *prometheus.Desc
+	seen        *prometheus.Desc
+	expirations *prometheus.Desc
+	expired     *prometheus.Desc
+	rollbacks   *prometheus.Desc
+	ahead       *prometheus.Desc
+	raised      *prometheus.Desc
+}
+
+// NewCollector returns a Collector for gen, whose metrics have a generator
+// label with the specified name, to distinguish them from those of other
+// generators.
+func NewCollector(gen *serial.Generator, name string) *Collector {
+	labels := prometheus.Labels{"generator": name}
+	return &Collector{
+		gen: gen,
+		generated: prometheus.NewDesc("serial_generated_total",
+			"Number of serial values generated.", nil, labels),
+		seen: prometheus.NewDesc("serial_seen_entries",
+			"Number of values in the seen history.", nil, labels),
+		expirations: prometheus.NewDesc("serial_seen_expirations_total",
+			"Number of times the seen history has been expired.", nil, labels),
+		expired: prometheus.NewDesc("serial_seen_expired_total",
+			"Number of values removed from the seen history by expiry.", nil, labels),
+		rollbacks: prometheus.NewDesc("serial_clock_rollbacks_total",
+			"Number of times the clock has been found to have moved backwards.", nil, labels),
+		ahead: prometheus.NewDesc("serial_generated_ahead_total",
+			"Number of serial values generated with embedded times ahead of the clock.", nil, labels),
+		raised: prometheus.NewDesc("serial_import_raised_total",
+			"Number of imported values the generator had to move on past.", nil, labels),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.generated
+	ch <- c.seen
+	ch <- c.expirations
+	ch <- c.expired
+	ch <- c.rollbacks
+	ch <- c.ahead
+	ch <- c.raised
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := c.gen.Stats()
+	ch <- prometheus.MustNewConstMetric(c.generated, prometheus.CounterValue, float64(s.Generated))
+	ch <- prometheus.MustNewConstMetric(c.seen, prometheus.GaugeValue, float64(s.Seen))
+	ch <- prometheus.MustNewConstMetric(c.expirations, prometheus.CounterValue, float64(s.Expirations))
+	ch <- prometheus.MustNewConstMetric(c.expired, prometheus.CounterValue, float64(s.Expired))
+	ch <- prometheus.MustNewConstMetric(c.rollbacks, prometheus.CounterValue, float64(s.Rollbacks))
+	ch <- prometheus.MustNewConstMetric(c.ahead, prometheus.CounterValue, float64(s.Ahead))
+	ch <- prometheus.MustNewConstMetric(c.raised, prometheus.CounterValue, float64(s.Raised))
+}
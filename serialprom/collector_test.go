@@ -0,0 +1,38 @@
+package serialprom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lpar/serial"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	gen := serial.NewGenerator()
+	gen.SetSeen(gen.Generate())
+	gen.GenerateN(4)
+	gen.ExpireSeen(0)
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(NewCollector(gen, "tokens"), NewCollector(serial.NewGenerator(), "orders"))
+	want := `
+# HELP serial_generated_total Number of serial values generated.
+# TYPE serial_generated_total counter
+serial_generated_total{generator="orders"} 0
+serial_generated_total{generator="tokens"} 5
+# HELP serial_seen_expirations_total Number of times the seen history has been expired.
+# TYPE serial_seen_expirations_total counter
+serial_seen_expirations_total{generator="orders"} 0
+serial_seen_expirations_total{generator="tokens"} 1
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"serial_generated_total", "serial_seen_expirations_total")
+	if err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(NewCollector(gen, "tokens")); n != 7 {
+		t.Errorf("Expected 7 metrics got %d", n)
+	}
+}
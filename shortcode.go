@@ -0,0 +1,161 @@
+package serial
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+// CodeAlphabet is the default alphabet for short codes: Crockford's Base32
+// alphabet, which has no letters easily mistaken for digits or each other.
+const CodeAlphabet = crockford
+
+// shortCodeTries is the number of random codes a ShortCoder tries before it
+// gives up.
+const shortCodeTries = 100
+
+var (
+	// ErrShortCode is returned when asked to create a ShortCoder with an
+	// invalid length, alphabet or lifetime, or when parsing a code which
+	// isn't valid for the ShortCoder.
+	ErrShortCode = errors.New("serial: invalid short code")
+	// ErrCodesExhausted is returned when a ShortCoder can't find an unused
+	// code, because nearly all of them are in use.
+	ErrCodesExhausted = errors.New("serial: short codes exhausted")
+)
+
+// ShortCoder issues short random codes, such as pickup codes, which are
+// unique among the codes currently in use. Each code issued is flagged as
+// seen in a generator's history for the code's lifetime, and a new code is
+// drawn at random until one is found which isn't in use.
+//
+// A code is flagged as the serial value given by reading it as a number in
+// the alphabet's base, so the generator should be dedicated to its codes,
+// rather than also used to flag other values. The codes have their own time
+// to live, so are unaffected by the age limit passed to ExpireSeen, but
+// ExpireSeen must still be called to remove them once they have expired.
+type ShortCoder struct {
+	gen      *Generator
+	alphabet string
+	length   int
+	space    uint64
+	ttl      time.Duration
+	fold     bool
+}
+
+// NewShortCoder creates a ShortCoder which issues codes of the specified
+// length, made of the characters of alphabet, and flags them in gen for the
+// specified time to live. If the alphabet is empty, CodeAlphabet is used.
+// The alphabet must have at least two characters, all different, and the
+// number of possible codes must fit in an int64: 10 characters from
+// CodeAlphabet give about a million billion.
+//
+// Codes need room to spare: once about half of them are in use, generating a
+// new one can take several attempts.
+func NewShortCoder(gen *Generator, length int, alphabet string, ttl time.Duration) (*ShortCoder, error) {
+	if alphabet == "" {
+		alphabet = CodeAlphabet
+	}
+	if length < 1 || len(alphabet) < 2 || ttl <= 0 {
+		return nil, ErrShortCode
+	}
+	for i := 0; i < len(alphabet); i++ {
+		if strings.IndexByte(alphabet[i+1:], alphabet[i]) >= 0 {
+			return nil, ErrShortCode
+		}
+	}
+	space := uint64(1)
+	for i := 0; i < length; i++ {
+		if space > math.MaxInt64/uint64(len(alphabet)) {
+			return nil, ErrShortCode
+		}
+		space *= uint64(len(alphabet))
+	}
+	return &ShortCoder{
+		gen:      gen,
+		alphabet: alphabet,
+		length:   length,
+		space:    space,
+		ttl:      ttl,
+		fold:     strings.ToUpper(alphabet) == alphabet,
+	}, nil
+}
+
+// Generate issues a new code, which isn't currently in use. It returns
+// ErrCodesExhausted if it can't find one after many attempts, or a
+// *QuotaError if the generator's seen quota has been reached.
+func (c *ShortCoder) Generate() (string, error) {
+	var b [8]byte
+	// Values at or above limit are discarded, so every code is equally
+	// likely.
+	limit := math.MaxUint64 - math.MaxUint64%c.space
+	for i := 0; i < shortCodeTries; i++ {
+		if err := c.gen.readRandom(b[:]); err != nil {
+			return "", err
+		}
+		n := binary.LittleEndian.Uint64(b[:])
+		if n >= limit {
+			continue
+		}
+		x := Serial(n % c.space)
+		now := c.gen.clock.Now().UnixNano()
+		e := seenEntry{deadline: now + int64(c.ttl)}
+		added, err := c.gen.addSeen(x, e, now)
+		if err != nil {
+			return "", err
+		}
+		if added {
+			c.gen.flagged(x, e)
+			return c.Format(x), nil
+		}
+	}
+	return "", ErrCodesExhausted
+}
+
+// Format returns the code for a serial value flagged by the ShortCoder.
+func (c *ShortCoder) Format(x Serial) string {
+	b := make([]byte, c.length)
+	n := uint64(x)
+	base := uint64(len(c.alphabet))
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = c.alphabet[n%base]
+		n /= base
+	}
+	return string(b)
+}
+
+// Parse returns the serial value for a code. If the alphabet has no lower
+// case letters, codes are accepted in either case. It returns ErrShortCode
+// if the code has the wrong length or characters outside the alphabet.
+func (c *ShortCoder) Parse(code string) (Serial, error) {
+	if c.fold {
+		code = strings.ToUpper(code)
+	}
+	if len(code) != c.length {
+		return 0, ErrShortCode
+	}
+	var n uint64
+	for i := 0; i < len(code); i++ {
+		v := strings.IndexByte(c.alphabet, code[i])
+		if v < 0 {
+			return 0, ErrShortCode
+		}
+		n = n*uint64(len(c.alphabet)) + uint64(v)
+	}
+	return Serial(n), nil
+}
+
+// Valid reports whether a code has been issued and is still in use.
+func (c *ShortCoder) Valid(code string) bool {
+	x, err := c.Parse(code)
+	return err == nil && c.gen.Seen(x)
+}
+
+// Release withdraws a code before its time to live runs out, so that it can
+// be issued again, and reports whether it was in use.
+func (c *ShortCoder) Release(code string) bool {
+	x, err := c.Parse(code)
+	return err == nil && c.gen.UnsetSeen(x)
+}
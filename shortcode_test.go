@@ -0,0 +1,86 @@
+package serial
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShortCoder(t *testing.T) {
+	g := NewGenerator()
+	for _, c := range []struct {
+		length   int
+		alphabet string
+	}{
+		{0, ""}, {6, "A"}, {6, "ABCA"}, {14, ""},
+	} {
+		if _, err := NewShortCoder(g, c.length, c.alphabet, time.Hour); err != ErrShortCode {
+			t.Errorf("Expected ErrShortCode for length %d alphabet %q, got %v", c.length, c.alphabet, err)
+		}
+	}
+	if _, err := NewShortCoder(g, 6, "", 0); err != ErrShortCode {
+		t.Errorf("Expected ErrShortCode with no lifetime, got %v", err)
+	}
+	sc, err := NewShortCoder(g, 6, "", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		code, err := sc.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != 6 || codes[code] {
+			t.Fatalf("Bad or repeated code %q", code)
+		}
+		codes[code] = true
+		if !sc.Valid(strings.ToLower(code)) {
+			t.Errorf("Expected %q to be valid", code)
+		}
+		x, err := sc.Parse(code)
+		if err != nil || sc.Format(x) != code {
+			t.Errorf("Round trip of %q failed, got %d %v", code, x, err)
+		}
+	}
+	if sc.Valid("UUUUUU") || sc.Valid("0000000") {
+		t.Error("Expected invalid codes to be rejected")
+	}
+	for code := range codes {
+		if !sc.Release(code) || sc.Valid(code) {
+			t.Errorf("Expected %q to be released", code)
+		}
+		break
+	}
+}
+
+func TestShortCoderExhausted(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clock))
+	sc, err := NewShortCoder(g, 2, "01", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		code, err := sc.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		codes[code] = true
+	}
+	if len(codes) != 4 {
+		t.Errorf("Expected all 4 codes, got %v", codes)
+	}
+	if _, err := sc.Generate(); err != ErrCodesExhausted {
+		t.Errorf("Expected ErrCodesExhausted got %v", err)
+	}
+	clock.Advance(time.Hour)
+	g.ExpireSeen(time.Hour)
+	if n := g.SeenCount(); n != 0 {
+		t.Errorf("Expected codes to expire, %d left", n)
+	}
+	if _, err := sc.Generate(); err != nil {
+		t.Errorf("Expected a code after expiry, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package serial
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	// ErrInvalidToken is returned when verifying a string which isn't a
+	// token produced by Signer.Sign.
+	ErrInvalidToken = errors.New("serial: invalid token")
+	// ErrSignature is returned when a token's MAC doesn't match any of the
+	// Signer's keys, indicating that it was forged or signed with a retired
+	// key.
+	ErrSignature = errors.New("serial: invalid signature")
+)
+
+// macSize is the number of bytes of HMAC-SHA256 kept in a token.
+const macSize = 12
+
+// Signer produces tokens which carry a serial value along with a MAC, so that
+// forged values can be rejected without consulting the seen history, which
+// then only needs to hold values which are genuine. Tokens are signed with
+// HMAC-SHA256, truncated to 96 bits, and encoded with unpadded URL-safe
+// Base64 in 27 characters.
+//
+// To rotate keys, create a new Signer with the new key first and the old one
+// after it: tokens are signed with the first key, and verified with any of
+// them. Once all tokens signed with the old key have expired, it can be
+// dropped. A Signer is safe for concurrent use.
+type Signer struct {
+	keys [][]byte
+}
+
+// NewSigner creates a Signer which signs with key and verifies with key or
+// any of the older keys provided. Keys should be at least 32 random bytes. It
+// returns ErrKey if any key is empty.
+func NewSigner(key []byte, older ...[]byte) (*Signer, error) {
+	keys := append([][]byte{key}, older...)
+	for _, k := range keys {
+		if len(k) == 0 {
+			return nil, ErrKey
+		}
+	}
+	return &Signer{keys: keys}, nil
+}
+
+// Sign returns a token for x signed with the Signer's current key.
+func (s *Signer) Sign(x Serial) string {
+	var b [8 + macSize]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(x))
+	copy(b[8:], mac(s.keys[0], b[:8]))
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
+
+// Verify checks a token produced by Sign, and returns the serial value it
+// carries. It returns ErrInvalidToken if the token is malformed, or
+// ErrSignature if it wasn't signed with any of the Signer's keys.
+func (s *Signer) Verify(token string) (Serial, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) != 8+macSize {
+		return 0, ErrInvalidToken
+	}
+	for _, k := range s.keys {
+		if hmac.Equal(b[8:], mac(k, b[:8])) {
+			return Serial(binary.BigEndian.Uint64(b[:8])), nil
+		}
+	}
+	return 0, ErrSignature
+}
+
+// mac returns the truncated HMAC-SHA256 of msg under key.
+func mac(key, msg []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(msg)
+	return h.Sum(nil)[:macSize]
+}
@@ -0,0 +1,46 @@
+package serial
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSigner(t *testing.T) {
+	if _, err := NewSigner(nil); err != ErrKey {
+		t.Errorf("Expected ErrKey got %v", err)
+	}
+	if _, err := NewSigner([]byte("key"), nil); err != ErrKey {
+		t.Errorf("Expected ErrKey for empty old key, got %v", err)
+	}
+	oldKey := []byte("an old key of at least 32 bytes!")
+	newKey := []byte("a new key of at least 32 bytes!!")
+	old, _ := NewSigner(oldKey)
+	s, err := NewSigner(newKey, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := NewGenerator().Generate()
+	tok := s.Sign(x)
+	if len(tok) != 27 {
+		t.Errorf("Expected 27 characters got %d", len(tok))
+	}
+	if y, err := s.Verify(tok); err != nil || y != x {
+		t.Errorf("Expected %d got %d, %v", x, y, err)
+	}
+	if y, err := s.Verify(old.Sign(x)); err != nil || y != x {
+		t.Errorf("Expected token from old key to verify, got %d, %v", y, err)
+	}
+	if _, err := old.Verify(tok); err != ErrSignature {
+		t.Errorf("Expected ErrSignature for token from new key, got %v", err)
+	}
+	forged := []byte(tok)
+	forged[3] ^= 1
+	if _, err := s.Verify(string(forged)); err != ErrSignature {
+		t.Errorf("Expected ErrSignature for altered token, got %v", err)
+	}
+	for _, bad := range []string{"", tok[:26], tok + "A", strings.Repeat("!", 27)} {
+		if _, err := s.Verify(bad); err != ErrInvalidToken {
+			t.Errorf("Expected ErrInvalidToken for %q, got %v", bad, err)
+		}
+	}
+}
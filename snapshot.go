@@ -0,0 +1,125 @@
+package serial
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// ErrSnapshot is returned by Restore when the snapshot is malformed, or was
+// taken from a generator with a different layout or node ID.
+var ErrSnapshot = errors.New("serial: invalid snapshot")
+
+// snapshotVersion is the version of the snapshot format written by Snapshot.
+const snapshotVersion = 1
+
+// snapshotHeader is the first line of a snapshot.
+type snapshotHeader struct {
+	Version int          `json:"version"`
+	Last    Serial       `json:"last"`
+	Layout  layoutFields `json:"layout"`
+	Node    int64        `json:"node"`
+}
+
+// layoutFields is a Layout without its text marshaling, so that snapshots
+// keep recording the layout as a JSON object of its fields.
+type layoutFields Layout
+
+// Snapshot captures the generator's state: the last value it issued, and its
+// seen history. Flagging values as seen, unsetting them and expiring history
+// wait while the history is copied, and the last value issued is read after
+// it, so a generator restored from the snapshot will never issue a value
+// issued before Snapshot returned, and will have seen every value flagged as
+// seen before Snapshot was called. This allows a graceful restart, or a move
+// to another instance.
+//
+// The snapshot is a header line holding the last value, layout and node ID,
+// as JSON, followed by the seen history in the format written by ExportSeen,
+// so attached values must be suitable for encoding/json. A history kept in
+// Bloom filters can't be captured, so only the last value is.
+func (g *Generator) Snapshot() ([]byte, error) {
+	var seen bytes.Buffer
+	g.snapMutex.Lock()
+	err := g.ExportSeen(&seen)
+	last := Serial(g.lastSerial.Load())
+	g.snapMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(snapshotHeader{Version: snapshotVersion, Last: last, Layout: layoutFields(g.layout), Node: g.node})
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, '\n')
+	return append(b, seen.Bytes()...), nil
+}
+
+// Restore restores state captured by Snapshot into the generator, which must
+// have the same layout and node ID as the one the snapshot was taken from.
+// The generator is moved on past the last value in the snapshot, and the
+// values in its seen history are flagged as seen, as by ImportSeen. A value
+// in the history after the snapshot's last value means the snapshot is
+// inconsistent; the generator is moved on past it too, it is counted in
+// Stats.Raised, and an EventRaise is sent for it. Restore is meant for a
+// freshly created generator; any history the generator already has is kept.
+// If the generator was created with WithStartupCheck, Restore may return
+// ErrClockBehind, restoring nothing.
+func (g *Generator) Restore(b []byte) error {
+	return g.restore(b, false)
+}
+
+// GobEncode implements gob.GobEncoder, so that a generator's state can be
+// included in data encoded with encoding/gob. The encoding is a snapshot as
+// taken by Snapshot.
+func (g *Generator) GobEncode() ([]byte, error) {
+	return g.Snapshot()
+}
+
+// GobDecode implements gob.GobDecoder. Decoding into a generator created by
+// New restores the snapshot as for Restore. Otherwise, as when gob allocates
+// a new Generator for a pointer field, the generator is initialized with the
+// snapshot's layout and node ID and the system clock, and default settings
+// for everything else, since other options aren't captured by snapshots.
+func (g *Generator) GobDecode(b []byte) error {
+	return g.restore(b, g.store == nil)
+}
+
+// restore restores a snapshot, first initializing the generator from the
+// snapshot if init is true.
+func (g *Generator) restore(b []byte, init bool) error {
+	r := bufio.NewReader(bytes.NewReader(b))
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return ErrSnapshot
+	}
+	var h snapshotHeader
+	if err := json.Unmarshal(line, &h); err != nil || h.Version != snapshotVersion {
+		return ErrSnapshot
+	}
+	l := Layout(h.Layout)
+	if init {
+		if l.check() != nil || h.Node < 0 || h.Node > l.maxNode() {
+			return ErrSnapshot
+		}
+		g.layout = l
+		g.node = h.Node
+		g.clock = systemClock{}
+		g.store = newMapStore()
+	}
+	if !sameLayout(l, g.layout) || h.Node != g.node {
+		return ErrSnapshot
+	}
+	if err := g.checkStartup(h.Last, 0); err != nil {
+		return err
+	}
+	g.raise(h.Last)
+	return g.importSeen(r, h.Last)
+}
+
+// sameLayout reports whether two layouts are the same.
+func sameLayout(a, b Layout) bool {
+	return a.TimeBits == b.TimeBits && a.NodeBits == b.NodeBits &&
+		a.SequenceBits == b.SequenceBits && a.Resolution == b.Resolution &&
+		a.Epoch.Equal(b.Epoch)
+}
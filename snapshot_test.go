@@ -0,0 +1,120 @@
+package serial
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk))
+	x := g.Generate()
+	g.SetSeen(x)
+	g.SetSeenWithValue(x+1, "order 1")
+	g.SetSeenFor(x+2, time.Minute)
+	g.GenerateN(100)
+	last := g.Generate()
+	b, err := g.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewGenerator(WithClock(clk))
+	if err := h.Restore(b); err != nil {
+		t.Fatal(err)
+	}
+	if y := h.Generate(); y <= last {
+		t.Errorf("Restored generator issued %d, not after %d", y, last)
+	}
+	if !h.Seen(x) || !h.Seen(x+2) {
+		t.Error("Seen history wasn't restored")
+	}
+	if v, ok := h.SeenValue(x + 1); !ok || v != "order 1" {
+		t.Errorf("Expected attached value, got %v", v)
+	}
+	clk.Advance(2 * time.Minute)
+	if h.Seen(x + 2) {
+		t.Error("Time to live wasn't restored")
+	}
+	for _, bad := range [][]byte{nil, []byte("{}\n"), []byte("junk\n")} {
+		if err := NewGenerator().Restore(bad); err != ErrSnapshot {
+			t.Errorf("Expected ErrSnapshot for %q, got %v", bad, err)
+		}
+	}
+	if err := NewGenerator(WithLayout(SnowflakeLayout)).Restore(b); err != ErrSnapshot {
+		t.Errorf("Expected ErrSnapshot for a different layout, got %v", err)
+	}
+}
+
+func TestRestoreInconsistent(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk))
+	last := g.Generate()
+	// The seen history holds values after the saved last value, as if the
+	// header had been written before they were issued.
+	h, err := json.Marshal(snapshotHeader{Version: snapshotVersion, Last: last, Layout: layoutFields(g.layout), Node: g.node})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := append(h, '\n')
+	for _, x := range []Serial{last - 1, last + 5, last + 10} {
+		b = append(b, `{"serial":`+x.String()+"}\n"...)
+	}
+	r := NewGenerator(WithClock(clk))
+	ch := r.Events()
+	if err := r.Restore(b); err != nil {
+		t.Fatal(err)
+	}
+	var raised []Serial
+	for len(ch) > 0 {
+		if e := <-ch; e.Kind == EventRaise {
+			raised = append(raised, e.Serial)
+		}
+	}
+	if len(raised) != 2 || raised[0] != last+5 || raised[1] != last+10 {
+		t.Errorf("Expected EventRaise for %d and %d, got %v", last+5, last+10, raised)
+	}
+	if n := r.Stats().Raised; n != 2 {
+		t.Errorf("Expected 2 raised got %d", n)
+	}
+	if y := r.Generate(); y <= last+10 {
+		t.Errorf("Expected value after %d got %d", last+10, y)
+	}
+}
+
+func TestGob(t *testing.T) {
+	type checkpoint struct {
+		Name string
+		Gen  *Generator
+	}
+	g := NewGenerator(WithLayout(SnowflakeLayout), WithNode(7))
+	x := g.Generate()
+	g.SetSeen(x)
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(checkpoint{Name: "tokens", Gen: g}); err != nil {
+		t.Fatal(err)
+	}
+	var cp checkpoint
+	if err := gob.NewDecoder(&buf).Decode(&cp); err != nil {
+		t.Fatal(err)
+	}
+	h := cp.Gen
+	if cp.Name != "tokens" || h == nil {
+		t.Fatalf("Wrong checkpoint decoded: %+v", cp)
+	}
+	if !h.Seen(x) {
+		t.Error("Seen history wasn't decoded")
+	}
+	y := h.Generate()
+	if y <= x {
+		t.Errorf("Decoded generator issued %d, not after %d", y, x)
+	}
+	if _, node, _ := SnowflakeLayout.unpack(y); node != 7 {
+		t.Errorf("Expected node 7 got %d", node)
+	}
+	if err := new(Generator).GobDecode([]byte("junk\n")); err != ErrSnapshot {
+		t.Errorf("Expected ErrSnapshot got %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package serial
+
+// Source is something which issues serial values. It is implemented by
+// Generator, so that code which only needs to generate values can depend on
+// a Source and be given something else in tests.
+type Source interface {
+	Generate() Serial
+}
+
+// SourceFunc adapts a function to a Source. For example, a test can supply
+// a canned sequence:
+//
+//	xs := []serial.Serial{1, 2, 3}
+//	src := serial.SourceFunc(func() serial.Serial {
+//		x := xs[0]
+//		xs = xs[1:]
+//		return x
+//	})
+type SourceFunc func() Serial
+
+// Generate calls f.
+func (f SourceFunc) Generate() Serial {
+	return f()
+}
+
+var _ Source = (*Generator)(nil)
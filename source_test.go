@@ -0,0 +1,17 @@
+package serial
+
+import "testing"
+
+func TestSourceFunc(t *testing.T) {
+	xs := []Serial{3, 1, 2}
+	var src Source = SourceFunc(func() Serial {
+		x := xs[0]
+		xs = xs[1:]
+		return x
+	})
+	for _, want := range []Serial{3, 1, 2} {
+		if x := src.Generate(); x != want {
+			t.Errorf("Expected %d got %d", want, x)
+		}
+	}
+}
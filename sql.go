@@ -0,0 +1,38 @@
+package serial
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+)
+
+// Value implements driver.Valuer, so that serial values can be stored in
+// integer database columns.
+func (x Serial) Value() (driver.Value, error) {
+	return int64(x), nil
+}
+
+// Scan implements sql.Scanner, so that serial values can be read from
+// database columns. Integer columns are read directly, and text or binary
+// columns are parsed as decimal digits.
+func (x *Serial) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case int64:
+		*x = Serial(v)
+		return nil
+	case []byte:
+		return x.scanString(string(v))
+	case string:
+		return x.scanString(v)
+	}
+	return fmt.Errorf("serial: cannot scan %T into Serial", src)
+}
+
+func (x *Serial) scanString(s string) error {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return ErrInvalidDecimal
+	}
+	*x = Serial(n)
+	return nil
+}
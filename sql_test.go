@@ -0,0 +1,101 @@
+package serial
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+)
+
+// mockDriver is a database driver which remembers the last value inserted,
+// and returns it from any query, converted to the column type named by the
+// query.
+type mockDriver struct {
+	stored driver.Value
+}
+
+type mockConn struct{ d *mockDriver }
+
+type mockStmt struct {
+	c     *mockConn
+	query string
+}
+
+type mockRows struct {
+	val  driver.Value
+	done bool
+}
+
+func (d *mockDriver) Open(name string) (driver.Conn, error) { return &mockConn{d}, nil }
+
+func (c *mockConn) Prepare(query string) (driver.Stmt, error) { return &mockStmt{c, query}, nil }
+func (c *mockConn) Close() error                              { return nil }
+func (c *mockConn) Begin() (driver.Tx, error)                 { return nil, errors.New("unsupported") }
+
+func (s *mockStmt) Close() error  { return nil }
+func (s *mockStmt) NumInput() int { return -1 }
+
+func (s *mockStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.d.stored = args[0]
+	return driver.RowsAffected(1), nil
+}
+
+func (s *mockStmt) Query(args []driver.Value) (driver.Rows, error) {
+	v := s.c.d.stored
+	switch s.query {
+	case "bytes":
+		v = []byte(strconv.FormatInt(v.(int64), 10))
+	case "string":
+		v = strconv.FormatInt(v.(int64), 10)
+	case "null":
+		v = nil
+	}
+	return &mockRows{val: v}, nil
+}
+
+func (r *mockRows) Columns() []string { return []string{"id"} }
+func (r *mockRows) Close() error      { return nil }
+
+func (r *mockRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.val
+	return nil
+}
+
+func init() {
+	sql.Register("serialmock", &mockDriver{})
+}
+
+func TestSQL(t *testing.T) {
+	db, err := sql.Open("serialmock", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	x := gen.Generate()
+	if _, err := db.Exec("insert", x); err != nil {
+		t.Fatal(err)
+	}
+	for _, col := range []string{"int64", "bytes", "string"} {
+		var y Serial
+		if err := db.QueryRow(col).Scan(&y); err != nil {
+			t.Fatalf("Failed to scan %s column: %v", col, err)
+		}
+		if y != x {
+			t.Errorf("Round trip via %s column failed, expected %d got %d", col, x, y)
+		}
+	}
+	var y Serial
+	if err := db.QueryRow("null").Scan(&y); err == nil {
+		t.Error("Expected an error scanning NULL")
+	}
+	var n sql.NullInt64
+	if err := db.QueryRow("null").Scan(&n); err != nil || n.Valid {
+		t.Errorf("Expected NULL, got %v %v", n, err)
+	}
+}
@@ -0,0 +1,413 @@
+// Package sqlstore keeps the seen history of a serial.Generator in a SQL
+// database, using database/sql, so that application servers sharing a
+// database also share one history and a one-time value can only be used
+// once, whichever server receives it.
+//
+//	db, err := sql.Open("pgx", dsn)
+//	...
+//	if err := sqlstore.Migrate(ctx, db, sqlstore.Postgres, "seen"); err != nil {
+//		...
+//	}
+//	st, err := sqlstore.New(db, sqlstore.Postgres, "seen", nil)
+//	...
+//	gen := serial.NewGenerator(serial.WithSeenStore(st))
+//
+// Values are kept in a table with one row per value, holding its expiry time
+// and any attached value as JSON. The table is indexed on expiry time, so
+// that ExpireBefore doesn't have to scan it. Concurrent calls to Set are
+// batched into a single transaction, so that a busy generator doesn't need a
+// transaction per value.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/lpar/serial"
+)
+
+var (
+	// ErrCorrupt is reported when an entry in the database can't be
+	// decoded.
+	ErrCorrupt = errors.New("sqlstore: corrupt entry")
+	// ErrTable is returned when asked to use a table name which isn't a
+	// plain SQL identifier.
+	ErrTable = errors.New("sqlstore: invalid table name")
+)
+
+const (
+	// maxBatch is the largest number of values Set records in one
+	// transaction.
+	maxBatch = 100
+	// rangeBatch is the number of entries Range reads in each query.
+	rangeBatch = 1000
+)
+
+// Dialect describes the SQL understood by a database.
+type Dialect struct {
+	name        string
+	dollar      bool
+	insert      string
+	valueType   string
+	inlineIndex bool
+}
+
+var (
+	// Postgres is the dialect of PostgreSQL.
+	Postgres = Dialect{name: "postgres", dollar: true, insert: "INSERT INTO %s (serial, expires, value) VALUES (%s, %s, %s) ON CONFLICT DO NOTHING", valueType: "TEXT"}
+	// MySQL is the dialect of MySQL and MariaDB.
+	MySQL = Dialect{name: "mysql", insert: "INSERT IGNORE INTO %s (serial, expires, value) VALUES (%s, %s, %s)", valueType: "MEDIUMTEXT", inlineIndex: true}
+	// SQLite is the dialect of SQLite.
+	SQLite = Dialect{name: "sqlite", insert: "INSERT OR IGNORE INTO %s (serial, expires, value) VALUES (%s, %s, %s)", valueType: "TEXT"}
+)
+
+// String returns the name of the dialect.
+func (d Dialect) String() string {
+	return d.name
+}
+
+// arg returns the placeholder for the nth argument of a statement, counting
+// from 1.
+func (d Dialect) arg(n int) string {
+	if d.dollar {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// Schema returns the statements which create the table, and its index, if
+// they don't already exist, for use with a migration tool. Migrate runs
+// them directly.
+//
+// Expiry times are stored as Unix times in nanoseconds, or 0 for values which
+// expire by age.
+func (d Dialect) Schema(table string) ([]string, error) {
+	if !identifier(table) {
+		return nil, ErrTable
+	}
+	cols := fmt.Sprintf("serial BIGINT NOT NULL PRIMARY KEY, expires BIGINT NOT NULL DEFAULT 0, value %s NULL", d.valueType)
+	index := table + "_expires"
+	if d.inlineIndex {
+		return []string{
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s, INDEX %s (expires, serial))", table, cols, index),
+		}, nil
+	}
+	return []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", table, cols),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (expires, serial)", index, table),
+	}, nil
+}
+
+// Migrate creates the table, and its index, if they don't already exist.
+func Migrate(ctx context.Context, db *sql.DB, d Dialect, table string) error {
+	stmts, err := d.Schema(table)
+	if err != nil {
+		return err
+	}
+	for _, s := range stmts {
+		if _, err := db.ExecContext(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// identifier reports whether s is a plain SQL identifier, which can be used
+// in statements without quoting.
+func identifier(s string) bool {
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// Store is a serial.SeenStore backed by a SQL database.
+//
+// The SeenStore interface has no way to report errors, so a Store fails
+// closed: if the database can't be read, values are reported as seen, and if
+// a value can't be recorded, it is reported as already seen, so that a
+// one-time value is never accepted twice. Errors are passed to the handler
+// given when the Store was created.
+type Store struct {
+	db      *sql.DB
+	onError func(error)
+
+	replace    string
+	insert     string
+	get        string
+	remove     string
+	expireAge  string
+	expireTime string
+	list       string
+	count      string
+
+	mutex    sync.Mutex
+	pending  []*write
+	flushing bool
+}
+
+// write is a call to Set waiting to be recorded in a batch.
+type write struct {
+	x     serial.Serial
+	exp   int64
+	value sql.NullString
+	now   int64
+	added bool
+	done  chan struct{}
+}
+
+// New returns a Store which keeps the history in the specified table, which
+// must already exist; see Migrate. Stores using the same table share a
+// history. The handler is called with any errors from the database; it may
+// be nil.
+func New(db *sql.DB, d Dialect, table string, onError func(error)) (*Store, error) {
+	if !identifier(table) {
+		return nil, ErrTable
+	}
+	return &Store{
+		db:         db,
+		onError:    onError,
+		replace:    fmt.Sprintf("DELETE FROM %s WHERE serial = %s AND expires > 0 AND expires <= %s", table, d.arg(1), d.arg(2)),
+		insert:     fmt.Sprintf(d.insert, table, d.arg(1), d.arg(2), d.arg(3)),
+		get:        fmt.Sprintf("SELECT expires, value FROM %s WHERE serial = %s", table, d.arg(1)),
+		remove:     fmt.Sprintf("DELETE FROM %s WHERE serial = %s", table, d.arg(1)),
+		expireAge:  fmt.Sprintf("DELETE FROM %s WHERE expires = 0 AND serial < %s", table, d.arg(1)),
+		expireTime: fmt.Sprintf("DELETE FROM %s WHERE expires > 0 AND expires <= %s", table, d.arg(1)),
+		list:       fmt.Sprintf("SELECT serial, expires, value FROM %s WHERE serial >= %s ORDER BY serial LIMIT %d", table, d.arg(1), rangeBatch),
+		count:      fmt.Sprintf("SELECT COUNT(*) FROM %s", table),
+	}, nil
+}
+
+// fail reports an error to the handler.
+func (st *Store) fail(err error) {
+	if st.onError != nil {
+		st.onError(err)
+	}
+}
+
+// Set implements serial.SeenStore. If other calls are already waiting for
+// the database, the value is recorded along with theirs, in the same
+// transaction.
+func (st *Store) Set(x serial.Serial, e serial.SeenEntry, now time.Time) bool {
+	w := &write{x: x, now: now.UnixNano(), done: make(chan struct{})}
+	if !e.Expires.IsZero() {
+		w.exp = e.Expires.UnixNano()
+	}
+	if e.Value != nil {
+		v, err := json.Marshal(e.Value)
+		if err != nil {
+			st.fail(err)
+			return false
+		}
+		w.value = sql.NullString{String: string(v), Valid: true}
+	}
+	st.mutex.Lock()
+	st.pending = append(st.pending, w)
+	if st.flushing {
+		st.mutex.Unlock()
+		<-w.done
+		return w.added
+	}
+	// This call writes batches until there are no more waiting, including
+	// its own.
+	st.flushing = true
+	for len(st.pending) > 0 {
+		batch := st.pending
+		if len(batch) > maxBatch {
+			batch = batch[:maxBatch]
+		}
+		st.pending = st.pending[len(batch):]
+		st.mutex.Unlock()
+		st.flush(batch)
+		st.mutex.Lock()
+	}
+	st.flushing = false
+	st.pending = nil
+	st.mutex.Unlock()
+	return w.added
+}
+
+// flush records a batch of values in one transaction, and wakes the calls
+// waiting for them.
+func (st *Store) flush(batch []*write) {
+	defer func() {
+		for _, w := range batch {
+			close(w.done)
+		}
+	}()
+	ctx := context.Background()
+	tx, err := st.db.BeginTx(ctx, nil)
+	if err != nil {
+		st.fail(err)
+		return
+	}
+	for _, w := range batch {
+		// An entry which has expired but not yet been removed counts as
+		// not seen, so it is replaced.
+		if _, err = tx.ExecContext(ctx, st.replace, int64(w.x), w.now); err != nil {
+			break
+		}
+		var res sql.Result
+		if res, err = tx.ExecContext(ctx, st.insert, int64(w.x), w.exp, w.value); err != nil {
+			break
+		}
+		var n int64
+		if n, err = res.RowsAffected(); err != nil {
+			break
+		}
+		w.added = n == 1
+	}
+	if err == nil {
+		err = tx.Commit()
+	} else {
+		tx.Rollback()
+	}
+	if err != nil {
+		st.fail(err)
+		for _, w := range batch {
+			w.added = false
+		}
+	}
+}
+
+// Has implements serial.SeenStore.
+func (st *Store) Has(x serial.Serial) (serial.SeenEntry, bool) {
+	var (
+		exp   int64
+		value sql.NullString
+	)
+	err := st.db.QueryRowContext(context.Background(), st.get, int64(x)).Scan(&exp, &value)
+	if err == sql.ErrNoRows {
+		return serial.SeenEntry{}, false
+	}
+	if err != nil {
+		st.fail(err)
+		return serial.SeenEntry{}, true
+	}
+	e, err := decode(exp, value)
+	if err != nil {
+		st.fail(err)
+	}
+	return e, true
+}
+
+// Delete implements serial.SeenStore.
+func (st *Store) Delete(x serial.Serial) bool {
+	n, err := st.exec(st.remove, int64(x))
+	if err != nil {
+		st.fail(err)
+		return false
+	}
+	return n == 1
+}
+
+// ExpireBefore implements serial.SeenStore. Values which expire by age and
+// values with a time to live are removed by separate statements, each of
+// which can use the table's index.
+func (st *Store) ExpireBefore(limit serial.Serial, now time.Time) int {
+	n, err := st.exec(st.expireAge, int64(limit))
+	if err != nil {
+		st.fail(err)
+		return 0
+	}
+	m, err := st.exec(st.expireTime, now.UnixNano())
+	if err != nil {
+		st.fail(err)
+	}
+	return int(n + m)
+}
+
+// exec executes a statement outside a transaction, and returns the number of
+// rows it affected.
+func (st *Store) exec(query string, args ...interface{}) (int64, error) {
+	res, err := st.db.ExecContext(context.Background(), query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Range implements serial.SeenStore. Entries are read in batches, in order,
+// so changes made during the call may or may not be visited.
+func (st *Store) Range(f func(x serial.Serial, e serial.SeenEntry) bool) {
+	type item struct {
+		x serial.Serial
+		e serial.SeenEntry
+	}
+	from := int64(math.MinInt64)
+	for {
+		rows, err := st.db.QueryContext(context.Background(), st.list, from)
+		if err != nil {
+			st.fail(err)
+			return
+		}
+		var items []item
+		for rows.Next() {
+			var (
+				x     int64
+				exp   int64
+				value sql.NullString
+			)
+			if err := rows.Scan(&x, &exp, &value); err != nil {
+				st.fail(err)
+				continue
+			}
+			e, err := decode(exp, value)
+			if err != nil {
+				st.fail(err)
+			}
+			items = append(items, item{serial.Serial(x), e})
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			st.fail(err)
+			return
+		}
+		for _, it := range items {
+			if !f(it.x, it.e) {
+				return
+			}
+		}
+		if len(items) < rangeBatch || items[len(items)-1].x == math.MaxInt64 {
+			return
+		}
+		from = int64(items[len(items)-1].x) + 1
+	}
+}
+
+// Len implements serial.SeenStore.
+func (st *Store) Len() int {
+	var n int
+	if err := st.db.QueryRowContext(context.Background(), st.count).Scan(&n); err != nil {
+		st.fail(err)
+		return 0
+	}
+	return n
+}
+
+// decode decodes an entry read from the database. Attached values are
+// decoded as for json.Unmarshal into an interface{}.
+func decode(exp int64, value sql.NullString) (serial.SeenEntry, error) {
+	var e serial.SeenEntry
+	if exp != 0 {
+		e.Expires = time.Unix(0, exp)
+	}
+	if value.Valid {
+		if err := json.Unmarshal([]byte(value.String), &e.Value); err != nil {
+			return e, ErrCorrupt
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,328 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lpar/serial"
+)
+
+// fakeDB is an in-memory database which understands only the statements the
+// store uses with the SQLite dialect and a table called seen.
+type fakeDB struct {
+	mutex  sync.Mutex
+	rows   map[int64]fakeRow
+	stmts  []string
+	txs    int
+	broken bool
+}
+
+type fakeRow struct {
+	expires int64
+	value   driver.Value
+}
+
+var errBroken = errors.New("database unavailable")
+
+func init() {
+	sql.Register("sqlstore-fake", fakeDriver{})
+}
+
+var (
+	fakeMutex sync.Mutex
+	fakeDBs   = make(map[string]*fakeDB)
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeMutex.Lock()
+	defer fakeMutex.Unlock()
+	db := fakeDBs[name]
+	if db == nil {
+		db = &fakeDB{rows: make(map[int64]fakeRow)}
+		fakeDBs[name] = db
+	}
+	return &fakeConn{db: db}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.mutex.Lock()
+	defer c.db.mutex.Unlock()
+	if c.db.broken {
+		return nil, errBroken
+	}
+	c.db.txs++
+	return fakeTx{}, nil
+}
+
+// fakeTx applies statements immediately, so it can't roll them back.
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	db := s.db
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if db.broken {
+		return nil, errBroken
+	}
+	db.stmts = append(db.stmts, s.query)
+	var n int64
+	switch {
+	case strings.HasPrefix(s.query, "CREATE "):
+	case s.query == "DELETE FROM seen WHERE serial = ? AND expires > 0 AND expires <= ?":
+		if r, ok := db.rows[args[0].(int64)]; ok && r.expires > 0 && r.expires <= args[1].(int64) {
+			delete(db.rows, args[0].(int64))
+			n = 1
+		}
+	case s.query == "INSERT OR IGNORE INTO seen (serial, expires, value) VALUES (?, ?, ?)":
+		if _, ok := db.rows[args[0].(int64)]; !ok {
+			db.rows[args[0].(int64)] = fakeRow{expires: args[1].(int64), value: args[2]}
+			n = 1
+		}
+	case s.query == "DELETE FROM seen WHERE serial = ?":
+		if _, ok := db.rows[args[0].(int64)]; ok {
+			delete(db.rows, args[0].(int64))
+			n = 1
+		}
+	case s.query == "DELETE FROM seen WHERE expires = 0 AND serial < ?":
+		for x, r := range db.rows {
+			if r.expires == 0 && x < args[0].(int64) {
+				delete(db.rows, x)
+				n++
+			}
+		}
+	case s.query == "DELETE FROM seen WHERE expires > 0 AND expires <= ?":
+		for x, r := range db.rows {
+			if r.expires > 0 && r.expires <= args[0].(int64) {
+				delete(db.rows, x)
+				n++
+			}
+		}
+	default:
+		return nil, errors.New("unexpected statement: " + s.query)
+	}
+	return driver.RowsAffected(n), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	db := s.db
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if db.broken {
+		return nil, errBroken
+	}
+	rows := &fakeRows{}
+	switch s.query {
+	case "SELECT expires, value FROM seen WHERE serial = ?":
+		rows.cols = []string{"expires", "value"}
+		if r, ok := db.rows[args[0].(int64)]; ok {
+			rows.vals = append(rows.vals, []driver.Value{r.expires, r.value})
+		}
+	case "SELECT serial, expires, value FROM seen WHERE serial >= ? ORDER BY serial LIMIT 1000":
+		rows.cols = []string{"serial", "expires", "value"}
+		var xs []int64
+		for x := range db.rows {
+			if x >= args[0].(int64) {
+				xs = append(xs, x)
+			}
+		}
+		sort.Slice(xs, func(i, j int) bool { return xs[i] < xs[j] })
+		if len(xs) > 1000 {
+			xs = xs[:1000]
+		}
+		for _, x := range xs {
+			rows.vals = append(rows.vals, []driver.Value{x, db.rows[x].expires, db.rows[x].value})
+		}
+	case "SELECT COUNT(*) FROM seen":
+		rows.cols = []string{"count"}
+		rows.vals = [][]driver.Value{{int64(len(db.rows))}}
+	default:
+		return nil, errors.New("unexpected query: " + s.query)
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	cols []string
+	vals [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.vals) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.vals[0])
+	r.vals = r.vals[1:]
+	return nil
+}
+
+func open(t *testing.T) (*Store, *fakeDB) {
+	db, err := sql.Open("sqlstore-fake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := Migrate(context.Background(), db, SQLite, "seen"); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	st, err := New(db, SQLite, "seen", func(err error) { t.Errorf("Unexpected store error: %v", err) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeMutex.Lock()
+	defer fakeMutex.Unlock()
+	return st, fakeDBs[t.Name()]
+}
+
+func TestStore(t *testing.T) {
+	st, _ := open(t)
+	g := serial.NewGenerator(serial.WithSeenStore(st))
+	x := g.Generate()
+	y := g.Generate()
+	z := g.Generate()
+	g.SetSeenWithValue(x, "alice")
+	g.SetSeenFor(y, time.Hour)
+	if g.SeenOrSet(z) || !g.SeenOrSet(z) {
+		t.Errorf("Expected SeenOrSet(%d) to succeed exactly once", z)
+	}
+	if v, ok := g.SeenValue(x); !ok || v != "alice" {
+		t.Errorf("Expected alice and 'seen' got %v and %v", v, ok)
+	}
+	if e, ok := st.Has(y); !ok || e.Expires.IsZero() {
+		t.Errorf("Expected %d seen with expiry, got %v and %v", y, e, ok)
+	}
+	if n := g.SeenCount(); n != 3 {
+		t.Errorf("Expected 3 seen values got %d", n)
+	}
+	var xs []serial.Serial
+	st.Range(func(x serial.Serial, e serial.SeenEntry) bool {
+		xs = append(xs, x)
+		return true
+	})
+	if len(xs) != 3 || xs[0] != x || xs[2] != z {
+		t.Errorf("Expected %d, %d and %d got %v", x, y, z, xs)
+	}
+	if !g.UnsetSeen(z) || g.Seen(z) {
+		t.Errorf("Expected %d to be unset", z)
+	}
+	// Expire x by age, but not y, which has a TTL.
+	if n := st.ExpireBefore(z, time.Now()); n != 1 {
+		t.Errorf("Expected 1 value expired got %d", n)
+	}
+	if n := st.ExpireBefore(z, time.Now().Add(2*time.Hour)); n != 1 {
+		t.Errorf("Expected 1 value expired got %d", n)
+	}
+	// An expired entry which hasn't been removed is replaced.
+	now := time.Now()
+	st.Set(x, serial.SeenEntry{Expires: now.Add(-time.Second)}, now)
+	if !st.Set(x, serial.SeenEntry{}, now) {
+		t.Errorf("Expected expired entry for %d to be replaced", x)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	st, db := open(t)
+	now := time.Now()
+	var wg sync.WaitGroup
+	added := make([]bool, 500)
+	for i := range added {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			added[i] = st.Set(serial.Serial(i%250), serial.SeenEntry{}, now)
+		}(i)
+	}
+	wg.Wait()
+	n := 0
+	for _, ok := range added {
+		if ok {
+			n++
+		}
+	}
+	if n != 250 {
+		t.Errorf("Expected 250 values added got %d", n)
+	}
+	if n := st.Len(); n != 250 {
+		t.Errorf("Expected 250 values stored got %d", n)
+	}
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if db.txs < 5 || db.txs > 500 {
+		t.Errorf("Expected between 5 and 500 transactions, got %d", db.txs)
+	}
+}
+
+func TestFailClosed(t *testing.T) {
+	st, db := open(t)
+	var errs int
+	st.onError = func(error) { errs++ }
+	db.mutex.Lock()
+	db.broken = true
+	db.mutex.Unlock()
+	if st.Set(1, serial.SeenEntry{}, time.Now()) {
+		t.Error("Expected Set to fail closed")
+	}
+	if _, ok := st.Has(2); !ok {
+		t.Error("Expected Has to fail closed")
+	}
+	if errs != 2 {
+		t.Errorf("Expected 2 errors reported got %d", errs)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	if _, err := New(nil, Postgres, "seen; DROP TABLE users", nil); err != ErrTable {
+		t.Errorf("Expected ErrTable got %v", err)
+	}
+	for _, d := range []Dialect{Postgres, MySQL, SQLite} {
+		stmts, err := d.Schema("seen")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(strings.Join(stmts, ";"), "(expires, serial)") {
+			t.Errorf("Expected %v schema to index expiry, got %v", d, stmts)
+		}
+	}
+	st, err := New(nil, Postgres, "seen", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.replace != "DELETE FROM seen WHERE serial = $1 AND expires > 0 AND expires <= $2" {
+		t.Errorf("Wrong Postgres statement: %s", st.replace)
+	}
+}
@@ -0,0 +1,62 @@
+package serial
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrStartupCheck is returned when asked to create a generator which
+	// checks its clock against persisted state with a negative tolerance.
+	ErrStartupCheck = errors.New("serial: invalid startup check")
+	// ErrClockBehind is returned by New and Restore when the persisted state
+	// being restored is further ahead of the clock than the tolerance set by
+	// WithStartupCheck, and no function was given to call instead.
+	ErrClockBehind = errors.New("serial: clock behind persisted state")
+)
+
+// startupCheck holds the settings for WithStartupCheck.
+type startupCheck struct {
+	tolerance time.Duration
+	f         func(time.Duration)
+}
+
+// WithStartupCheck makes the generator compare the time embedded in the last
+// value of any persisted state it is restored from, a checkpoint or mapped
+// state loaded by New or a snapshot passed to Restore, with its clock. If the
+// state is more than tolerance ahead of the clock, suggesting that the clock
+// has moved backwards while the generator was down, the generator refuses to
+// start: New or Restore returns ErrClockBehind. If f isn't nil, it is called
+// with the distance instead, and the generator starts anyway, running ahead
+// of the clock as usual.
+//
+// A checkpoint is saved with the lead set by WithCheckpoint, so the lead is
+// allowed for before comparing. The check is skipped for generators which
+// lease values from an Allocator or count with WithCounter, since their
+// values aren't tied to the clock.
+func WithStartupCheck(tolerance time.Duration, f func(time.Duration)) Option {
+	return func(g *Generator) error {
+		if tolerance < 0 {
+			return ErrStartupCheck
+		}
+		g.startup = &startupCheck{tolerance: tolerance, f: f}
+		return nil
+	}
+}
+
+// checkStartup compares x, the last value of persisted state saved lead
+// ahead of the values issued, with the clock, as set by WithStartupCheck.
+func (g *Generator) checkStartup(x Serial, lead time.Duration) error {
+	if g.startup == nil || g.counter || g.leaser != nil || x <= 0 {
+		return nil
+	}
+	d := g.layout.timeOf(x).Add(-lead).Sub(g.clock.Now())
+	if d <= g.startup.tolerance {
+		return nil
+	}
+	if g.startup.f == nil {
+		return ErrClockBehind
+	}
+	g.startup.f(d)
+	return nil
+}
@@ -0,0 +1,49 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStartupCheck(t *testing.T) {
+	if _, err := New(WithStartupCheck(-time.Second, nil)); err != ErrStartupCheck {
+		t.Errorf("Expected ErrStartupCheck got %v", err)
+	}
+	cp := &countingCheckpoint{}
+	clk := &fakeClock{now: time.Unix(2000, 0)}
+	g := NewGenerator(WithClock(clk), WithCheckpoint(cp, time.Minute))
+	g.Generate()
+	snap, err := g.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The checkpoint's lead doesn't count against the tolerance.
+	if _, err := New(WithClock(clk), WithCheckpoint(cp, time.Minute), WithStartupCheck(time.Second, nil)); err != nil {
+		t.Errorf("Expected start with the clock unchanged, got %v", err)
+	}
+	clk.Advance(-time.Hour)
+	if _, err := New(WithClock(clk), WithCheckpoint(cp, time.Minute), WithStartupCheck(time.Minute, nil)); err != ErrClockBehind {
+		t.Errorf("Expected ErrClockBehind got %v", err)
+	}
+	var behind time.Duration
+	g2, err := New(WithClock(clk), WithCheckpoint(cp, time.Minute), WithStartupCheck(time.Minute, func(d time.Duration) { behind = d }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if behind != time.Hour {
+		t.Errorf("Expected callback with 1h0m0s got %v", behind)
+	}
+	if x := g2.Generate(); clk.now.After(g2.layout.timeOf(x)) {
+		t.Errorf("Expected %d to run ahead of the clock", x)
+	}
+
+	g3 := NewGenerator(WithClock(clk), WithStartupCheck(time.Minute, nil))
+	if err := g3.Restore(snap); err != ErrClockBehind {
+		t.Errorf("Expected ErrClockBehind from Restore got %v", err)
+	}
+	clk.Advance(time.Hour)
+	if err := g3.Restore(snap); err != nil {
+		t.Errorf("Expected Restore to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package serial
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats holds figures describing a generator's activity since it was
+// created, for monitoring.
+type Stats struct {
+	// Generated is the number of serial values issued, including those in
+	// blocks claimed with ReserveBlock, but not those from Generate128.
+	Generated uint64
+	// Seen is the number of values in the seen history, as returned by
+	// SeenCount.
+	Seen int
+	// Expirations is the number of times the seen history has been expired.
+	Expirations uint64
+	// Expired is the number of values removed from the seen history by
+	// expiry.
+	Expired uint64
+	// Rollbacks is the number of times the clock has been found to have
+	// moved backwards.
+	Rollbacks uint64
+	// Ahead is the number of values issued whose embedded times were ahead
+	// of the clock when they were issued, because values were being
+	// generated faster than the clock ticks, or the clock had moved
+	// backwards. A value from a block counts if its own embedded time was
+	// ahead.
+	Ahead uint64
+	// Raised is the number of imported values which the generator has had
+	// to move on past because they were ahead of it, as reported by
+	// EventRaise. Unlike the events, which may be dropped, it counts every
+	// one.
+	Raised uint64
+	// LastGenerated is the time according to the generator's clock at which
+	// it last issued a value, or the zero time if it hasn't yet.
+	LastGenerated time.Time
+}
+
+// counters accumulates the figures reported by Stats.
+type counters struct {
+	generated   atomic.Uint64
+	expirations atomic.Uint64
+	expired     atomic.Uint64
+	rollbacks   atomic.Uint64
+	ahead       atomic.Uint64
+	raised      atomic.Uint64
+	last        atomic.Int64
+}
+
+// Stats returns figures describing the generator's activity so far. The
+// figures are read one at a time, so they may not be consistent with each
+// other if the generator is in use.
+func (g *Generator) Stats() Stats {
+	s := Stats{
+		Generated:   g.counters.generated.Load(),
+		Seen:        g.SeenCount(),
+		Expirations: g.counters.expirations.Load(),
+		Expired:     g.counters.expired.Load(),
+		Rollbacks:   g.counters.rollbacks.Load(),
+		Ahead:       g.counters.ahead.Load(),
+		Raised:      g.counters.raised.Load(),
+	}
+	if t := g.counters.last.Load(); t != 0 {
+		s.LastGenerated = time.Unix(0, t)
+	}
+	return s
+}
+
+// ahead returns the least value whose embedded time is ahead of now.
+func (g *Generator) ahead(now time.Time) Serial {
+	return g.layout.pack(g.layout.ticks(now)+1, 0, 0)
+}
+
+// countSerials counts the ascending values in xs as issued at the time now.
+func (g *Generator) countSerials(now time.Time, xs []Serial) {
+	g.counters.generated.Add(uint64(len(xs)))
+	if n := len(xs) - Serials(xs).Search(g.ahead(now)); n > 0 {
+		g.counters.ahead.Add(uint64(n))
+	}
+	g.counters.last.Store(now.UnixNano())
+}
+
+// countRange counts the values in r as issued at the time now.
+func (g *Generator) countRange(now time.Time, r Range) {
+	g.counters.generated.Add(uint64(r.Len()))
+	if a := g.ahead(now); r.Last >= a {
+		if r.First > a {
+			a = r.First
+		}
+		g.counters.ahead.Add(uint64(r.Last-a) + 1)
+	}
+	g.counters.last.Store(now.UnixNano())
+}
@@ -0,0 +1,38 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk))
+	g.SetSeen(g.Generate())
+	g.SetSeen(g.Generate())
+	g.GenerateN(10)
+	g.ReserveBlock(100)
+	clk.Advance(time.Hour)
+	g.ExpireSeen(time.Minute)
+	g.Generate()
+	clk.Advance(-time.Second)
+	g.Generate()
+	want := Stats{
+		Generated:     114,
+		Seen:          0,
+		Expirations:   1,
+		Expired:       2,
+		Rollbacks:     1,
+		Ahead:         112,
+		LastGenerated: time.Unix(0, clk.now.UnixNano()),
+	}
+	if s := g.Stats(); s != want {
+		t.Errorf("Expected %+v got %+v", want, s)
+	}
+}
+
+func TestStatsZero(t *testing.T) {
+	if s := NewGenerator().Stats(); !s.LastGenerated.IsZero() || s.Ahead != 0 {
+		t.Errorf("Expected zero stats got %+v", s)
+	}
+}
@@ -0,0 +1,31 @@
+package serial
+
+import "context"
+
+// Stream returns a channel which receives freshly generated serial values,
+// in ascending order, until ctx is cancelled, when the channel is closed.
+// Values are generated by a goroutine which keeps up to buffer values ready
+// in the channel, so pipelines can fan them out to workers without calling
+// Generate themselves. Because buffered values are generated ahead of time,
+// their embedded times can be earlier than the time they are received.
+//
+// If the generator can't generate a value, as when the clock has moved
+// backwards under the RollbackError policy, the channel is closed early.
+func (g *Generator) Stream(ctx context.Context, buffer int) <-chan Serial {
+	ch := make(chan Serial, buffer)
+	go func() {
+		defer close(ch)
+		for {
+			x, err := g.GenerateCtx(ctx)
+			if err != nil {
+				return
+			}
+			select {
+			case ch <- x:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
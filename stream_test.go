@@ -0,0 +1,44 @@
+package serial
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g := NewGenerator()
+	ch := g.Stream(ctx, 10)
+	var last Serial
+	for i := 0; i < 100; i++ {
+		x := <-ch
+		if x <= last {
+			t.Fatalf("Values out of order, %d after %d", x, last)
+		}
+		last = x
+	}
+	cancel()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Channel not closed after cancel")
+		}
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	clk := &fakeClock{now: time.Unix(1000, 0)}
+	g := NewGenerator(WithClock(clk), WithRollbackPolicy(RollbackError))
+	g.Generate()
+	clk.Advance(-time.Second)
+	ch := g.Stream(context.Background(), 0)
+	if _, ok := <-ch; ok {
+		t.Error("Expected channel to be closed on error")
+	}
+}
@@ -0,0 +1,120 @@
+package serial
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// ErrStripes is returned when asked to create a Striped generator with a
+// number of stripe bits out of range, with options which give it a layout
+// with node IDs, or with a checkpoint, journal or mapped state, which the
+// stripes would have to share.
+var ErrStripes = errors.New("serial: invalid stripes")
+
+// Striped issues serial values from a number of independent generators, or
+// stripes, so that callers on different CPUs rarely touch the same state,
+// for applications which need to generate more values than a single
+// Generator can. Each stripe embeds its own stripe ID in the low bits of its
+// values, in the same way as a node ID, so values from different stripes
+// never collide.
+//
+// Each call picks a stripe at random, using the runtime's per-thread random
+// number generator. Values from each stripe are in ascending order, but
+// values from different stripes are only ordered to within the time it
+// takes to generate a value, so a value can be less than one which was
+// returned before it.
+type Striped struct {
+	stripes []*Generator
+	mask    uint32
+}
+
+// NewStriped creates a Striped generator with 2^bits stripes, from 1 to 8
+// bits, each configured by the options provided. The stripe ID takes the
+// place of bits in the layout's sequence number if there are enough of them;
+// otherwise it takes the lowest bits of the timestamp, whose resolution is
+// coarsened to match, so values from the default layout are still close to
+// Unix times in nanoseconds. The options mustn't give the generators a
+// layout with node IDs, or a checkpoint, journal or mapped state, since each
+// stripe needs its own; a Striped generator's values can't be persisted.
+func NewStriped(bits uint, opts ...Option) (*Striped, error) {
+	if bits < 1 || bits > 8 {
+		return nil, ErrStripes
+	}
+	// Find the layout by applying the options to a bare generator, rather
+	// than calling New, which would start goroutines and load state.
+	g := &Generator{layout: NanosecondLayout, clock: systemClock{}}
+	for _, opt := range opts {
+		if err := opt(g); err != nil {
+			return nil, err
+		}
+	}
+	if g.checkpoint != nil || g.journal != nil || g.mapped != nil {
+		return nil, ErrStripes
+	}
+	l := g.layout
+	if err := l.check(); err != nil {
+		return nil, err
+	}
+	if l.NodeBits > 0 {
+		return nil, ErrStripes
+	}
+	l.NodeBits = bits
+	if l.SequenceBits >= bits {
+		l.SequenceBits -= bits
+	} else if l.TimeBits > bits {
+		l.TimeBits -= bits
+		l.Resolution <<= bits
+	} else {
+		return nil, ErrStripes
+	}
+	s := &Striped{stripes: make([]*Generator, 1<<bits), mask: 1<<bits - 1}
+	for i := range s.stripes {
+		sopts := append(append([]Option(nil), opts...), WithLayout(l), WithNode(int64(i)))
+		sg, err := New(sopts...)
+		if err != nil {
+			for _, sg := range s.stripes[:i] {
+				sg.Close()
+			}
+			return nil, err
+		}
+		s.stripes[i] = sg
+	}
+	return s, nil
+}
+
+// Generate returns a serial value from one of the stripes. It panics in the
+// same circumstances as Generator.Generate, including once the stripes have
+// been closed; use TryGenerate to handle errors.
+func (s *Striped) Generate() Serial {
+	return s.stripes[rand.Uint32()&s.mask].Generate()
+}
+
+// TryGenerate is like Generate, but returns an error rather than panicking
+// if a value can't be generated.
+func (s *Striped) TryGenerate() (Serial, error) {
+	return s.stripes[rand.Uint32()&s.mask].TryGenerate()
+}
+
+// Time returns the time embedded in a serial value from the generator.
+func (s *Striped) Time(x Serial) time.Time {
+	return s.stripes[0].Time(x)
+}
+
+// Stripes returns the generators for the individual stripes, for inspecting
+// their statistics.
+func (s *Striped) Stripes() []*Generator {
+	return append([]*Generator(nil), s.stripes...)
+}
+
+// Close closes the generators for all the stripes, returning the errors from
+// closing them joined with errors.Join.
+func (s *Striped) Close() error {
+	errs := make([]error, len(s.stripes))
+	for i, g := range s.stripes {
+		errs[i] = g.Close()
+	}
+	return errors.Join(errs...)
+}
+
+var _ Source = (*Striped)(nil)
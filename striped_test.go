@@ -0,0 +1,108 @@
+package serial
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStriped(t *testing.T) {
+	for _, bits := range []uint{0, 9} {
+		if _, err := NewStriped(bits); err != ErrStripes {
+			t.Errorf("Expected ErrStripes for %d bits, got %v", bits, err)
+		}
+	}
+	if _, err := NewStriped(4, WithLayout(SnowflakeLayout)); err != ErrStripes {
+		t.Errorf("Expected ErrStripes with node IDs, got %v", err)
+	}
+	cp := FileCheckpoint(filepath.Join(t.TempDir(), "serial.checkpoint"))
+	if _, err := NewStriped(4, WithCheckpoint(cp, time.Second)); err != ErrStripes {
+		t.Errorf("Expected ErrStripes with a checkpoint, got %v", err)
+	}
+	if _, err := os.Stat(string(cp)); !os.IsNotExist(err) {
+		t.Errorf("Expected checkpoint file untouched, got %v", err)
+	}
+
+	// Stripes already created are closed if a later one fails.
+	var gens []*Generator
+	fail := errors.New("fail")
+	_, err := NewStriped(2, WithAutoExpire(time.Hour, time.Hour), func(g *Generator) error {
+		gens = append(gens, g)
+		if len(gens) == 4 {
+			return fail
+		}
+		return nil
+	})
+	if err != fail {
+		t.Fatalf("Expected error from third stripe, got %v", err)
+	}
+	for i, g := range gens[1:3] {
+		if !g.closed.Load() {
+			t.Errorf("Expected stripe %d closed", i)
+		}
+	}
+
+	s, err := NewStriped(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	var (
+		mutex sync.Mutex
+		seen  = make(map[Serial]bool)
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			xs := make([]Serial, 1000)
+			for i := range xs {
+				xs[i] = s.Generate()
+			}
+			mutex.Lock()
+			for _, x := range xs {
+				if seen[x] {
+					t.Errorf("Got the same value twice: %d", x)
+				}
+				seen[x] = true
+			}
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+	if d := time.Since(s.Time(s.Generate())); d < -time.Second || d > time.Second {
+		t.Errorf("Embedded timestamp is %v away from now", d)
+	}
+	if d := time.Since(s.Generate().Time()); d < -time.Second || d > time.Second {
+		t.Errorf("Value isn't close to Unix nanoseconds, %v away", d)
+	}
+	used := 0
+	for _, g := range s.Stripes() {
+		if g.Stats().Generated > 0 {
+			used++
+		}
+	}
+	if used < 8 {
+		t.Errorf("Expected values from most stripes, got %d", used)
+	}
+	m, err := NewStriped(3, WithResolution(time.Millisecond, 12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := m.stripes[0].layout; l.NodeBits != 3 || l.SequenceBits != 9 || l.Resolution != time.Millisecond {
+		t.Errorf("Expected stripe ID to come from sequence bits, got %+v", l)
+	}
+}
+
+func BenchmarkStripedParallel(b *testing.B) {
+	s, _ := NewStriped(6)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.Generate()
+		}
+	})
+}
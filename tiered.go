@@ -0,0 +1,257 @@
+package serial
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/RoaringBitmap/roaring"
+)
+
+// ErrCompaction is returned when asked to create a generator which compacts
+// its seen history after an age which isn't positive, or together with an
+// option which replaces the default history, or in counter mode.
+var ErrCompaction = errors.New("serial: invalid seen history compaction")
+
+// coldBucketBits is the number of low bits of a serial value held in a
+// compressed bitmap; the remaining high bits select the bitmap, so each
+// bitmap covers a span of time, about 4.3 seconds with the default layout.
+const coldBucketBits = 32
+
+// coldBucketBytes is the approximate overhead of each bitmap, besides its
+// contents.
+const coldBucketBytes = 64
+
+// tieredStore is a seenStore which keeps recent entries in a mapStore, and
+// compacts older ones into compressed bitmaps, one per time bucket. Only
+// entries which expire by age and have no attached value are compacted,
+// since the bitmaps record nothing but membership.
+type tieredStore struct {
+	hot *mapStore
+	// mutex guards cold and coldLen. Adding takes a read lock, so that a
+	// value can't be compacted between checking the cold tier and adding it
+	// to the hot one; compacting and removing take the write lock.
+	mutex   sync.RWMutex
+	cold    map[int64]*roaring.Bitmap
+	coldLen int
+	lagw    lagWindow
+	// cutoff returns the value below which entries are compacted.
+	cutoff func(now int64) Serial
+}
+
+// WithCompaction keeps the seen history in two tiers, to cut the memory used
+// by very large, long-lived histories. Entries flagged more than age ago,
+// according to the time embedded in their values, are moved from the default
+// sharded history into compressed bitmaps, which typically need a few bytes
+// per entry rather than about a hundred. Membership checks stay exact.
+//
+// Compaction happens when the history is expired, by ExpireSeen or
+// WithAutoExpire, which must therefore be called regularly. Entries with a
+// time to live or an attached value stay in the first tier until they
+// expire. The option can't be combined with WithMaxSeen, WithBloomFilter,
+// WithSeenStore or WithCounter.
+func WithCompaction(age time.Duration) Option {
+	return func(g *Generator) error {
+		if age <= 0 {
+			return ErrCompaction
+		}
+		g.compactAfter = age
+		return nil
+	}
+}
+
+// newTieredStore returns a tieredStore which compacts entries whose values
+// are more than age old in the layout.
+func newTieredStore(l Layout, age time.Duration) *tieredStore {
+	return &tieredStore{
+		hot:  newMapStore(),
+		cold: make(map[int64]*roaring.Bitmap),
+		cutoff: func(now int64) Serial {
+			return l.pack(l.ticks(time.Unix(0, now).Add(-age)), 0, 0)
+		},
+	}
+}
+
+// coldHas reports whether x is in the cold tier. The caller must hold the
+// lock.
+func (st *tieredStore) coldHas(x Serial) bool {
+	b := st.cold[int64(x)>>coldBucketBits]
+	return b != nil && b.Contains(uint32(x))
+}
+
+func (st *tieredStore) add(x Serial, e seenEntry, now int64) bool {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+	if st.coldHas(x) {
+		return false
+	}
+	return st.hot.add(x, e, now)
+}
+
+func (st *tieredStore) has(x Serial) (seenEntry, bool) {
+	// Compaction adds to the cold tier before removing from the hot one, so
+	// checking in this order can't miss a value being moved.
+	if e, ok := st.hot.has(x); ok {
+		return e, true
+	}
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+	return seenEntry{}, st.coldHas(x)
+}
+
+func (st *tieredStore) remove(x Serial, now int64) bool {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	if st.hot.remove(x, now) {
+		return true
+	}
+	key := int64(x) >> coldBucketBits
+	b := st.cold[key]
+	if b == nil || !b.CheckedRemove(uint32(x)) {
+		return false
+	}
+	if b.IsEmpty() {
+		delete(st.cold, key)
+	}
+	st.coldLen--
+	st.lagw.record(now, -1)
+	return true
+}
+
+func (st *tieredStore) each(f func(x Serial, e seenEntry) bool) {
+	ok := true
+	st.hot.each(func(x Serial, e seenEntry) bool {
+		ok = f(x, e)
+		return ok
+	})
+	if !ok {
+		return
+	}
+	for _, x := range st.coldValues(func(int64) bool { return true }) {
+		if !f(x, seenEntry{}) {
+			return
+		}
+	}
+}
+
+// coldValues returns the values in the cold tier's buckets for which keep
+// returns true, in order.
+func (st *tieredStore) coldValues(keep func(key int64) bool) []Serial {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+	keys := make([]int64, 0, len(st.cold))
+	for key := range st.cold {
+		if keep(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	var xs []Serial
+	for _, key := range keys {
+		it := st.cold[key].Iterator()
+		for it.HasNext() {
+			xs = append(xs, Serial(key<<coldBucketBits|int64(it.Next())))
+		}
+	}
+	return xs
+}
+
+func (st *tieredStore) expire(limit Serial, now int64, removed *[]Serial) int {
+	n := st.hot.expire(limit, now, removed)
+	n += st.expireCold(limit, now, removed)
+	st.compact(now)
+	return n
+}
+
+// expireCold removes values less than limit from the cold tier.
+func (st *tieredStore) expireCold(limit Serial, now int64, removed *[]Serial) int {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	n := 0
+	top := int64(limit) >> coldBucketBits
+	for key, b := range st.cold {
+		if key > top {
+			continue
+		}
+		if key == top {
+			// Only part of the bucket is old enough.
+			old := roaring.New()
+			old.AddRange(0, uint64(uint32(limit)))
+			old.And(b)
+			if old.IsEmpty() {
+				continue
+			}
+			b.AndNot(old)
+			if b.IsEmpty() {
+				delete(st.cold, key)
+			}
+			b = old
+		} else {
+			delete(st.cold, key)
+		}
+		n += int(b.GetCardinality())
+		if removed != nil {
+			it := b.Iterator()
+			for it.HasNext() {
+				*removed = append(*removed, Serial(key<<coldBucketBits|int64(it.Next())))
+			}
+		}
+	}
+	st.coldLen -= n
+	st.lagw.record(now, -int64(n))
+	return n
+}
+
+// compact moves entries older than the cutoff which expire by age and have
+// no attached value from the hot tier to the cold one. It finds them through
+// the hot tier's expiry indexes, so only visits entries old enough to move.
+func (st *tieredStore) compact(now int64) {
+	cutoff := st.cutoff(now)
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	touched := make(map[int64]*roaring.Bitmap)
+	// Each value is added to the cold tier before it is removed from the
+	// hot one, so has can't miss it.
+	moved := st.hot.extract(cutoff, now, func(x Serial) {
+		key := int64(x) >> coldBucketBits
+		b := st.cold[key]
+		if b == nil {
+			b = roaring.New()
+			st.cold[key] = b
+		}
+		b.Add(uint32(x))
+		touched[key] = b
+	})
+	if moved == 0 {
+		return
+	}
+	for _, b := range touched {
+		b.RunOptimize()
+	}
+	st.coldLen += moved
+	// The hot tier counted the moves as removals.
+	st.lagw.record(now, int64(moved))
+}
+
+func (st *tieredStore) lag(now int64) int64 {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+	return st.hot.lag(now) + st.lagw.sum(now)
+}
+
+func (st *tieredStore) len() int {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+	return st.hot.len() + st.coldLen
+}
+
+func (st *tieredStore) memory() int64 {
+	st.mutex.RLock()
+	defer st.mutex.RUnlock()
+	n := st.hot.memory()
+	for _, b := range st.cold {
+		n += int64(b.GetSizeInBytes()) + coldBucketBytes
+	}
+	return n
+}
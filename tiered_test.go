@@ -0,0 +1,117 @@
+package serial
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompaction(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithCompaction(0)},
+		{WithCompaction(time.Minute), WithMaxSeen(10)},
+		{WithCompaction(time.Minute), WithCounter(0)},
+	} {
+		if _, err := New(opts...); err != ErrCompaction {
+			t.Errorf("Expected ErrCompaction got %v", err)
+		}
+	}
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	g := NewGenerator(WithClock(clock), WithCompaction(time.Minute))
+	var expired int
+	g.OnExpire(func(Serial) { expired++ })
+	xs := make([]Serial, 10000)
+	for i := range xs {
+		xs[i] = g.Generate()
+		g.SetSeen(xs[i])
+		clock.Advance(time.Microsecond)
+	}
+	ttl := g.Generate()
+	g.SetSeenFor(ttl, time.Hour)
+	withValue := g.Generate()
+	g.SetSeenWithValue(withValue, "alice")
+	before := g.EstimatedMemory()
+
+	clock.Advance(2 * time.Minute)
+	if removed, remaining := g.ExpireSeen(time.Hour); removed != 0 || remaining != len(xs)+2 {
+		t.Errorf("Expected 0 removed and %d remaining, got %d and %d", len(xs)+2, removed, remaining)
+	}
+	if after := g.EstimatedMemory(); after*10 > before {
+		t.Errorf("Expected compaction to cut memory tenfold, from %d to %d", before, after)
+	}
+	for _, x := range xs {
+		if !g.Seen(x) {
+			t.Fatalf("Expected %d to be seen after compaction", x)
+		}
+	}
+	if v, ok := g.SeenValue(withValue); !ok || v != "alice" {
+		t.Errorf("Expected alice and 'seen' got %v and %v", v, ok)
+	}
+	if !g.SeenOrSet(xs[0]) {
+		t.Errorf("Expected compacted %d to be reported as seen", xs[0])
+	}
+	if !g.UnsetSeen(xs[1]) || g.Seen(xs[1]) {
+		t.Errorf("Expected compacted %d to be unset", xs[1])
+	}
+	if n := g.SeenCount(); n != len(xs)+1 {
+		t.Errorf("Expected %d seen got %d", len(xs)+1, n)
+	}
+	n := 0
+	g.store.each(func(Serial, seenEntry) bool {
+		n++
+		return true
+	})
+	if n != len(xs)+1 {
+		t.Errorf("Expected %d entries got %d", len(xs)+1, n)
+	}
+
+	// Expire by age part way through the compacted values.
+	limit := xs[len(xs)/2]
+	if removed := g.store.expire(limit, clock.now.UnixNano(), nil); removed != len(xs)/2-1 {
+		t.Errorf("Expected %d removed got %d", len(xs)/2-1, removed)
+	}
+	if g.Seen(xs[len(xs)/2-1]) || !g.Seen(limit) {
+		t.Errorf("Expected values below %d to be expired", limit)
+	}
+	g.ExpireSeen(time.Second)
+	if n := g.SeenCount(); n != 1 || !g.Seen(ttl) {
+		t.Errorf("Expected only the entry with a TTL left, got %d", n)
+	}
+	// The values expired directly from the store aren't reported.
+	if want := len(xs)/2 + 1; expired != want {
+		t.Errorf("Expected OnExpire called %d times got %d", want, expired)
+	}
+}
+
+func TestCompactionIndex(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	g := NewGenerator(WithClock(clock), WithCompaction(time.Minute))
+	for i := 0; i < 1000; i++ {
+		g.SetSeen(g.Generate())
+	}
+	clock.Advance(2 * time.Minute)
+	young := make([]Serial, 10)
+	for i := range young {
+		young[i] = g.Generate()
+		g.SetSeen(young[i])
+	}
+	g.ExpireSeen(time.Hour)
+	st := g.store.(*tieredStore)
+	if n := st.hot.len(); n != len(young) {
+		t.Errorf("Expected %d entries in the hot tier got %d", len(young), n)
+	}
+	// Compacted values leave the expiry index, so later passes skip them.
+	indexed := 0
+	for i := range st.hot.shards {
+		for _, b := range st.hot.shards[i].byKey.buckets {
+			indexed += len(b)
+		}
+	}
+	if indexed != len(young) {
+		t.Errorf("Expected %d indexed entries got %d", len(young), indexed)
+	}
+	for _, x := range young {
+		if !g.Seen(x) {
+			t.Errorf("Expected %d to be seen", x)
+		}
+	}
+}
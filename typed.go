@@ -0,0 +1,88 @@
+package serial
+
+import "context"
+
+// ID is a serial value belonging to the domain identified by the type
+// parameter T, which is usually an empty struct type declared just for the
+// purpose:
+//
+//	type order struct{}
+//	type OrderID = serial.ID[order]
+//
+// IDs from different domains are different types, so the compiler rejects
+// an attempt to pass a user's ID to a function expecting an order's. An ID
+// has all the methods of Serial, including those for encoding it as text,
+// JSON and SQL, and encodes the same way. The underlying value is available
+// as the Serial field.
+type ID[T any] struct {
+	Serial
+}
+
+// Typed generates IDs for one domain. Any number of Typed values for
+// different domains can share one underlying Generator, so the values they
+// issue are unique across all of them, but each can only issue and look up
+// IDs of its own type.
+type Typed[T any] struct {
+	gen *Generator
+}
+
+// NewTyped returns a Typed which generates IDs for the domain T from gen.
+func NewTyped[T any](gen *Generator) *Typed[T] {
+	return &Typed[T]{gen: gen}
+}
+
+// Generator returns the underlying generator.
+func (t *Typed[T]) Generator() *Generator {
+	return t.gen
+}
+
+// Generate generates a new ID, as for Generator.Generate, and panics in the
+// same circumstances, including once the generator has been closed; use
+// TryGenerate to handle errors.
+func (t *Typed[T]) Generate() ID[T] {
+	return ID[T]{t.gen.Generate()}
+}
+
+// TryGenerate is like Generate, but returns an error rather than panicking
+// if an ID can't be generated.
+func (t *Typed[T]) TryGenerate() (ID[T], error) {
+	x, err := t.gen.TryGenerate()
+	return ID[T]{x}, err
+}
+
+// GenerateCtx is like TryGenerate, but gives up if the context is done while
+// the generator is waiting, as for Generator.GenerateCtx.
+func (t *Typed[T]) GenerateCtx(ctx context.Context) (ID[T], error) {
+	x, err := t.gen.GenerateCtx(ctx)
+	return ID[T]{x}, err
+}
+
+// Parse parses an ID in any of the formats accepted by the package-level
+// Parse.
+func (t *Typed[T]) Parse(s string) (ID[T], error) {
+	x, err := Parse(s)
+	return ID[T]{x}, err
+}
+
+// Seen reports whether the ID has been flagged as seen.
+func (t *Typed[T]) Seen(id ID[T]) bool {
+	return t.gen.Seen(id.Serial)
+}
+
+// SetSeen flags the ID as seen. Like Generator.SetSeen, it panics if the
+// generator's seen quota has been reached.
+func (t *Typed[T]) SetSeen(id ID[T]) {
+	t.gen.SetSeen(id.Serial)
+}
+
+// SeenOrSet reports whether the ID has been flagged as seen, and flags it if
+// it hasn't, as one atomic operation.
+func (t *Typed[T]) SeenOrSet(id ID[T]) bool {
+	return t.gen.SeenOrSet(id.Serial)
+}
+
+// UnsetSeen removes the ID from the seen history, and reports whether it was
+// there.
+func (t *Typed[T]) UnsetSeen(id ID[T]) bool {
+	return t.gen.UnsetSeen(id.Serial)
+}
@@ -0,0 +1,45 @@
+package serial
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type testOrder struct{}
+type testUser struct{}
+
+func TestTyped(t *testing.T) {
+	g := NewGenerator()
+	orders := NewTyped[testOrder](g)
+	users := NewTyped[testUser](g)
+	o := orders.Generate()
+	u := users.Generate()
+	if u.Serial <= o.Serial {
+		t.Errorf("Expected IDs from a shared generator in order, got %d then %d", o.Serial, u.Serial)
+	}
+	if orders.SeenOrSet(o) || !orders.Seen(o) {
+		t.Errorf("Expected %v to be flagged once", o)
+	}
+	if !orders.UnsetSeen(o) || orders.Seen(o) {
+		t.Errorf("Expected %v to be unset", o)
+	}
+	b, err := json.Marshal(struct{ Order ID[testOrder] }{o})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := json.Marshal(struct{ Order Serial }{o.Serial})
+	if string(b) != string(want) {
+		t.Errorf("Expected %s got %s", want, b)
+	}
+	var v struct{ Order ID[testOrder] }
+	if err := json.Unmarshal(b, &v); err != nil || v.Order != o {
+		t.Errorf("Expected %v got %v %v", o, v.Order, err)
+	}
+	p, err := orders.Parse(o.Base62())
+	if err != nil || p != o {
+		t.Errorf("Expected %v got %v %v", o, p, err)
+	}
+	if o.String() != o.Serial.String() {
+		t.Errorf("Expected %s got %s", o.Serial.String(), o.String())
+	}
+}
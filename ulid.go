@@ -0,0 +1,92 @@
+package serial
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ULID is a 128 bit Universally Unique Lexicographically Sortable Identifier,
+// as described at <https://github.com/ulid/spec>. Its string form is 26
+// characters of Crockford's Base32, and sorts in the same order as the
+// underlying bytes.
+type ULID [16]byte
+
+// ErrInvalidULID is returned when parsing a string which isn't a valid ULID.
+var ErrInvalidULID = errors.New("serial: invalid ULID")
+
+// GenerateULID generates a new serial value and returns it as a ULID. The
+// first 48 bits hold the serial's embedded timestamp in Unix milliseconds, and
+// the last 64 bits hold the serial itself, in place of the random component of
+// a standard ULID. ULIDs from a generator therefore have the same uniqueness
+// and ordering guarantees as the values returned by Generate, and the serial
+// recovered with the Serial method can be used with SetSeen and Seen. It
+// panics in the same circumstances as Generate, including once the generator
+// has been closed; to handle errors, pass a value from TryGenerate to ULID.
+func (g *Generator) GenerateULID() ULID {
+	return g.ULID(g.Generate())
+}
+
+// ULID converts a serial value from the generator to a ULID, in the same form
+// as returned by GenerateULID.
+func (g *Generator) ULID(x Serial) ULID {
+	var u ULID
+	ms := uint64(g.layout.timeOf(x).UnixMilli())
+	binary.BigEndian.PutUint16(u[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(u[2:6], uint32(ms))
+	binary.BigEndian.PutUint64(u[8:16], uint64(x))
+	return u
+}
+
+// Serial returns the serial value embedded in a ULID from GenerateULID.
+func (u ULID) Serial() Serial {
+	return Serial(binary.BigEndian.Uint64(u[8:16]))
+}
+
+// String returns the canonical 26 character form of the ULID.
+func (u ULID) String() string {
+	b, _ := u.MarshalText()
+	return string(b)
+}
+
+// MarshalText implements encoding.TextMarshaler, returning the canonical form
+// of the ULID.
+func (u ULID) MarshalText() ([]byte, error) {
+	b := make([]byte, 26)
+	// 26 characters of 5 bits is 130 bits, so the first character only
+	// carries the top 3 bits.
+	hi := binary.BigEndian.Uint64(u[0:8])
+	lo := binary.BigEndian.Uint64(u[8:16])
+	for i := 25; i >= 0; i-- {
+		b[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return b, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting the form
+// returned by MarshalText in either upper or lower case.
+func (u *ULID) UnmarshalText(b []byte) error {
+	if len(b) != 26 {
+		return ErrInvalidULID
+	}
+	var hi, lo uint64
+	for i, c := range b {
+		v := crockfordValue(c)
+		if v < 0 || i == 0 && v > 7 {
+			return ErrInvalidULID
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	binary.BigEndian.PutUint64(u[0:8], hi)
+	binary.BigEndian.PutUint64(u[8:16], lo)
+	return nil
+}
+
+// ParseULID parses the string form of a ULID.
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	err := u.UnmarshalText([]byte(s))
+	return u, err
+}
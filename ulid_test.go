@@ -0,0 +1,55 @@
+package serial
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestULID(t *testing.T) {
+	g := NewGenerator()
+	var prev string
+	for i := 0; i < 1000; i++ {
+		u := g.GenerateULID()
+		s := u.String()
+		if len(s) != 26 {
+			t.Fatalf("Wrong length ULID %q", s)
+		}
+		if s <= prev {
+			t.Fatalf("ULIDs out of order: %q then %q", prev, s)
+		}
+		prev = s
+		p, err := ParseULID(strings.ToLower(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != u {
+			t.Fatalf("Round trip failed, expected %v got %v", u, p)
+		}
+	}
+	u := g.GenerateULID()
+	g.SetSeen(u.Serial())
+	if !g.Seen(u.Serial()) {
+		t.Error("Flagged ULID as seen, got 'not seen'")
+	}
+	x := u.Serial()
+	if ms := time.Unix(0, int64(x)).UnixMilli(); int64(u[0])<<40|int64(u[1])<<32|int64(u[2])<<24|int64(u[3])<<16|int64(u[4])<<8|int64(u[5]) != ms {
+		t.Errorf("Wrong timestamp in ULID %v", u)
+	}
+}
+
+func TestParseULIDErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"01ARZ3NDEKTSV4RRFFQ69G5FA",
+		"81ARZ3NDEKTSV4RRFFQ69G5FAV",
+		"01ARZ3NDEKTSV4RRFFQ69G5FAU",
+	} {
+		if _, err := ParseULID(s); err != ErrInvalidULID {
+			t.Errorf("Expected ErrInvalidULID for %q, got %v", s, err)
+		}
+	}
+	if _, err := ParseULID("01ARZ3NDEKTSV4RRFFQ69G5FAV"); err != nil {
+		t.Errorf("Failed to parse valid ULID: %v", err)
+	}
+}
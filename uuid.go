@@ -0,0 +1,150 @@
+package serial
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+)
+
+// UUID is a 128 bit universally unique identifier, as described in RFC 9562.
+type UUID [16]byte
+
+// ErrInvalidUUID is returned when parsing a string which isn't a valid
+// version 7 or version 8 UUID, or converting a UUID which doesn't hold a
+// serial value.
+var ErrInvalidUUID = errors.New("serial: invalid UUID")
+
+// GenerateUUIDv7 generates a new serial value and returns it as a version 7
+// (time-ordered) UUID. The first 48 bits hold the serial's embedded timestamp
+// in Unix milliseconds, as the standard requires, and the 74 bits which would
+// normally be random hold the serial itself. UUIDs from a generator therefore
+// have the same uniqueness and ordering guarantees as the values returned by
+// Generate, and the serial recovered with the Serial method can be used with
+// SetSeen, Seen and ExpireSeen just like any other. It panics in the same
+// circumstances as Generate, including once the generator has been closed;
+// to handle errors, pass a value from TryGenerate to UUIDv7.
+func (g *Generator) GenerateUUIDv7() UUID {
+	return g.UUIDv7(g.Generate())
+}
+
+// UUIDv7 converts a serial value from the generator to a UUID, in the same
+// form as returned by GenerateUUIDv7.
+func (g *Generator) UUIDv7(x Serial) UUID {
+	var u UUID
+	ms := uint64(g.layout.timeOf(x).UnixMilli())
+	binary.BigEndian.PutUint16(u[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(u[2:6], uint32(ms))
+	// rand_a gets the top bit of the serial, rand_b the remaining 62.
+	binary.BigEndian.PutUint16(u[6:8], 0x7000|uint16(uint64(x)>>62))
+	binary.BigEndian.PutUint64(u[8:16], 0x8000000000000000|uint64(x)&(1<<62-1))
+	return u
+}
+
+// ToUUID embeds a serial value in a version 8 (custom) UUID, for systems
+// whose schema requires UUID columns. The 64 bits of the serial are stored in
+// order, most significant first, skipping the 4 version bits, which are set
+// to 1000, and the 2 variant bits, which are set to 10 as RFC 9562 requires.
+// The last 58 bits are zero. UUIDs from non-negative serials therefore sort
+// in the same order as the serials, and the serial can be recovered with
+// FromUUID.
+//
+// Unlike the UUIDs from GenerateUUIDv7, these don't need a generator, but
+// other systems won't find a timestamp in them.
+func ToUUID(x Serial) UUID {
+	var u UUID
+	n := uint64(x)
+	binary.BigEndian.PutUint32(u[0:4], uint32(n>>32))
+	binary.BigEndian.PutUint16(u[4:6], uint16(n>>16))
+	binary.BigEndian.PutUint16(u[6:8], 0x8000|uint16(n>>4)&0x0fff)
+	u[8] = 0x80 | byte(n&0xf)<<2
+	return u
+}
+
+// FromUUID recovers the serial value from a UUID returned by ToUUID, or by a
+// generator's GenerateUUIDv7 or UUIDv7. It returns ErrInvalidUUID if the
+// UUID has the wrong version or variant, or a version 8 UUID has bits set
+// which ToUUID leaves zero.
+func FromUUID(u UUID) (Serial, error) {
+	if u[8]>>6 != 2 {
+		return 0, ErrInvalidUUID
+	}
+	switch u[6] >> 4 {
+	case 7:
+	case 8:
+		if u[8]&3 != 0 || binary.BigEndian.Uint64(u[8:16])&(1<<56-1) != 0 {
+			return 0, ErrInvalidUUID
+		}
+	default:
+		return 0, ErrInvalidUUID
+	}
+	return u.Serial(), nil
+}
+
+// Serial returns the serial value embedded in a UUID from GenerateUUIDv7 or
+// ToUUID. Use FromUUID to check that the UUID holds a serial value.
+func (u UUID) Serial() Serial {
+	if u[6]>>4 == 8 {
+		n := uint64(binary.BigEndian.Uint32(u[0:4]))<<32 |
+			uint64(binary.BigEndian.Uint16(u[4:6]))<<16 |
+			uint64(binary.BigEndian.Uint16(u[6:8])&0x0fff)<<4 |
+			uint64(u[8]>>2&0xf)
+		return Serial(n)
+	}
+	a := uint64(binary.BigEndian.Uint16(u[6:8]) & 0x0fff)
+	b := binary.BigEndian.Uint64(u[8:16]) & (1<<62 - 1)
+	return Serial(a<<62 | b)
+}
+
+// String returns the canonical form of the UUID, as 32 lower case hex digits
+// grouped by hyphens.
+func (u UUID) String() string {
+	b, _ := u.MarshalText()
+	return string(b)
+}
+
+// MarshalText implements encoding.TextMarshaler, returning the canonical form
+// of the UUID.
+func (u UUID) MarshalText() ([]byte, error) {
+	b := make([]byte, 36)
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:36], u[10:16])
+	return b, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting the canonical
+// form of a version 7 or version 8 UUID in either upper or lower case.
+func (u *UUID) UnmarshalText(b []byte) error {
+	if len(b) != 36 || b[8] != '-' || b[13] != '-' || b[18] != '-' || b[23] != '-' {
+		return ErrInvalidUUID
+	}
+	digits := make([]byte, 0, 32)
+	digits = append(digits, b[0:8]...)
+	digits = append(digits, b[9:13]...)
+	digits = append(digits, b[14:18]...)
+	digits = append(digits, b[19:23]...)
+	digits = append(digits, b[24:36]...)
+	var v UUID
+	if _, err := hex.Decode(v[:], digits); err != nil {
+		return ErrInvalidUUID
+	}
+	if ver := v[6] >> 4; ver != 7 && ver != 8 || v[8]>>6 != 2 {
+		return ErrInvalidUUID
+	}
+	*u = v
+	return nil
+}
+
+// ParseUUID parses the canonical string form of a version 7 or version 8
+// UUID.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	err := u.UnmarshalText([]byte(s))
+	return u, err
+}
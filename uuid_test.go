@@ -0,0 +1,111 @@
+package serial
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUUIDv7(t *testing.T) {
+	g := NewGenerator()
+	var prev string
+	for i := 0; i < 1000; i++ {
+		u := g.GenerateUUIDv7()
+		s := u.String()
+		if s <= prev {
+			t.Fatalf("UUIDs out of order: %q then %q", prev, s)
+		}
+		prev = s
+		if s[14] != '7' || !strings.ContainsRune("89ab", rune(s[19])) {
+			t.Fatalf("Wrong version or variant in %q", s)
+		}
+		p, err := ParseUUID(strings.ToUpper(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != u {
+			t.Fatalf("Round trip failed, expected %v got %v", u, p)
+		}
+	}
+	for _, x := range []Serial{0, 1, 1<<62 + 12345, 1<<63 - 1} {
+		if y := g.UUIDv7(x).Serial(); y != x {
+			t.Errorf("Serial round trip failed, expected %d got %d", x, y)
+		}
+	}
+	u := g.GenerateUUIDv7()
+	g.SetSeen(u.Serial())
+	if !g.Seen(u.Serial()) {
+		t.Error("Flagged UUID as seen, got 'not seen'")
+	}
+	g.ExpireSeen(time.Duration(0))
+	if g.Seen(u.Serial()) {
+		t.Error("Emptied history but UUID was still 'seen'")
+	}
+}
+
+func TestParseUUIDErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"017f22e2-79b0-7cc3-98c4-dc0c0c07398",
+		"017f22e2-79b0-4cc3-98c4-dc0c0c07398f",
+		"017f22e2-79b0-7cc3-18c4-dc0c0c07398f",
+		"017f22e2x79b0-7cc3-98c4-dc0c0c07398f",
+		"017f22e2-79b0-7cc3-98c4-dc0c0c07398g",
+	} {
+		if _, err := ParseUUID(s); err != ErrInvalidUUID {
+			t.Errorf("Expected ErrInvalidUUID for %q, got %v", s, err)
+		}
+	}
+	if _, err := ParseUUID("017f22e2-79b0-7cc3-98c4-dc0c0c07398f"); err != nil {
+		t.Errorf("Failed to parse valid UUID: %v", err)
+	}
+}
+
+func TestToUUID(t *testing.T) {
+	var prev UUID
+	for _, x := range []Serial{0, 1, 15, 16, 1<<62 + 12345, 1<<63 - 1, -1} {
+		u := ToUUID(x)
+		s := u.String()
+		if s[14] != '8' || !strings.ContainsRune("89ab", rune(s[19])) {
+			t.Errorf("Wrong version or variant in %q", s)
+		}
+		if x >= 0 && s <= prev.String() {
+			t.Errorf("UUIDs out of order: %v then %q", prev, s)
+		}
+		prev = u
+		y, err := FromUUID(u)
+		if err != nil || y != x {
+			t.Errorf("Round trip failed, expected %d got %d %v", x, y, err)
+		}
+		p, err := ParseUUID(s)
+		if err != nil || p != u {
+			t.Errorf("Expected %v got %v %v", u, p, err)
+		}
+	}
+	g := NewGenerator()
+	x := g.Generate()
+	if y, err := FromUUID(g.UUIDv7(x)); err != nil || y != x {
+		t.Errorf("Expected %d from version 7 UUID, got %d %v", x, y, err)
+	}
+	for _, s := range []string{
+		"017f22e2-79b0-4cc3-98c4-dc0c0c07398f",
+		"017f22e2-79b0-8cc3-18c4-dc0c0c07398f",
+		"017f22e2-79b0-8cc3-8001-000000000000",
+		"017f22e2-79b0-8cc3-8000-000000000001",
+	} {
+		var u UUID
+		copy(u[:], mustHex(t, strings.ReplaceAll(s, "-", "")))
+		if _, err := FromUUID(u); err != ErrInvalidUUID {
+			t.Errorf("Expected ErrInvalidUUID for %q, got %v", s, err)
+		}
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
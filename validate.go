@@ -0,0 +1,143 @@
+package serial
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNegative is the reason Validate gives for rejecting a negative
+	// value.
+	ErrNegative = errors.New("serial: negative value")
+	// ErrTooEarly is the reason Validate gives for rejecting a value whose
+	// embedded time is before the earliest allowed.
+	ErrTooEarly = errors.New("serial: value earlier than allowed")
+	// ErrLayoutMismatch is the reason Validate gives for rejecting a value
+	// with bits set above those used by its layout, or with the wrong node
+	// ID.
+	ErrLayoutMismatch = errors.New("serial: value doesn't match layout")
+)
+
+// ValidationError records why Validate rejected a value. Err is one of
+// ErrNegative, ErrTooEarly, ErrSkew or ErrLayoutMismatch, so callers can use
+// errors.Is to find out which check failed.
+type ValidationError struct {
+	Value Serial
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return "serial: invalid value " + strconv.FormatInt(int64(e.Value), 10) + ": " +
+		strings.TrimPrefix(e.Err.Error(), "serial: ")
+}
+
+// Unwrap returns the underlying error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// validation holds the checks made by Validate.
+type validation struct {
+	layout    Layout
+	match     bool
+	node      int64
+	notBefore time.Time
+	maxAhead  time.Duration
+	clock     Clock
+}
+
+// ValidateOption configures the checks made by Validate.
+type ValidateOption func(*validation)
+
+// ValidateLayout makes Validate read values with the specified layout, and
+// reject values with bits set above those the layout uses.
+func ValidateLayout(l Layout) ValidateOption {
+	return func(v *validation) {
+		v.layout = l
+		v.match = true
+	}
+}
+
+// ValidateNode makes Validate reject values which don't have the specified
+// node ID, according to the layout.
+func ValidateNode(node int64) ValidateOption {
+	return func(v *validation) {
+		v.node = node
+	}
+}
+
+// ValidateNotBefore makes Validate reject values whose embedded time is
+// before t, such as the time a service was launched.
+func ValidateNotBefore(t time.Time) ValidateOption {
+	return func(v *validation) {
+		v.notBefore = t
+	}
+}
+
+// ValidateMaxSkew makes Validate reject values whose embedded time is more
+// than max ahead of the clock.
+func ValidateMaxSkew(max time.Duration) ValidateOption {
+	return func(v *validation) {
+		v.maxAhead = max
+	}
+}
+
+// ValidateClock sets the clock Validate compares embedded times with, for
+// ValidateMaxSkew. The default is the system clock.
+func ValidateClock(c Clock) ValidateOption {
+	return func(v *validation) {
+		v.clock = c
+	}
+}
+
+// Validate checks that a value, such as one received from a client, is
+// plausible. A value is always rejected if it is negative; further checks
+// are added by the options. Values are read with the default layout, unless
+// ValidateLayout is given. If the value fails a check, the error is a
+// *ValidationError.
+//
+// Validate can only reject values which couldn't have been generated; it
+// can't tell whether a plausible value was really issued.
+func Validate(x Serial, opts ...ValidateOption) error {
+	v := validation{layout: NanosecondLayout, node: -1, maxAhead: -1, clock: systemClock{}}
+	for _, opt := range opts {
+		opt(&v)
+	}
+	return v.check(x)
+}
+
+// Validate checks that a value is plausible for the generator, as for the
+// package-level Validate: its embedded time is read with the generator's
+// layout and compared with the generator's clock, and it must match the
+// layout. The options can add further checks.
+func (g *Generator) Validate(x Serial, opts ...ValidateOption) error {
+	v := validation{layout: g.layout, match: true, node: -1, maxAhead: -1, clock: g.clock}
+	for _, opt := range opts {
+		opt(&v)
+	}
+	return v.check(x)
+}
+
+// check makes the checks.
+func (v *validation) check(x Serial) error {
+	var err error
+	_, node, _ := v.layout.unpack(x)
+	bits := v.layout.TimeBits + v.layout.NodeBits + v.layout.SequenceBits
+	switch {
+	case x < 0:
+		err = ErrNegative
+	case v.match && bits < 63 && x >= 1<<bits:
+		err = ErrLayoutMismatch
+	case v.node >= 0 && node != v.node:
+		err = ErrLayoutMismatch
+	case !v.notBefore.IsZero() && v.layout.timeOf(x).Before(v.notBefore):
+		err = ErrTooEarly
+	case v.maxAhead >= 0 && v.layout.timeOf(x).Sub(v.clock.Now()) > v.maxAhead:
+		err = ErrSkew
+	default:
+		return nil
+	}
+	return &ValidationError{Value: x, Err: err}
+}
@@ -0,0 +1,49 @@
+package serial
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clock := &fakeClock{now: now}
+	x := Serial(now.UnixNano())
+	for _, c := range []struct {
+		x    Serial
+		opts []ValidateOption
+		want error
+	}{
+		{x, nil, nil},
+		{-1, nil, ErrNegative},
+		{x, []ValidateOption{ValidateNotBefore(now.Add(-time.Hour))}, nil},
+		{x, []ValidateOption{ValidateNotBefore(now.Add(time.Hour))}, ErrTooEarly},
+		{x + Serial(time.Minute), []ValidateOption{ValidateClock(clock), ValidateMaxSkew(time.Second)}, ErrSkew},
+		{x + Serial(time.Second), []ValidateOption{ValidateClock(clock), ValidateMaxSkew(time.Second)}, nil},
+		{1 << 53, []ValidateOption{ValidateLayout(Layout{TimeBits: 41, SequenceBits: 12, Resolution: time.Millisecond})}, ErrLayoutMismatch},
+		{SnowflakeLayout.pack(1, 5, 0), []ValidateOption{ValidateLayout(SnowflakeLayout), ValidateNode(5)}, nil},
+		{SnowflakeLayout.pack(1, 4, 0), []ValidateOption{ValidateLayout(SnowflakeLayout), ValidateNode(5)}, ErrLayoutMismatch},
+	} {
+		err := Validate(c.x, c.opts...)
+		if !errors.Is(err, c.want) || (err == nil) != (c.want == nil) {
+			t.Errorf("Expected %v for %d got %v", c.want, c.x, err)
+		}
+		var ve *ValidationError
+		if err != nil && (!errors.As(err, &ve) || ve.Value != c.x) {
+			t.Errorf("Expected *ValidationError for %d got %v", c.x, err)
+		}
+	}
+	g := NewGenerator(WithClock(clock), WithLayout(SnowflakeLayout), WithNode(3))
+	y := g.Generate()
+	if err := g.Validate(y, ValidateNode(3), ValidateMaxSkew(time.Second)); err != nil {
+		t.Errorf("Expected %d to be valid, got %v", y, err)
+	}
+	clock.Advance(-time.Hour)
+	if err := g.Validate(y, ValidateMaxSkew(time.Second)); !errors.Is(err, ErrSkew) {
+		t.Errorf("Expected ErrSkew with the clock turned back, got %v", err)
+	}
+	if err := g.Validate(SnowflakeLayout.pack(1, 4, 0), ValidateNode(3)); !errors.Is(err, ErrLayoutMismatch) {
+		t.Errorf("Expected ErrLayoutMismatch got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package serial
+
+import (
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalYAML implements yaml.Marshaler, returning the serial value as a
+// YAML integer.
+func (x Serial) MarshalYAML() (interface{}, error) {
+	return int64(x), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, accepting the serial value as a
+// YAML integer or as a string of decimal digits.
+func (x *Serial) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.ScalarNode {
+		return ErrInvalidDecimal
+	}
+	return x.scanString(n.Value)
+}
+
+// MarshalYAML implements yaml.Marshaler, returning the layout as a string in
+// the form returned by String.
+func (l Layout) MarshalYAML() (interface{}, error) {
+	return l.String(), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, accepting a string in the forms
+// accepted by Set, or a mapping with the same keys, such as
+//
+//	layout:
+//	  time: 41
+//	  node: 10
+//	  sequence: 12
+//	  resolution: 1ms
+//	  epoch: 2020-01-01T00:00:00Z
+//
+// The result must be a usable layout, or ErrLayout is returned and the
+// layout is left unchanged.
+func (l *Layout) UnmarshalYAML(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return l.Set(n.Value)
+	case yaml.MappingNode:
+		var f struct {
+			Time       uint          `yaml:"time"`
+			Node       uint          `yaml:"node"`
+			Sequence   uint          `yaml:"sequence"`
+			Resolution time.Duration `yaml:"resolution"`
+			Epoch      time.Time     `yaml:"epoch"`
+		}
+		if err := n.Decode(&f); err != nil {
+			return ErrLayout
+		}
+		nl := Layout{TimeBits: f.Time, NodeBits: f.Node, SequenceBits: f.Sequence, Resolution: f.Resolution, Epoch: f.Epoch}
+		if err := nl.check(); err != nil {
+			return err
+		}
+		*l = nl
+		return nil
+	}
+	return ErrLayout
+}
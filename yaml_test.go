@@ -0,0 +1,62 @@
+package serial
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type yamlConfig struct {
+	Start  Serial   `yaml:"start"`
+	Skip   []Serial `yaml:"skip"`
+	Layout Layout   `yaml:"layout"`
+}
+
+func TestYAML(t *testing.T) {
+	c := yamlConfig{Start: 1<<62 + 1, Skip: []Serial{1, 2}, Layout: SnowflakeLayout}
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(b); s != "start: 4611686018427387905\nskip:\n    - 1\n    - 2\nlayout: snowflake\n" {
+		t.Errorf("Wrong YAML %q", s)
+	}
+	var p yamlConfig
+	if err := yaml.Unmarshal(b, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Start != c.Start || len(p.Skip) != 2 || p.Skip[1] != 2 || !sameLayout(p.Layout, SnowflakeLayout) {
+		t.Errorf("Round trip failed, got %+v", p)
+	}
+
+	doc := `
+start: "123"
+layout:
+  time: 41
+  node: 10
+  sequence: 12
+  resolution: 1ms
+  epoch: 2020-01-01T00:00:00Z
+`
+	if err := yaml.Unmarshal([]byte(doc), &p); err != nil {
+		t.Fatal(err)
+	}
+	want := Layout{TimeBits: 41, NodeBits: 10, SequenceBits: 12, Resolution: time.Millisecond,
+		Epoch: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if p.Start != 123 || !sameLayout(p.Layout, want) {
+		t.Errorf("Wrong values from YAML, got %+v", p)
+	}
+
+	for _, doc := range []string{"start: 12x", "start: [1]", "start: 1.5"} {
+		if err := yaml.Unmarshal([]byte(doc), &p); err != ErrInvalidDecimal {
+			t.Errorf("Expected ErrInvalidDecimal for %q got %v", doc, err)
+		}
+	}
+	for _, doc := range []string{"layout: minute", "layout: [1]", "layout: {time: 70, resolution: 1s}",
+		"layout: {time: 41}", "layout: {time: x}"} {
+		if err := yaml.Unmarshal([]byte(doc), &p); err != ErrLayout {
+			t.Errorf("Expected ErrLayout for %q got %v", doc, err)
+		}
+	}
+}